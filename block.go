@@ -0,0 +1,698 @@
+package influxdb
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/boltdb/bolt"
+)
+
+// maxPointsPerBlock is the maximum number of points stored in a single block.
+// Larger blocks compress better but cost more to rewrite on out-of-order writes.
+const maxPointsPerBlock = 1000
+
+// Column types stored in a block.
+const (
+	blockNumber  = uint8(1)
+	blockBoolean = uint8(2)
+	blockString  = uint8(3)
+	blockInteger = uint8(4)
+)
+
+// blockColumnType returns the column type used to store a value.
+// Returns zero if the value cannot be stored in a block.
+func blockColumnType(v interface{}) uint8 {
+	switch v.(type) {
+	case float64, int:
+		return blockNumber
+	case bool:
+		return blockBoolean
+	case string:
+		return blockString
+	case int64:
+		return blockInteger
+	}
+	return 0
+}
+
+// blockPoint represents a single decoded point within a series block.
+type blockPoint struct {
+	timestamp int64
+	values    map[uint8]interface{} // values by field id
+}
+
+// blockPoints represents a list of points sortable by timestamp.
+type blockPoints []blockPoint
+
+func (a blockPoints) Len() int           { return len(a) }
+func (a blockPoints) Less(i, j int) bool { return a[i].timestamp < a[j].timestamp }
+func (a blockPoints) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// search returns the index of the first point with a timestamp greater than or equal to timestamp.
+func (a blockPoints) search(timestamp int64) int {
+	return sort.Search(len(a), func(i int) bool { return a[i].timestamp >= timestamp })
+}
+
+// marshalBlock encodes a list of time-ordered points into a columnar block.
+//
+// The block starts with the point count and the last timestamp so that both
+// can be read without decoding the block. Then come the timestamps, which are
+// stored as the first timestamp, the first delta, and then the delta-of-delta
+// for every remaining point. After the timestamps comes one column per field.
+// Each column holds the field id, its type, the column length, a bitmap of the
+// points that have a value for the field and then the values themselves.
+// Numbers are XOR compressed against the previous value, integers are stored
+// as varint deltas from the previous value, booleans are stored as single bits
+// and strings are length prefixed.
+func marshalBlock(points []blockPoint) []byte {
+	b := make([]byte, 0, 64)
+	b = appendUvarint(b, uint64(len(points)))
+	if len(points) > 0 {
+		b = appendVarint(b, points[len(points)-1].timestamp)
+	}
+
+	// Encode timestamps.
+	var delta int64
+	for i, p := range points {
+		switch i {
+		case 0:
+			b = appendVarint(b, p.timestamp)
+		case 1:
+			delta = p.timestamp - points[0].timestamp
+			b = appendVarint(b, delta)
+		default:
+			d := p.timestamp - points[i-1].timestamp
+			b = appendVarint(b, d-delta)
+			delta = d
+		}
+	}
+
+	// Determine the set of field ids used in the block.
+	types := make(map[uint8]uint8)
+	for _, p := range points {
+		for id, v := range p.values {
+			if typ := blockColumnType(v); typ != 0 {
+				types[id] = typ
+			}
+		}
+	}
+	ids := make([]int, 0, len(types))
+	for id := range types {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	// Encode a column for each field.
+	b = appendUvarint(b, uint64(len(ids)))
+	for _, id := range ids {
+		col := marshalBlockColumn(uint8(id), types[uint8(id)], points)
+		b = append(b, uint8(id), types[uint8(id)])
+		b = appendUvarint(b, uint64(len(col)))
+		b = append(b, col...)
+	}
+
+	return b
+}
+
+// marshalBlockColumn encodes the presence bitmap and values for a single field.
+func marshalBlockColumn(id, typ uint8, points []blockPoint) []byte {
+	bitmap := make([]byte, (len(points)+7)/8)
+	var values []interface{}
+	for i, p := range points {
+		if v, ok := p.values[id]; ok && blockColumnType(v) == typ {
+			bitmap[i/8] |= 1 << uint(i%8)
+			values = append(values, v)
+		}
+	}
+
+	b := bitmap
+	switch typ {
+	case blockNumber:
+		var w floatEncoder
+		for _, v := range values {
+			w.write(toFloat64(v))
+		}
+		b = append(b, w.bytes()...)
+	case blockBoolean:
+		var w bitWriter
+		for _, v := range values {
+			w.writeBit(v.(bool))
+		}
+		b = append(b, w.bytes()...)
+	case blockString:
+		for _, v := range values {
+			s := v.(string)
+			b = appendUvarint(b, uint64(len(s)))
+			b = append(b, s...)
+		}
+	case blockInteger:
+		var prev int64
+		for _, v := range values {
+			b = appendVarint(b, v.(int64)-prev)
+			prev = v.(int64)
+		}
+	}
+	return b
+}
+
+// unmarshalBlockHeader decodes the point count and the last timestamp of a
+// block. Returns the remaining bytes of the block.
+func unmarshalBlockHeader(b []byte) (n int, last int64, other []byte, err error) {
+	count, sz := binary.Uvarint(b)
+	if sz <= 0 || count == 0 || count > uint64(len(b)) {
+		return 0, 0, nil, ErrInvalidBlock
+	}
+	b = b[sz:]
+
+	last, sz = binary.Varint(b)
+	if sz <= 0 {
+		return 0, 0, nil, ErrInvalidBlock
+	}
+	return int(count), last, b[sz:], nil
+}
+
+// unmarshalBlock decodes a block into a list of time-ordered points.
+func unmarshalBlock(b []byte) ([]blockPoint, error) {
+	// Read point count and last timestamp.
+	n, last, b, err := unmarshalBlockHeader(b)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode timestamps.
+	points := make([]blockPoint, n)
+	var delta int64
+	for i := range points {
+		v, sz := binary.Varint(b)
+		if sz <= 0 {
+			return nil, ErrInvalidBlock
+		}
+		b = b[sz:]
+
+		switch i {
+		case 0:
+			points[i].timestamp = v
+		case 1:
+			delta = v
+			points[i].timestamp = points[0].timestamp + delta
+		default:
+			delta += v
+			points[i].timestamp = points[i-1].timestamp + delta
+		}
+		points[i].values = make(map[uint8]interface{})
+	}
+	if points[n-1].timestamp != last {
+		return nil, ErrInvalidBlock
+	}
+
+	// Read column count.
+	colN, sz := binary.Uvarint(b)
+	if sz <= 0 {
+		return nil, ErrInvalidBlock
+	}
+	b = b[sz:]
+
+	// Decode each column.
+	for i := uint64(0); i < colN; i++ {
+		if len(b) < 2 {
+			return nil, ErrInvalidBlock
+		}
+		id, typ := b[0], b[1]
+		b = b[2:]
+
+		size, sz := binary.Uvarint(b)
+		if sz <= 0 || uint64(len(b)-sz) < size {
+			return nil, ErrInvalidBlock
+		}
+		if err := unmarshalBlockColumn(id, typ, b[sz:sz+int(size)], points); err != nil {
+			return nil, err
+		}
+		b = b[sz+int(size):]
+	}
+
+	return points, nil
+}
+
+// unmarshalBlockColumn decodes a single field column into a list of points.
+func unmarshalBlockColumn(id, typ uint8, b []byte, points []blockPoint) error {
+	// Read presence bitmap.
+	bitmapN := (len(points) + 7) / 8
+	if len(b) < bitmapN {
+		return ErrInvalidBlock
+	}
+	bitmap, b := b[:bitmapN], b[bitmapN:]
+
+	var fr *floatDecoder
+	var br *bitReader
+	var prev int64 // previous integer value
+	switch typ {
+	case blockNumber:
+		fr = newFloatDecoder(b)
+	case blockBoolean:
+		br = newBitReader(b)
+	case blockString, blockInteger:
+	default:
+		return ErrInvalidBlock
+	}
+
+	for i := range points {
+		if bitmap[i/8]&(1<<uint(i%8)) == 0 {
+			continue
+		}
+
+		switch typ {
+		case blockNumber:
+			v, err := fr.read()
+			if err != nil {
+				return err
+			}
+			points[i].values[id] = v
+		case blockBoolean:
+			v, err := br.readBit()
+			if err != nil {
+				return err
+			}
+			points[i].values[id] = v
+		case blockString:
+			size, sz := binary.Uvarint(b)
+			if sz <= 0 || uint64(len(b)-sz) < size {
+				return ErrInvalidBlock
+			}
+			points[i].values[id] = string(b[sz : sz+int(size)])
+			b = b[sz+int(size):]
+		case blockInteger:
+			delta, sz := binary.Varint(b)
+			if sz <= 0 {
+				return ErrInvalidBlock
+			}
+			prev += delta
+			points[i].values[id] = prev
+			b = b[sz:]
+		}
+	}
+	return nil
+}
+
+// writeBlocks merges a set of points into the blocks of a series bucket.
+// If overwrite is false then existing points with the same timestamp are kept.
+func writeBlocks(b *bolt.Bucket, points []blockPoint, overwrite bool) error {
+	sort.Stable(blockPoints(points))
+	if len(points) == 0 {
+		return nil
+	}
+
+	// Append points that are newer than every existing point without
+	// decoding the existing blocks.
+	if k, v := b.Cursor().Last(); k == nil {
+		return appendBlocks(b, mergeBlockPoints(nil, points, overwrite))
+	} else if _, last, _, err := unmarshalBlockHeader(v); err != nil {
+		return err
+	} else if points[0].timestamp > last {
+		return appendBlocks(b, mergeBlockPoints(nil, points, overwrite))
+	}
+
+	for len(points) > 0 {
+		// Find the block that the first point belongs to. This is the last block
+		// starting at or before the timestamp or the first block if none exist.
+		c := b.Cursor()
+		k, v := seekBlock(c, points[0].timestamp)
+		if k == nil {
+			k, v = c.First()
+		}
+
+		// Decode the existing block and determine where the next block starts.
+		var existing []blockPoint
+		i := len(points)
+		if k != nil {
+			var err error
+			if existing, err = unmarshalBlock(v); err != nil {
+				return err
+			}
+			if nk, _ := c.Next(); nk != nil {
+				i = blockPoints(points).search(int64(btou64(nk)))
+			}
+		}
+
+		// Split off the points that belong in this block.
+		merged := mergeBlockPoints(existing, points[:i], overwrite)
+		points = points[i:]
+
+		// Remove the old block and write the merged points in new blocks.
+		if k != nil {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		for len(merged) > 0 {
+			n := len(merged)
+			if n > maxPointsPerBlock {
+				n = maxPointsPerBlock
+			}
+			if err := b.Put(u64tob(uint64(merged[0].timestamp)), marshalBlock(merged[:n])); err != nil {
+				return err
+			}
+			merged = merged[n:]
+		}
+	}
+
+	return nil
+}
+
+// appendBlocks writes time-ordered points after the last block of a series
+// bucket. The last block is merged with each new block while it holds no more
+// points than the new block. Trailing blocks therefore grow geometrically and
+// every point is only rewritten a few times as single points are appended.
+func appendBlocks(b *bolt.Bucket, points []blockPoint) error {
+	for len(points) > 0 {
+		n := len(points)
+		if n > maxPointsPerBlock {
+			n = maxPointsPerBlock
+		}
+		block := points[:n]
+		points = points[n:]
+
+		// Merge trailing blocks that are no larger than the new block.
+		for {
+			k, v := b.Cursor().Last()
+			if k == nil {
+				break
+			}
+			count, _, _, err := unmarshalBlockHeader(v)
+			if err != nil {
+				return err
+			} else if count > len(block) || count+len(block) > maxPointsPerBlock {
+				break
+			}
+
+			existing, err := unmarshalBlock(v)
+			if err != nil {
+				return err
+			} else if err := b.Delete(k); err != nil {
+				return err
+			}
+			block = append(existing, block...)
+		}
+
+		if err := b.Put(u64tob(uint64(block[0].timestamp)), marshalBlock(block)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteBlockRange removes all points between min and max, inclusive, from a series bucket.
+func deleteBlockRange(b *bolt.Bucket, min, max int64) error {
+	// Collect the keys of all blocks that may overlap the range.
+	var keys [][]byte
+	c := b.Cursor()
+	k, _ := seekBlock(c, min)
+	if k == nil {
+		k, _ = c.First()
+	}
+	for ; k != nil && int64(btou64(k)) <= max; k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+
+	for _, k := range keys {
+		points, err := unmarshalBlock(b.Get(k))
+		if err != nil {
+			return err
+		}
+
+		// Keep only the points outside of the range.
+		other := make([]blockPoint, 0, len(points))
+		for _, p := range points {
+			if p.timestamp < min || p.timestamp > max {
+				other = append(other, p)
+			}
+		}
+		if len(other) == len(points) {
+			continue
+		}
+
+		// Replace the block with the remaining points, if any.
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+		if len(other) > 0 {
+			if err := b.Put(u64tob(uint64(other[0].timestamp)), marshalBlock(other)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// seekBlock moves the cursor to the last block starting at or before timestamp.
+// Returns a nil key if no block starts at or before the timestamp.
+func seekBlock(c *bolt.Cursor, timestamp int64) (k, v []byte) {
+	k, v = c.Seek(u64tob(uint64(timestamp)))
+	if k == nil {
+		return c.Last()
+	} else if int64(btou64(k)) > timestamp {
+		return c.Prev()
+	}
+	return k, v
+}
+
+// mergeBlockPoints merges two time-ordered lists of points.
+// Points with matching timestamps are taken from b if overwrite is true.
+func mergeBlockPoints(a, b []blockPoint, overwrite bool) []blockPoint {
+	// Remove duplicate timestamps from the new points.
+	// Later points win if overwriting, otherwise the first point is kept.
+	if len(b) > 1 {
+		other := make([]blockPoint, 1, len(b))
+		other[0] = b[0]
+		for _, p := range b[1:] {
+			if n := len(other); other[n-1].timestamp != p.timestamp {
+				other = append(other, p)
+			} else if overwrite {
+				other[n-1] = p
+			}
+		}
+		b = other
+	}
+
+	merged := make([]blockPoint, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0].timestamp < b[0].timestamp:
+			merged, a = append(merged, a[0]), a[1:]
+		case a[0].timestamp > b[0].timestamp:
+			merged, b = append(merged, b[0]), b[1:]
+		default:
+			if overwrite {
+				merged = append(merged, b[0])
+			} else {
+				merged = append(merged, a[0])
+			}
+			a, b = a[1:], b[1:]
+		}
+	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
+
+// toFloat64 converts a numeric value to a float64.
+func toFloat64(v interface{}) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	}
+	return 0
+}
+
+// appendUvarint appends an unsigned varint to a byte slice.
+func appendUvarint(b []byte, v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return append(b, buf[:binary.PutUvarint(buf, v)]...)
+}
+
+// appendVarint appends a signed, zig-zag encoded varint to a byte slice.
+func appendVarint(b []byte, v int64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return append(b, buf[:binary.PutVarint(buf, v)]...)
+}
+
+// floatEncoder compresses a series of floats by XORing each value against the
+// previous value and only storing the meaningful bits of the result.
+type floatEncoder struct {
+	w        bitWriter
+	prev     uint64
+	leading  int
+	trailing int
+	window   bool // true if leading & trailing are set
+	n        int
+}
+
+// write appends a value to the encoder.
+func (e *floatEncoder) write(f float64) {
+	v := math.Float64bits(f)
+	x := v ^ e.prev
+	e.prev = v
+	e.n++
+
+	// Write the first value in full.
+	if e.n == 1 {
+		e.w.writeBits(v, 64)
+		return
+	}
+
+	// Write a single zero bit if the value is unchanged.
+	if x == 0 {
+		e.w.writeBit(false)
+		return
+	}
+	e.w.writeBit(true)
+
+	leading, trailing := bits.LeadingZeros64(x), bits.TrailingZeros64(x)
+	if leading > 31 {
+		leading = 31
+	}
+
+	// Reuse the previous window of meaningful bits if the value fits inside it.
+	if e.window && leading >= e.leading && trailing >= e.trailing {
+		e.w.writeBit(false)
+		e.w.writeBits(x>>uint(e.trailing), 64-e.leading-e.trailing)
+		return
+	}
+
+	// Otherwise write a new window. A 64 bit window is stored as zero.
+	e.leading, e.trailing, e.window = leading, trailing, true
+	sigbits := 64 - leading - trailing
+	e.w.writeBit(true)
+	e.w.writeBits(uint64(leading), 5)
+	e.w.writeBits(uint64(sigbits&0x3F), 6)
+	e.w.writeBits(x>>uint(trailing), sigbits)
+}
+
+// bytes returns the encoded bytes.
+func (e *floatEncoder) bytes() []byte { return e.w.bytes() }
+
+// floatDecoder decodes values written by a floatEncoder.
+type floatDecoder struct {
+	r        *bitReader
+	prev     uint64
+	leading  int
+	trailing int
+	n        int
+}
+
+// newFloatDecoder returns a decoder for an encoded float column.
+func newFloatDecoder(b []byte) *floatDecoder { return &floatDecoder{r: newBitReader(b)} }
+
+// read returns the next value from the decoder.
+func (d *floatDecoder) read() (float64, error) {
+	// Read the first value in full.
+	if d.n == 0 {
+		v, err := d.r.readBits(64)
+		if err != nil {
+			return 0, err
+		}
+		d.prev, d.n = v, d.n+1
+		return math.Float64frombits(v), nil
+	}
+	d.n++
+
+	// A zero bit means the value is unchanged.
+	if changed, err := d.r.readBit(); err != nil {
+		return 0, err
+	} else if !changed {
+		return math.Float64frombits(d.prev), nil
+	}
+
+	// Read a new window if the control bit is set.
+	if newWindow, err := d.r.readBit(); err != nil {
+		return 0, err
+	} else if newWindow {
+		leading, err := d.r.readBits(5)
+		if err != nil {
+			return 0, err
+		}
+		sigbits, err := d.r.readBits(6)
+		if err != nil {
+			return 0, err
+		} else if sigbits == 0 {
+			sigbits = 64
+		}
+		d.leading, d.trailing = int(leading), 64-int(leading)-int(sigbits)
+		if d.trailing < 0 {
+			return 0, ErrInvalidBlock
+		}
+	}
+
+	x, err := d.r.readBits(64 - d.leading - d.trailing)
+	if err != nil {
+		return 0, err
+	}
+	d.prev ^= x << uint(d.trailing)
+	return math.Float64frombits(d.prev), nil
+}
+
+// bitWriter writes individual bits to a byte slice.
+type bitWriter struct {
+	buf []byte
+	n   uint // bits used in the last byte
+}
+
+// writeBit appends a single bit.
+func (w *bitWriter) writeBit(bit bool) {
+	if w.n == 0 || w.n == 8 {
+		w.buf = append(w.buf, 0)
+		w.n = 0
+	}
+	if bit {
+		w.buf[len(w.buf)-1] |= 1 << (7 - w.n)
+	}
+	w.n++
+}
+
+// writeBits appends the lowest n bits of v, most significant bit first.
+func (w *bitWriter) writeBits(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.writeBit(v&(1<<uint(i)) != 0)
+	}
+}
+
+// bytes returns the written bytes.
+func (w *bitWriter) bytes() []byte { return w.buf }
+
+// bitReader reads individual bits from a byte slice.
+type bitReader struct {
+	buf []byte
+	i   uint // bit index
+}
+
+// newBitReader returns a reader for b.
+func newBitReader(b []byte) *bitReader { return &bitReader{buf: b} }
+
+// readBit reads a single bit.
+func (r *bitReader) readBit() (bool, error) {
+	if r.i/8 >= uint(len(r.buf)) {
+		return false, ErrInvalidBlock
+	}
+	bit := r.buf[r.i/8]&(1<<(7-r.i%8)) != 0
+	r.i++
+	return bit, nil
+}
+
+// readBits reads n bits, most significant bit first.
+func (r *bitReader) readBits(n int) (uint64, error) {
+	var v uint64
+	for i := 0; i < n; i++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		v <<= 1
+		if bit {
+			v |= 1
+		}
+	}
+	return v, nil
+}
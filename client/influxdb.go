@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/influxdb/influxdb/influxql"
@@ -165,13 +166,17 @@ func (r *Result) UnmarshalJSON(b []byte) error {
 type Results struct {
 	Results []Result
 	Err     error
+
+	// Points rejected from a write.
+	Rejected []PointError
 }
 
 func (r Results) MarshalJSON() ([]byte, error) {
 	// Define a struct that outputs "error" as a string.
 	var o struct {
-		Results []Result `json:"results,omitempty"`
-		Err     string   `json:"error,omitempty"`
+		Results  []Result     `json:"results,omitempty"`
+		Err      string       `json:"error,omitempty"`
+		Rejected []PointError `json:"rejected,omitempty"`
 	}
 
 	// Copy fields to output struct.
@@ -179,6 +184,7 @@ func (r Results) MarshalJSON() ([]byte, error) {
 	if r.Err != nil {
 		o.Err = r.Err.Error()
 	}
+	o.Rejected = r.Rejected
 
 	return json.Marshal(&o)
 }
@@ -186,8 +192,9 @@ func (r Results) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON decodes the data into the Results struct
 func (r *Results) UnmarshalJSON(b []byte) error {
 	var o struct {
-		Results []Result `json:"results,omitempty"`
-		Err     string   `json:"error,omitempty"`
+		Results  []Result     `json:"results,omitempty"`
+		Err      string       `json:"error,omitempty"`
+		Rejected []PointError `json:"rejected,omitempty"`
 	}
 
 	dec := json.NewDecoder(bytes.NewBuffer(b))
@@ -200,6 +207,45 @@ func (r *Results) UnmarshalJSON(b []byte) error {
 	if o.Err != "" {
 		r.Err = errors.New(o.Err)
 	}
+	r.Rejected = o.Rejected
+	return nil
+}
+
+// PointError represents a point that was rejected from a write.
+type PointError struct {
+	Index int   // position of the point in the write
+	Err   error // reason the point was rejected
+}
+
+// Error returns the reason the point was rejected prefixed with its index.
+func (e PointError) Error() string {
+	return fmt.Sprintf("point %d: %s", e.Index, e.Err)
+}
+
+// MarshalJSON encodes the point error into JSON.
+func (e PointError) MarshalJSON() ([]byte, error) {
+	var o struct {
+		Index int    `json:"index"`
+		Err   string `json:"error"`
+	}
+	o.Index = e.Index
+	if e.Err != nil {
+		o.Err = e.Err.Error()
+	}
+	return json.Marshal(&o)
+}
+
+// UnmarshalJSON decodes the data into the PointError struct
+func (e *PointError) UnmarshalJSON(b []byte) error {
+	var o struct {
+		Index int    `json:"index"`
+		Err   string `json:"error"`
+	}
+	if err := json.Unmarshal(b, &o); err != nil {
+		return err
+	}
+	e.Index = o.Index
+	e.Err = errors.New(o.Err)
 	return nil
 }
 
@@ -232,13 +278,16 @@ func (t Timestamp) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + s + `"`), nil
 }
 
-// Point defines the values that will be written to the database
+// Point defines the values that will be written to the database.
+// Numeric values are written as floats unless Integers is set, in which case
+// whole numbers are written as 64-bit integers.
 type Point struct {
 	Name      string                 `json:"name"`
 	Tags      map[string]string      `json:"tags"`
 	Timestamp Timestamp              `json:"timestamp"`
 	Values    map[string]interface{} `json:"values"`
 	Precision string                 `json:"precision"`
+	Integers  bool                   `json:"integers,omitempty"`
 }
 
 // UnmarshalJSON decodes the data into the Point struct
@@ -249,6 +298,7 @@ func (p *Point) UnmarshalJSON(b []byte) error {
 		Timestamp time.Time              `json:"timestamp"`
 		Precision string                 `json:"precision"`
 		Values    map[string]interface{} `json:"values"`
+		Integers  bool                   `json:"integers"`
 	}
 	var epoch struct {
 		Name      string                 `json:"name"`
@@ -256,6 +306,7 @@ func (p *Point) UnmarshalJSON(b []byte) error {
 		Timestamp *int64                 `json:"timestamp"`
 		Precision string                 `json:"precision"`
 		Values    map[string]interface{} `json:"values"`
+		Integers  bool                   `json:"integers"`
 	}
 
 	if err := func() error {
@@ -278,7 +329,8 @@ func (p *Point) UnmarshalJSON(b []byte) error {
 		p.Tags = epoch.Tags
 		p.Timestamp = Timestamp(ts)
 		p.Precision = epoch.Precision
-		p.Values = normalizeValues(epoch.Values)
+		p.Integers = epoch.Integers
+		p.Values = normalizeValues(epoch.Values, epoch.Integers)
 		return nil
 	}(); err == nil {
 		return nil
@@ -294,18 +346,27 @@ func (p *Point) UnmarshalJSON(b []byte) error {
 	p.Tags = normal.Tags
 	p.Timestamp = Timestamp(normal.Timestamp)
 	p.Precision = normal.Precision
-	p.Values = normalizeValues(normal.Values)
+	p.Integers = normal.Integers
+	p.Values = normalizeValues(normal.Values, normal.Integers)
 
 	return nil
 }
 
-// Remove any notion of json.Number
-func normalizeValues(values map[string]interface{}) map[string]interface{} {
+// Remove any notion of json.Number. Numbers become float64 values unless
+// integers is true, in which case numbers without a fraction or exponent that
+// fit in 64 bits become int64 values.
+func normalizeValues(values map[string]interface{}, integers bool) map[string]interface{} {
 	newValues := map[string]interface{}{}
 
 	for k, v := range values {
 		switch v := v.(type) {
 		case json.Number:
+			if integers && !strings.ContainsAny(string(v), ".eE") {
+				if iv, e := v.Int64(); e == nil {
+					newValues[k] = iv
+					continue
+				}
+			}
 			jv, e := v.Float64()
 			if e != nil {
 				panic(fmt.Sprintf("unable to convert json.Number to float64: %s", e))
@@ -2,10 +2,12 @@ package client_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"testing"
 	"time"
 
@@ -118,6 +120,35 @@ func TestClient_Write(t *testing.T) {
 	}
 }
 
+func TestClient_Write_Rejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := influxdb.Results{
+			Err:      errors.New("1 of 2 points rejected, point 1: values required"),
+			Rejected: []*influxdb.PointError{{Index: 1, Err: influxdb.ErrValuesRequired}},
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(data)
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	c, err := client.NewClient(client.Config{URL: *u})
+	if err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	}
+
+	results, err := c.Write(client.Write{})
+	if err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	} else if results.Error() == nil || results.Error().Error() != "1 of 2 points rejected, point 1: values required" {
+		t.Fatalf("unexpected results error: %v", results.Error())
+	} else if len(results.Rejected) != 1 {
+		t.Fatalf("unexpected rejected count: %d", len(results.Rejected))
+	} else if e := results.Rejected[0]; e.Index != 1 || e.Err.Error() != "values required" {
+		t.Fatalf("unexpected rejected point: %s", e)
+	}
+}
+
 func TestPoint_UnmarshalEpoch(t *testing.T) {
 	now := time.Now()
 	tests := []struct {
@@ -229,6 +260,28 @@ func TestPoint_UnmarshalRFC(t *testing.T) {
 	}
 }
 
+func TestPoint_UnmarshalValues(t *testing.T) {
+	// Numbers are floats by default.
+	var p client.Point
+	if err := json.Unmarshal([]byte(`{"name":"cpu","values":{"count":100,"value":1.5}}`), &p); err != nil {
+		t.Fatalf("unexpected error.  exptected: %v, actual: %v", nil, err)
+	}
+	exp := map[string]interface{}{"count": float64(100), "value": float64(1.5)}
+	if !reflect.DeepEqual(p.Values, exp) {
+		t.Fatalf("unexpected values.  expected: %#v, actual: %#v", exp, p.Values)
+	}
+
+	// Whole numbers are integers when integers are enabled.
+	p = client.Point{}
+	if err := json.Unmarshal([]byte(`{"name":"cpu","integers":true,"values":{"count":9007199254740993,"value":1.5,"exp":1e3,"big":18446744073709551616}}`), &p); err != nil {
+		t.Fatalf("unexpected error.  exptected: %v, actual: %v", nil, err)
+	}
+	exp = map[string]interface{}{"count": int64(9007199254740993), "value": float64(1.5), "exp": float64(1000), "big": float64(18446744073709551616)}
+	if !reflect.DeepEqual(p.Values, exp) {
+		t.Fatalf("unexpected values.  expected: %#v, actual: %#v", exp, p.Values)
+	}
+}
+
 func TestEpochToTime(t *testing.T) {
 	now := time.Now()
 
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+)
+
+// execBroker runs the "broker" command.
+func execBroker(args []string) {
+	// Parse command flags.
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	brokerURL := fs.String("url", fmt.Sprintf("http://localhost:%d", DefaultBrokerPort), "")
+	fs.Usage = printBrokerUsage
+	fs.Parse(args)
+
+	// Parse the subcommand and broker id.
+	if fs.NArg() != 2 {
+		printBrokerUsage()
+		os.Exit(2)
+	}
+	id, err := strconv.ParseUint(fs.Arg(1), 10, 64)
+	if err != nil || id == 0 {
+		log.Fatalf("invalid broker id: %s", fs.Arg(1))
+	}
+	u, err := url.Parse(*brokerURL)
+	if err != nil {
+		log.Fatalf("invalid broker url: %s", *brokerURL)
+	}
+
+	switch fs.Arg(0) {
+	case "remove":
+		if err := brokerRequest(u, "raft/leave", id); err != nil {
+			log.Fatalf("remove broker: %s", err)
+		}
+		log.Printf("broker %d removed", id)
+	case "transfer":
+		if err := brokerRequest(u, "raft/transfer", id); err != nil {
+			log.Fatalf("transfer leadership: %s", err)
+		}
+		log.Printf("leadership transferred to broker %d", id)
+	default:
+		log.Fatalf(`influxd broker: unknown command "%s"`+"\n"+`Run 'influxd help broker' for usage`+"\n\n", fs.Arg(0))
+	}
+}
+
+// brokerRequest sends a membership request for a broker id to the raft handler.
+// Requests sent to a follower are redirected to the leader.
+func brokerRequest(u *url.URL, p string, id uint64) error {
+	other := *u
+	other.Path = path.Join(u.Path, p)
+	other.RawQuery = url.Values{"id": {strconv.FormatUint(id, 10)}}.Encode()
+
+	resp, err := http.Get(other.String())
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if s := resp.Header.Get("X-Raft-Error"); s != "" {
+		return errors.New(s)
+	} else if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func printBrokerUsage() {
+	log.Printf(`usage: broker [flags] <command> <id>
+
+broker manages the membership of the broker cluster. Requests may be sent to
+any broker and are forwarded to the leader.
+
+The commands are:
+
+    remove <id>          removes a broker from the cluster
+    transfer <id>        transfers leadership to a broker
+
+The leader cannot be removed. Transfer leadership to another broker first.
+
+        -url <url>
+                          URL of a broker in the cluster. Defaults to
+                          http://localhost:8086.
+`)
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/influxdb/influxdb/collectd"
 	"github.com/influxdb/influxdb/graphite"
+	"github.com/influxdb/influxdb/httpd"
+	"github.com/influxdb/influxdb/raft"
 )
 
 const (
@@ -34,11 +36,18 @@ const (
 	// DefaultBrokerPort represents the default port the broker runs on.
 	DefaultBrokerPort = 8086
 
+	// DefaultBrokerTransport represents the default transport used between brokers.
+	DefaultBrokerTransport = "http"
+
 	// DefaultDataPort represents the default port the data server runs on.
 	DefaultDataPort = 8086
 
 	// DefaultJoinURLs represents the default URLs for joining a cluster.
 	DefaultJoinURLs = ""
+
+	// DefaultHeartbeatInterval represents how often a data node reports its
+	// applied indexes to the broker.
+	DefaultHeartbeatInterval = 1 * time.Second
 )
 
 // Config represents the configuration format for the influxd binary.
@@ -67,6 +76,7 @@ type Config struct {
 		SSLPort     int      `toml:"ssl-port"`
 		SSLCertPath string   `toml:"ssl-cert"`
 		ReadTimeout Duration `toml:"read-timeout"`
+		MaxBodySize Size     `toml:"max-body-size"`
 	} `toml:"api"`
 
 	Graphites []Graphite `toml:"graphite"`
@@ -86,9 +96,11 @@ type Config struct {
 	} `toml:"input_plugins"`
 
 	Broker struct {
-		Port    int      `toml:"port"`
-		Dir     string   `toml:"dir"`
-		Timeout Duration `toml:"election-timeout"`
+		Port              int      `toml:"port"`
+		Dir               string   `toml:"dir"`
+		Timeout           Duration `toml:"election-timeout"`
+		SnapshotThreshold uint64   `toml:"snapshot-threshold"`
+		Transport         string   `toml:"raft-transport"`
 	} `toml:"broker"`
 
 	Data struct {
@@ -96,6 +108,7 @@ type Config struct {
 		Port                  int      `toml:"port"`
 		RetentionCheckEnabled bool     `toml:"retention-check-enabled"`
 		RetentionCheckPeriod  Duration `toml:"retention-check-period"`
+		HeartbeatInterval     Duration `toml:"heartbeat-interval"`
 	} `toml:"data"`
 
 	Cluster struct {
@@ -144,10 +157,14 @@ func NewConfig() *Config {
 	c.Broker.Dir = filepath.Join(u.HomeDir, ".influxdb/broker")
 	c.Broker.Port = DefaultBrokerPort
 	c.Broker.Timeout = Duration(1 * time.Second)
+	c.Broker.SnapshotThreshold = raft.DefaultSnapshotThreshold
+	c.Broker.Transport = DefaultBrokerTransport
 	c.Data.Dir = filepath.Join(u.HomeDir, ".influxdb/data")
 	c.Data.Port = DefaultDataPort
 	c.Data.RetentionCheckEnabled = true
 	c.Data.RetentionCheckPeriod = Duration(10 * time.Minute)
+	c.Data.HeartbeatInterval = Duration(DefaultHeartbeatInterval)
+	c.HTTPAPI.MaxBodySize = Size(httpd.DefaultMaxBodySize)
 	c.Admin.Enabled = true
 	c.Admin.Port = 8083
 	c.ContinuousQuery.RecomputePreviousN = 2
@@ -196,6 +213,17 @@ func (c *Config) BrokerURL() *url.URL {
 	}
 }
 
+// BrokerTransport returns the raft transport used to communicate with other brokers.
+func (c *Config) BrokerTransport() (raft.Transport, error) {
+	switch c.Broker.Transport {
+	case "", "http":
+		return &raft.HTTPTransport{}, nil
+	case "tcp":
+		return &raft.TCPTransport{}, nil
+	}
+	return nil, fmt.Errorf("unknown raft transport: %q", c.Broker.Transport)
+}
+
 // BrokerDir returns the data directory to start up in and does home directory expansion if necessary.
 func (c *Config) BrokerDir() string {
 	p, e := filepath.Abs(c.Broker.Dir)
@@ -62,6 +62,10 @@ func TestParseConfig(t *testing.T) {
 		t.Fatalf("admin port mismatch: %v", c.Admin.Port)
 	}
 
+	if c.HTTPAPI.MaxBodySize != 10*(1<<20) {
+		t.Fatalf("api max body size mismatch: %v", c.HTTPAPI.MaxBodySize)
+	}
+
 	if c.Data.Port != main.DefaultBrokerPort {
 		t.Fatalf("data port mismatch: %v", c.Data.Port)
 	}
@@ -121,6 +125,10 @@ func TestParseConfig(t *testing.T) {
 		t.Fatalf("broker dir mismatch: %v", c.Broker.Dir)
 	} else if time.Duration(c.Broker.Timeout) != time.Second {
 		t.Fatalf("broker duration mismatch: %v", c.Broker.Timeout)
+	} else if c.Broker.SnapshotThreshold != 500 {
+		t.Fatalf("broker snapshot threshold mismatch: %v", c.Broker.SnapshotThreshold)
+	} else if c.Broker.Transport != "tcp" {
+		t.Fatalf("broker transport mismatch: %v", c.Broker.Transport)
 	}
 
 	if c.Data.Dir != "/tmp/influxdb/development/db" {
@@ -133,6 +141,10 @@ func TestParseConfig(t *testing.T) {
 		t.Fatalf("Retention check period mismatch: %v", c.Data.RetentionCheckPeriod)
 	}
 
+	if c.Data.HeartbeatInterval != main.Duration(2*time.Second) {
+		t.Fatalf("Heartbeat interval mismatch: %v", c.Data.HeartbeatInterval)
+	}
+
 	if c.Cluster.Dir != "/tmp/influxdb/development/cluster" {
 		t.Fatalf("cluster dir mismatch: %v", c.Cluster.Dir)
 	}
@@ -181,6 +193,7 @@ ssl-cert = "../cert.pem"
 # and keep alive connections they don't use won't end up connection a million times.
 # However, if a request is taking longer than this to complete, could be a problem.
 read-timeout = "5s"
+max-body-size = "10m"
 
 [input_plugins]
 
@@ -225,10 +238,16 @@ dir  = "/tmp/influxdb/development/broker"
 
 # election-timeout = "2s"
 
+# Applied raft log entries kept in memory for replicating to other brokers.
+snapshot-threshold = 500
+
+raft-transport = "tcp"
+
 [data]
 dir = "/tmp/influxdb/development/db"
 retention-check-enabled = true
 retention-check-period = "5m"
+heartbeat-interval = "2s"
 
 [cluster]
 dir = "/tmp/influxdb/development/cluster"
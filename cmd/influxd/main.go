@@ -60,6 +60,8 @@ func main() {
 		execRun(args[1:])
 	case "":
 		execRun(args)
+	case "broker":
+		execBroker(args[1:])
 	case "version":
 		execVersion(args[1:])
 	case "help":
@@ -137,6 +139,7 @@ Usage:
 
 The commands are:
 
+    broker               manage the membership of the broker cluster
     join-cluster         create a new node that will join an existing cluster
     run                  run node with existing configuration
     version              displays the InfluxDB version
@@ -20,6 +20,7 @@ import (
 	"github.com/influxdb/influxdb/graphite"
 	"github.com/influxdb/influxdb/httpd"
 	"github.com/influxdb/influxdb/messaging"
+	"github.com/influxdb/influxdb/raft"
 )
 
 func Run(config *Config, join, version string, logWriter *os.File) (*messaging.Broker, *influxdb.Server) {
@@ -38,8 +39,14 @@ func Run(config *Config, join, version string, logWriter *os.File) (*messaging.B
 		joinURLs = parseURLs(join)
 	}
 
+	// Determine how brokers communicate with each other.
+	transport, err := config.BrokerTransport()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Open broker, initialize or join as necessary.
-	b := openBroker(config.BrokerDir(), config.BrokerURL(), initializing, joinURLs, logWriter)
+	b := openBroker(config.BrokerDir(), config.BrokerURL(), config.Broker.SnapshotThreshold, transport, initializing, joinURLs, logWriter)
 
 	// Start the broker handler.
 	var h *Handler
@@ -70,9 +77,15 @@ func Run(config *Config, join, version string, logWriter *os.File) (*messaging.B
 		log.Printf("broker enforcing retention policies with check interval of %s", interval)
 	}
 
+	// Report replication progress to the broker.
+	if err := s.StartHeartbeats(time.Duration(config.Data.HeartbeatInterval)); err != nil {
+		log.Fatalf("heartbeats failed: %s", err.Error())
+	}
+
 	// Start the server handler. Attach to broker if listening on the same port.
 	if s != nil {
 		sh := httpd.NewHandler(s, config.Authentication.Enabled, version)
+		sh.MaxBodySize = int64(config.HTTPAPI.MaxBodySize)
 		if h != nil && config.BrokerAddr() == config.DataAddr() {
 			h.serverHandler = sh
 		} else {
@@ -179,7 +192,7 @@ func parseConfig(path, hostname string) *Config {
 }
 
 // creates and initializes a broker.
-func openBroker(path string, u *url.URL, initializing bool, joinURLs []*url.URL, w io.Writer) *influxdb.Broker {
+func openBroker(path string, u *url.URL, snapshotThreshold uint64, transport raft.Transport, initializing bool, joinURLs []*url.URL, w io.Writer) *influxdb.Broker {
 	// Ignore if there's no existing broker and we're not initializing or joining.
 	if !fileExists(path) && !initializing && len(joinURLs) == 0 {
 		return nil
@@ -188,6 +201,8 @@ func openBroker(path string, u *url.URL, initializing bool, joinURLs []*url.URL,
 	// Create broker.
 	b := influxdb.NewBroker()
 	b.SetLogOutput(w)
+	b.SetSnapshotThreshold(snapshotThreshold)
+	b.SetTransport(transport)
 
 	if err := b.Open(path, u); err != nil {
 		log.Fatalf("failed to open broker: %s", err)
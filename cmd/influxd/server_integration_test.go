@@ -40,6 +40,16 @@ type node struct {
 // cluster represents a multi-node cluster.
 type cluster []node
 
+// Close shuts down every data node and broker in the cluster.
+func (c cluster) Close() {
+	for _, n := range c {
+		_ = n.server.Close()
+	}
+	for _, n := range c {
+		_ = n.broker.Close()
+	}
+}
+
 // createCombinedNodeCluster creates a cluster of nServers nodes, each of which
 // runs as both a Broker and Data node. If any part cluster creation fails,
 // the testing is marked as failed.
@@ -179,10 +189,10 @@ func createDatabase(t *testing.T, testName string, nodes cluster, database strin
 }
 
 // createRetentionPolicy creates a retetention policy and verifies that the creation was successful.
-func createRetentionPolicy(t *testing.T, testName string, nodes cluster, database, retention string) {
+func createRetentionPolicy(t *testing.T, testName string, nodes cluster, database, retention string, replicationFactor int) {
 	t.Log("Creating retention policy")
 	serverURL := nodes[0].url
-	replication := fmt.Sprintf("CREATE RETENTION POLICY bar ON foo DURATION 1h REPLICATION %d DEFAULT", len(nodes))
+	replication := fmt.Sprintf("CREATE RETENTION POLICY bar ON foo DURATION 1h REPLICATION %d DEFAULT", replicationFactor)
 
 	u := urlFor(serverURL, "query", url.Values{"q": []string{replication}})
 	resp, err := http.Get(u.String())
@@ -281,9 +291,10 @@ func Test_ServerSingleIntegration(t *testing.T) {
 	testName := "single node"
 	now := time.Now().UTC()
 	nodes := createCombinedNodeCluster(t, "single node", nNodes, basePort)
+	defer nodes.Close()
 
 	createDatabase(t, testName, nodes, "foo")
-	createRetentionPolicy(t, testName, nodes, "foo", "bar")
+	createRetentionPolicy(t, testName, nodes, "foo", "bar", len(nodes))
 	write(t, testName, nodes, fmt.Sprintf(`
 {
 "database":
@@ -330,9 +341,10 @@ func Test_Server3NodeIntegration(t *testing.T) {
 	testName := "3 node"
 	now := time.Now().UTC()
 	nodes := createCombinedNodeCluster(t, testName, nNodes, basePort)
+	defer nodes.Close()
 
 	createDatabase(t, testName, nodes, "foo")
-	createRetentionPolicy(t, testName, nodes, "foo", "bar")
+	createRetentionPolicy(t, testName, nodes, "foo", "bar", len(nodes))
 	write(t, testName, nodes, fmt.Sprintf(`
 {
 "database":
@@ -379,9 +391,10 @@ func Test_Server5NodeIntegration(t *testing.T) {
 	testName := "5 node"
 	now := time.Now().UTC()
 	nodes := createCombinedNodeCluster(t, testName, nNodes, basePort)
+	defer nodes.Close()
 
 	createDatabase(t, testName, nodes, "foo")
-	createRetentionPolicy(t, testName, nodes, "foo", "bar")
+	createRetentionPolicy(t, testName, nodes, "foo", "bar", len(nodes))
 	write(t, testName, nodes, fmt.Sprintf(`
 {
 "database":
@@ -419,3 +432,180 @@ func Test_Server5NodeIntegration(t *testing.T) {
 
 	simpleQuery(t, testName, nodes[:1], `select value from "foo"."bar".cpu`, expectedResults)
 }
+
+func Test_Server3NodeDistributedQueryIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	nNodes := 3
+	basePort := 8390
+	testName := "3 node distributed query"
+	now := time.Now().UTC()
+	nodes := createCombinedNodeCluster(t, testName, nNodes, basePort)
+	defer nodes.Close()
+
+	// Store each series on a single node so every query must read from remote shards.
+	createDatabase(t, testName, nodes, "foo")
+	createRetentionPolicy(t, testName, nodes, "foo", "bar", 1)
+	write(t, testName, nodes, fmt.Sprintf(`
+{
+"database": "foo",
+"retentionPolicy": "bar",
+"points": [
+	{"name": "cpu", "tags": {"host": "server01"}, "timestamp": %d, "precision": "n", "values": {"value": 100}},
+	{"name": "cpu", "tags": {"host": "server02"}, "timestamp": %d, "precision": "n", "values": {"value": 200}},
+	{"name": "cpu", "tags": {"host": "server03"}, "timestamp": %d, "precision": "n", "values": {"value": 300}}
+]
+}
+`, now.UnixNano(), now.Add(1*time.Millisecond).UnixNano(), now.Add(2*time.Millisecond).UnixNano()))
+
+	simpleQuery(t, testName, nodes, `select value from "foo"."bar".cpu`, client.Results{
+		Results: []client.Result{
+			{Rows: []influxql.Row{
+				{
+					Name:    "cpu",
+					Columns: []string{"time", "value"},
+					Values: [][]interface{}{
+						[]interface{}{now.Format(time.RFC3339Nano), json.Number("100")},
+						[]interface{}{now.Add(1 * time.Millisecond).Format(time.RFC3339Nano), json.Number("200")},
+						[]interface{}{now.Add(2 * time.Millisecond).Format(time.RFC3339Nano), json.Number("300")},
+					},
+				}}},
+		},
+	})
+
+	simpleQuery(t, testName, nodes, `select sum(value) from "foo"."bar".cpu`, client.Results{
+		Results: []client.Result{
+			{Rows: []influxql.Row{
+				{
+					Name:    "cpu",
+					Columns: []string{"time", "sum"},
+					Values: [][]interface{}{
+						[]interface{}{time.Unix(0, 0).UTC().Format(time.RFC3339Nano), json.Number("600")},
+					},
+				}}},
+		},
+	})
+}
+
+func Test_Server3NodeRebalanceIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	nNodes := 3
+	basePort := 8490
+	testName := "3 node rebalance"
+	now := time.Now().UTC()
+	nodes := createCombinedNodeCluster(t, testName, nNodes, basePort)
+	defer nodes.Close()
+
+	// Store each series on a single node.
+	createDatabase(t, testName, nodes, "foo")
+	createRetentionPolicy(t, testName, nodes, "foo", "bar", 1)
+	write(t, testName, nodes, fmt.Sprintf(`
+{
+"database": "foo",
+"retentionPolicy": "bar",
+"points": [
+	{"name": "cpu", "tags": {"host": "server01"}, "timestamp": %d, "precision": "n", "values": {"value": 100}},
+	{"name": "cpu", "tags": {"host": "server02"}, "timestamp": %d, "precision": "n", "values": {"value": 200}},
+	{"name": "cpu", "tags": {"host": "server03"}, "timestamp": %d, "precision": "n", "values": {"value": 300}}
+]
+}
+`, now.UnixNano(), now.Add(1*time.Millisecond).UnixNano(), now.Add(2*time.Millisecond).UnixNano()))
+
+	expected := client.Results{
+		Results: []client.Result{
+			{Rows: []influxql.Row{
+				{
+					Name:    "cpu",
+					Columns: []string{"time", "value"},
+					Values: [][]interface{}{
+						[]interface{}{now.Format(time.RFC3339Nano), json.Number("100")},
+						[]interface{}{now.Add(1 * time.Millisecond).Format(time.RFC3339Nano), json.Number("200")},
+						[]interface{}{now.Add(2 * time.Millisecond).Format(time.RFC3339Nano), json.Number("300")},
+					},
+				}}},
+		},
+	}
+	simpleQuery(t, testName, nodes, `select value from "foo"."bar".cpu`, expected)
+
+	// Copy every shard to every node.
+	simpleQuery(t, testName, nodes[:1], `ALTER RETENTION POLICY bar ON foo REPLICATION 3`, client.Results{Results: []client.Result{{}}})
+	simpleQuery(t, testName, nodes[:1], `REBALANCE SHARDS`, client.Results{Results: []client.Result{{}}})
+
+	// Remove the third node and rebalance so that it deletes its shards.
+	req, _ := http.NewRequest("DELETE", urlFor(nodes[0].url, fmt.Sprintf("data_nodes/%d", nodes[2].server.ID()), url.Values{}).String(), nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Couldn't delete data node: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Delete data node failed.  Unexpected status code.  expected: %d, actual %d", http.StatusNoContent, resp.StatusCode)
+	}
+
+	resp, err = http.Post(urlFor(nodes[0].url, "rebalance", url.Values{}).String(), "", nil)
+	if err != nil {
+		t.Fatalf("Couldn't rebalance: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Rebalance failed.  Unexpected status code.  expected: %d, actual %d", http.StatusNoContent, resp.StatusCode)
+	}
+
+	// The remaining nodes should serve all data from their copies.
+	simpleQuery(t, testName, nodes[:2], `select value from "foo"."bar".cpu`, expected)
+}
+
+func Test_Server3NodeLeadershipTransferIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	nNodes := 3
+	basePort := 8590
+	testName := "3 node leadership transfer"
+	now := time.Now().UTC()
+	nodes := createCombinedNodeCluster(t, testName, nNodes, basePort)
+	defer nodes.Close()
+
+	createDatabase(t, testName, nodes, "foo")
+	createRetentionPolicy(t, testName, nodes, "foo", "bar", len(nodes))
+
+	// Transfer leadership to the second broker through a follower.
+	resp, err := http.Get(urlFor(nodes[2].url, "raft/transfer", url.Values{"id": {"2"}}).String())
+	if err != nil {
+		t.Fatalf("Couldn't transfer leadership: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Transfer failed.  Unexpected status code.  expected: %d, actual %d, %s", http.StatusOK, resp.StatusCode, resp.Header.Get("X-Raft-Error"))
+	} else if !nodes[1].broker.IsLeader() {
+		t.Fatalf("Test %s: expected broker 2 to be leader", testName)
+	} else if nodes[0].broker.IsLeader() {
+		t.Fatalf("Test %s: expected broker 1 to step down", testName)
+	}
+
+	// Writes should be accepted through the new leader.
+	write(t, testName, nodes, fmt.Sprintf(`
+{
+"database": "foo",
+"retentionPolicy": "bar",
+"points": [{"name": "cpu", "tags": {"host": "server01"}, "timestamp": %d, "precision": "n", "values": {"value": 100}}]
+}
+`, now.UnixNano()))
+
+	expected := client.Results{
+		Results: []client.Result{
+			{Rows: []influxql.Row{
+				{
+					Name:    "cpu",
+					Columns: []string{"time", "value"},
+					Values: [][]interface{}{
+						[]interface{}{now.Format(time.RFC3339Nano), json.Number("100")},
+					},
+				}}},
+		},
+	}
+	simpleQuery(t, testName, nodes, `select value from "foo"."bar".cpu`, expected)
+}
@@ -144,7 +144,7 @@ func NewMeasurement(name string) *Measurement {
 func (m *Measurement) createFieldIfNotExists(name string, typ influxql.DataType) error {
 	// Ignore if the field already exists.
 	if f := m.FieldByName(name); f != nil {
-		if f.Type != typ {
+		if !fieldTypeAccepts(f.Type, typ) {
 			return ErrFieldTypeConflict
 		}
 		return nil
@@ -221,24 +221,101 @@ func (m *Measurement) addSeries(s *Series) bool {
 	return true
 }
 
+// dropSeries will remove a series from the measurementIndex. Returns false if not present
+func (m *Measurement) dropSeries(seriesID uint32) bool {
+	s := m.seriesByID[seriesID]
+	if s == nil {
+		return false
+	}
+	delete(m.seriesByID, seriesID)
+	delete(m.series, string(marshalTags(s.Tags)))
+	m.seriesIDs = m.seriesIDs.reject(seriesIDs{seriesID})
+
+	// remove this series id from the tag index on the measurement
+	for k, v := range s.Tags {
+		valueMap := m.seriesByTagKeyValue[k]
+		if ids := valueMap[v].reject(seriesIDs{seriesID}); len(ids) > 0 {
+			valueMap[v] = ids
+			continue
+		}
+
+		// remove the tag value and key entirely if no other series use them
+		delete(valueMap, v)
+		if len(valueMap) == 0 {
+			delete(m.seriesByTagKeyValue, k)
+		}
+	}
+
+	return true
+}
+
 // seriesByTags returns the Series that matches the given tagset.
 func (m *Measurement) seriesByTags(tags map[string]string) *Series {
 	return m.series[string(marshalTags(tags))]
 }
 
 func (m *Measurement) seriesIDsAndFilters(stmt *influxql.SelectStatement) (seriesIDs, map[uint32]influxql.Expr) {
-	seriesIdsToExpr := make(map[uint32]influxql.Expr)
 	if stmt.Condition == nil {
 		return m.seriesIDs, nil
 	}
-	ids, _, _ := m.walkWhereForSeriesIds(stmt.Condition, seriesIdsToExpr)
 
-	// ids will be empty if all they had was a time in the where clause. so return all measurement series ids
-	if len(ids) == 0 && stmt.OnlyTimeDimensions() {
-		return m.seriesIDs, nil
+	// Narrow down the series using the tag conditions.
+	// If the where clause only restricts time then all series are included.
+	ids, ok, _ := m.walkWhereForSeriesIds(stmt.Condition, make(map[uint32]influxql.Expr))
+	if !ok {
+		ids = m.seriesIDs
+	}
+
+	// Reduce the condition for each series using its tag values. Series whose
+	// tags can never match are removed and the remaining field expression is
+	// evaluated against every point read for the series.
+	var a seriesIDs
+	seriesIdsToExpr := make(map[uint32]influxql.Expr)
+	for _, id := range ids {
+		expr := m.seriesFilter(stmt.Condition, m.seriesByID[id])
+		if b, ok := expr.(*influxql.BooleanLiteral); ok {
+			if !b.Val {
+				continue
+			}
+			expr = nil
+		}
+
+		a = append(a, id)
+		if expr != nil {
+			seriesIdsToExpr[id] = expr
+		}
 	}
 
-	return ids, seriesIdsToExpr
+	return a, seriesIdsToExpr
+}
+
+// seriesFilter returns the field expression of a condition for a single series.
+// Tag references are replaced by the series' tag values and time comparisons
+// are removed since time ranges are applied separately by the shard iterators.
+func (m *Measurement) seriesFilter(condition influxql.Expr, s *Series) influxql.Expr {
+	expr := influxql.Reduce(condition, &seriesValuer{measurement: m, series: s})
+	expr = influxql.RewriteFunc(expr, func(n influxql.Node) influxql.Node {
+		if n, ok := n.(*influxql.BinaryExpr); ok && isTimeComparison(n) {
+			return &influxql.BooleanLiteral{Val: true}
+		}
+		return n
+	}).(influxql.Expr)
+	return influxql.Reduce(expr, nil)
+}
+
+// isTimeComparison returns true if the expression compares against time.
+func isTimeComparison(n *influxql.BinaryExpr) bool {
+	for _, expr := range []influxql.Expr{n.LHS, n.RHS} {
+		switch expr := expr.(type) {
+		case *influxql.VarRef:
+			if strings.ToLower(expr.Val) == "time" {
+				return true
+			}
+		case *influxql.TimeLiteral:
+			return true
+		}
+	}
+	return false
 }
 
 // tagSets returns the unique tag sets that exist for the given tag keys. This is used to determine
@@ -270,6 +347,21 @@ func (m *Measurement) tagSets(stmt *influxql.SelectStatement, dimensions []strin
 		tagSets[t] = set
 	}
 
+	// Restrict the tag sets to the window requested by SLIMIT and SOFFSET.
+	if stmt.SLimit > 0 || stmt.SOffset > 0 {
+		keys := make([]string, 0, len(tagSets))
+		for k := range tagSets {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for i, k := range keys {
+			if i < stmt.SOffset || (stmt.SLimit > 0 && i >= stmt.SOffset+stmt.SLimit) {
+				delete(tagSets, k)
+			}
+		}
+	}
+
 	return tagSets
 }
 
@@ -288,8 +380,14 @@ func (m *Measurement) idsForExpr(n *influxql.BinaryExpr) (seriesIDs, bool, influ
 		return nil, false, nil
 	}
 
-	// if it's a field we can't collapse it so we have to look at all series ids for this
-	if m.FieldByName(name.Val) != nil {
+	// ignore comparisons against time, such as "time > 10s"
+	if name != nil && strings.ToLower(name.Val) == "time" {
+		return nil, false, nil
+	}
+
+	// if it's a field or an expression without a reference we can't collapse it
+	// so we have to look at all series ids for this
+	if name == nil || m.FieldByName(name.Val) != nil {
 		return m.seriesIDs, true, n
 	}
 
@@ -299,9 +397,10 @@ func (m *Measurement) idsForExpr(n *influxql.BinaryExpr) (seriesIDs, bool, influ
 		return nil, true, nil
 	}
 
-	vals, ok := m.seriesByTagKeyValue[name.Val]
-	if !ok {
-		return nil, true, nil
+	// series without the tag value match a not equal comparison
+	vals := m.seriesByTagKeyValue[name.Val]
+	if n.Op == influxql.NEQ {
+		return m.seriesIDs.reject(vals[str.Val]), true, nil
 	}
 
 	return vals[str.Val], true, nil
@@ -483,6 +582,25 @@ func (v *tagValuer) Value(name string) (interface{}, bool) {
 	return nil, false
 }
 
+// seriesValuer is used to reduce a condition for a single series.
+type seriesValuer struct {
+	measurement *Measurement
+	series      *Series
+}
+
+// Value returns the series' value for a tag key. Tag keys that the series
+// does not have are returned as empty strings. Fields are not returned.
+func (v *seriesValuer) Value(name string) (interface{}, bool) {
+	if v.measurement.FieldByName(name) != nil {
+		return nil, false
+	} else if value, ok := v.series.Tags[name]; ok {
+		return value, true
+	} else if _, ok := v.measurement.seriesByTagKeyValue[name]; ok {
+		return "", true
+	}
+	return nil, false
+}
+
 // tagSetExpr represents a set of tag keys/values and associated expression.
 type tagSetExpr struct {
 	values []tagExpr
@@ -651,68 +769,106 @@ func NewFieldCodec(m *Measurement) *FieldCodec {
 // EncodeFields converts a map of values with string keys to a byte slice of field
 // IDs and values.
 //
-// If a field exists in the codec, but its type is different, an error is returned. If
-// a field is not present in the codec, the system panics.
+// A *FieldError is returned if a field is not present in the codec, if a value
+// cannot be stored or if a value's type differs from the type of its field.
 func (f *FieldCodec) EncodeFields(values map[string]interface{}) ([]byte, error) {
-	// Allocate byte slice and write field count.
-	b := make([]byte, 1, 10)
-	b[0] = byte(len(values))
-
+	// Convert field names to ids.
+	m := make(map[uint8]interface{}, len(values))
 	for k, v := range values {
 		field := f.fieldsByName[k]
 		if field == nil {
-			panic(fmt.Sprintf("field does not exist for %s", k))
-		} else if influxql.InspectDataType(v) != field.Type {
-			return nil, fmt.Errorf("field %s is not of type %s", k, field.Type)
+			return nil, &FieldError{Name: k, Value: v, Err: ErrFieldNotFound}
+		} else if typ := fieldDataType(v); typ == influxql.Unknown {
+			return nil, &FieldError{Name: k, Value: v, Err: ErrFieldTypeUnsupported}
+		} else if !fieldTypeAccepts(field.Type, typ) {
+			return nil, &FieldError{Name: k, Value: v, Type: field.Type, Err: ErrFieldTypeConflict}
 		}
 
-		var buf []byte
+		// Store integers as int64, or as floats if the field is a number.
+		switch intval := v.(type) {
+		case int:
+			v = int64(intval)
+		case int32:
+			v = int64(intval)
+		}
+		if intval, ok := v.(int64); ok && field.Type == influxql.Number {
+			v = float64(intval)
+		}
 
-		switch field.Type {
-		case influxql.Number:
-			var value float64
-			// Convert integers to floats.
-			if intval, ok := v.(int); ok {
-				value = float64(intval)
-			} else {
-				value = v.(float64)
-			}
+		m[field.ID] = v
+	}
 
-			buf = make([]byte, 9)
-			binary.BigEndian.PutUint64(buf[1:9], math.Float64bits(value))
-		case influxql.Boolean:
-			value := v.(bool)
+	return marshalFieldValues(m), nil
+}
+
+// fieldDataType returns the data type that a value is stored as. Returns
+// influxql.Unknown if the value cannot be stored in a field.
+func fieldDataType(v interface{}) influxql.DataType {
+	switch v.(type) {
+	case float64, int, int32, int64, bool, string:
+		return influxql.InspectDataType(v)
+	}
+	return influxql.Unknown
+}
+
+// fieldTypeAccepts returns true if a value of type typ can be stored in a field
+// of type fieldType. Integers are widened to floats when written to number fields.
+func fieldTypeAccepts(fieldType, typ influxql.DataType) bool {
+	return fieldType == typ || (fieldType == influxql.Number && typ == influxql.Integer)
+}
+
+// marshalFieldValues encodes a map of values keyed by field id. Fields are
+// encoded in field id order and each field's type is determined by its value.
+func marshalFieldValues(values map[uint8]interface{}) []byte {
+	// Sort field ids so the encoding is deterministic.
+	ids := make([]int, 0, len(values))
+	for id := range values {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	// Allocate byte slice and write field count.
+	b := make([]byte, 1, 10)
+	b[0] = byte(len(values))
+
+	for _, id := range ids {
+		var buf []byte
 
+		switch v := values[uint8(id)].(type) {
+		case float64:
+			buf = make([]byte, 9)
+			binary.BigEndian.PutUint64(buf[1:9], math.Float64bits(v))
+		case int64:
+			buf = make([]byte, 9)
+			binary.BigEndian.PutUint64(buf[1:9], uint64(v))
+		case bool:
 			// Only 1 byte need for a boolean.
 			buf = make([]byte, 2)
-			if value {
+			if v {
 				buf[1] = byte(1)
 			}
-		case influxql.String:
-			value := v.(string)
-			if len(value) > maxStringLength {
-				value = value[:maxStringLength]
+		case string:
+			if len(v) > maxStringLength {
+				v = v[:maxStringLength]
 			}
 			// Make a buffer for field ID (1 bytes), the string length (2 bytes), and the string.
-			buf = make([]byte, len(value)+3)
+			buf = make([]byte, len(v)+3)
 
 			// Set the string length, then copy the string itself.
-			binary.BigEndian.PutUint16(buf[1:3], uint16(len(value)))
-			for i, c := range []byte(value) {
-				buf[i+3] = byte(c)
-			}
+			binary.BigEndian.PutUint16(buf[1:3], uint16(len(v)))
+			copy(buf[3:], v)
 		default:
 			panic(fmt.Sprintf("unsupported value type: %T", v))
 		}
 
 		// Always set the field ID as the leading byte.
-		buf[0] = field.ID
+		buf[0] = uint8(id)
 
 		// Append temp buffer to the end.
 		b = append(b, buf...)
 	}
 
-	return b, nil
+	return b
 }
 
 // DecodeByID scans a byte slice for a field with the given ID, converts it to its
@@ -738,6 +894,10 @@ func (f *FieldCodec) DecodeByID(targetID uint8, b []byte) (interface{}, error) {
 			// Move bytes forward.
 			value = math.Float64frombits(binary.BigEndian.Uint64(b[1:9]))
 			b = b[9:]
+		case influxql.Integer:
+			// Move bytes forward.
+			value = int64(binary.BigEndian.Uint64(b[1:9]))
+			b = b[9:]
 		case influxql.Boolean:
 			if b[1] == 1 {
 				value = true
@@ -791,6 +951,10 @@ func (f *FieldCodec) DecodeFields(b []byte) map[uint8]interface{} {
 			value = math.Float64frombits(binary.BigEndian.Uint64(b[1:9]))
 			// Move bytes forward.
 			b = b[9:]
+		case influxql.Integer:
+			value = int64(binary.BigEndian.Uint64(b[1:9]))
+			// Move bytes forward.
+			b = b[9:]
 		case influxql.Boolean:
 			if b[1] == 1 {
 				value = true
@@ -801,7 +965,7 @@ func (f *FieldCodec) DecodeFields(b []byte) map[uint8]interface{} {
 			b = b[2:]
 		case influxql.String:
 			size := binary.BigEndian.Uint16(b[1:3])
-			value = string(b[3:size])
+			value = string(b[3 : 3+size])
 			// Move bytes forward.
 			b = b[size+3:]
 		default:
@@ -1208,12 +1372,31 @@ func (d *database) MeasurementNames() []string {
 
 // DropSeries will clear the index of all references to a series.
 func (d *database) DropSeries(id uint32) {
-	panic("not implemented")
+	s := d.series[id]
+	if s == nil {
+		return
+	}
+	s.measurement.dropSeries(id)
+	delete(d.series, id)
 }
 
 // DropMeasurement will clear the index of all references to a measurement and its child series.
 func (d *database) DropMeasurement(name string) {
-	panic("not implemented")
+	m := d.measurements[name]
+	if m == nil {
+		return
+	}
+
+	// Remove the measurement's series from the database lookup.
+	for id := range m.seriesByID {
+		delete(d.series, id)
+	}
+	delete(d.measurements, name)
+
+	// Remove the name from the sorted list of measurement names.
+	if i := sort.SearchStrings(d.names, name); i < len(d.names) && d.names[i] == name {
+		d.names = append(d.names[:i], d.names[i+1:]...)
+	}
 }
 
 func (d *database) continuousQueryByName(name string) *ContinuousQuery {
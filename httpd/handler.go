@@ -1,11 +1,13 @@
 package httpd
 
 import (
+	"encoding/gob"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
@@ -20,6 +22,7 @@ import (
 	"github.com/bmizerany/pat"
 	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/messaging"
 )
 
 // TODO: Standard response headers (see: HeaderHandler)
@@ -27,6 +30,13 @@ import (
 
 // TODO: Check HTTP response codes: 400, 401, 403, 409.
 
+// DefaultMaxBodySize is the default maximum size of a decompressed request body.
+const DefaultMaxBodySize = 25 * 1024 * 1024 // 25MB
+
+// ErrBodyTooLarge is returned when reading a decompressed request body that
+// is larger than the handler's maximum body size.
+var ErrBodyTooLarge = errors.New("request body too large")
+
 type route struct {
 	name        string
 	method      string
@@ -41,6 +51,10 @@ type Handler struct {
 	routes                []route
 	mux                   *pat.PatternServeMux
 	requireAuthentication bool
+
+	// The maximum number of bytes read from a gzip compressed request body
+	// after decompression. Zero means no limit.
+	MaxBodySize int64
 }
 
 // NewHandler returns a new instance of Handler.
@@ -49,6 +63,7 @@ func NewHandler(s *influxdb.Server, requireAuthentication bool, version string)
 		server: s,
 		mux:    pat.New(),
 		requireAuthentication: requireAuthentication,
+		MaxBodySize:           DefaultMaxBodySize,
 	}
 
 	weblog := log.New(os.Stderr, `[http] `, 0)
@@ -58,6 +73,10 @@ func NewHandler(s *influxdb.Server, requireAuthentication bool, version string)
 			"query", // Query serving route.
 			"GET", "/query", h.serveQuery, true,
 		},
+		route{
+			"query", // Query serving route for queries too long for a URL.
+			"POST", "/query", h.serveQuery, true,
+		},
 		route{
 			"write", // Data-ingest route.
 			"OPTIONS", "/write", h.serveOptions, true,
@@ -98,6 +117,26 @@ func NewHandler(s *influxdb.Server, requireAuthentication bool, version string)
 			"process_continuous_queries",
 			"POST", "/process_continuous_queries", h.serveProcessContinuousQueries, false,
 		},
+		route{ // Map shard data for a query coordinated by another data node
+			"run_mapper",
+			"POST", "/run_mapper", h.serveRunMapper, false,
+		},
+		route{ // Balance shard replicas across data nodes
+			"rebalance",
+			"POST", "/rebalance", h.serveRebalance, true,
+		},
+		route{ // Replication status of data nodes on the broker
+			"replicas",
+			"GET", "/replicas", h.serveReplicas, true,
+		},
+		route{ // Shard snapshot
+			"shard",
+			"GET", "/shards/:id", h.serveShard, false,
+		},
+		route{ // Copy a shard from another data node
+			"shard_replicate",
+			"POST", "/shards/:id/replicate", h.serveReplicateShard, false,
+		},
 	)
 
 	for _, r := range h.routes {
@@ -115,6 +154,7 @@ func NewHandler(s *influxdb.Server, requireAuthentication bool, version string)
 		if r.gzipped {
 			handler = gzipFilter(handler)
 		}
+		handler = gunzipFilter(handler, h)
 		handler = versionHeader(handler, version)
 		handler = cors(handler)
 		handler = requestID(handler)
@@ -133,11 +173,20 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // serveQuery parses an incoming query and, if valid, executes the query.
+// Parameters may be sent in the URL or, for POST requests, as a form body.
 func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *influxdb.User) {
-	q := r.URL.Query()
-	p := influxql.NewParser(strings.NewReader(q.Get("q")))
-	db := q.Get("db")
-	pretty := q.Get("pretty") == "true"
+	pretty := r.URL.Query().Get("pretty") == "true"
+	if err := r.ParseForm(); err == ErrBodyTooLarge {
+		httpError(w, err.Error(), pretty, http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		httpError(w, "error parsing form: "+err.Error(), pretty, http.StatusBadRequest)
+		return
+	}
+
+	p := influxql.NewParser(strings.NewReader(r.Form.Get("q")))
+	db := r.Form.Get("db")
+	pretty = r.Form.Get("pretty") == "true"
 
 	// Parse query from query string.
 	query, err := p.ParseQuery()
@@ -154,59 +203,121 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *influ
 }
 
 // serveWrite receives incoming series data and writes it to the database.
+// Points are decoded from a JSON batch unless a "db" query parameter is set,
+// in which case they are parsed from the line protocol.
+//
+// The write is rejected with a list of the invalid points if any point is
+// invalid. If the "partial" query parameter is true then the valid points are
+// written and only the invalid points are rejected.
 func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user *influxdb.User) {
-	var bp influxdb.BatchPoints
-
-	dec := json.NewDecoder(r.Body)
-
-	var writeError = func(result influxdb.Result, statusCode int) {
+	var writeError = func(result influxdb.Results, statusCode int) {
 		w.WriteHeader(statusCode)
 		w.Header().Add("content-type", "application/json")
 		_ = json.NewEncoder(w).Encode(&result)
 		return
 	}
 
-	if err := dec.Decode(&bp); err != nil {
-		if err.Error() == "EOF" {
+	var db, rp string
+	var points []influxdb.Point
+	if q := r.URL.Query(); isLineProtocol(r) {
+		// Line protocol points are sent with the database in the query string.
+		db, rp = q.Get("db"), q.Get("rp")
+
+		var err error
+		if points, err = influxdb.ParseLineProtocol(r.Body, q.Get("precision")); err == ErrBodyTooLarge {
+			writeError(influxdb.Results{Err: err}, http.StatusRequestEntityTooLarge)
+			return
+		} else if err != nil {
+			writeError(influxdb.Results{Err: err}, http.StatusBadRequest)
+			return
+		} else if len(points) == 0 {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
-		writeError(influxdb.Result{Err: err}, http.StatusInternalServerError)
-		return
+	} else {
+		var bp influxdb.BatchPoints
+		if err := json.NewDecoder(r.Body).Decode(&bp); err != nil {
+			if err.Error() == "EOF" {
+				w.WriteHeader(http.StatusOK)
+				return
+			} else if err == ErrBodyTooLarge {
+				writeError(influxdb.Results{Err: err}, http.StatusRequestEntityTooLarge)
+				return
+			}
+			writeError(influxdb.Results{Err: err}, http.StatusInternalServerError)
+			return
+		}
+		db, rp = bp.Database, bp.RetentionPolicy
+
+		var err error
+		if points, err = influxdb.NormalizeBatchPoints(bp); err != nil {
+			writeError(influxdb.Results{Err: err}, http.StatusInternalServerError)
+			return
+		}
 	}
 
-	if bp.Database == "" {
-		writeError(influxdb.Result{Err: fmt.Errorf("database is required")}, http.StatusInternalServerError)
+	if db == "" {
+		writeError(influxdb.Results{Err: fmt.Errorf("database is required")}, http.StatusInternalServerError)
 		return
 	}
 
-	if !h.server.DatabaseExists(bp.Database) {
-		writeError(influxdb.Result{Err: fmt.Errorf("database not found: %q", bp.Database)}, http.StatusNotFound)
+	if !h.server.DatabaseExists(db) {
+		writeError(influxdb.Results{Err: fmt.Errorf("database not found: %q", db)}, http.StatusNotFound)
 		return
 	}
 
 	if h.requireAuthentication && user == nil {
-		writeError(influxdb.Result{Err: fmt.Errorf("user is required to write to database %q", bp.Database)}, http.StatusUnauthorized)
+		writeError(influxdb.Results{Err: fmt.Errorf("user is required to write to database %q", db)}, http.StatusUnauthorized)
 		return
 	}
 
-	if h.requireAuthentication && !user.Authorize(influxql.WritePrivilege, bp.Database) {
-		writeError(influxdb.Result{Err: fmt.Errorf("%q user is not authorized to write to database %q", user.Name, bp.Database)}, http.StatusUnauthorized)
+	if h.requireAuthentication && !user.Authorize(influxql.WritePrivilege, db) {
+		writeError(influxdb.Results{Err: fmt.Errorf("%q user is not authorized to write to database %q", user.Name, db)}, http.StatusUnauthorized)
 		return
 	}
 
-	points, err := influxdb.NormalizeBatchPoints(bp)
-	if err != nil {
-		writeError(influxdb.Result{Err: err}, http.StatusInternalServerError)
-		return
+	write, partial := h.server.WriteSeries, r.URL.Query().Get("partial") == "true"
+	if partial {
+		write = h.server.WriteSeriesPartial
 	}
 
-	if _, err := h.server.WriteSeries(bp.Database, bp.RetentionPolicy, points); err != nil {
-		writeError(influxdb.Result{Err: err}, http.StatusInternalServerError)
+	if _, err := write(db, rp, points); err != nil {
+		if werr, ok := err.(*influxdb.WriteError); !ok {
+			writeError(influxdb.Results{Err: err}, writeErrorStatus(err))
+		} else if partial && len(werr.Points) < werr.N {
+			// The valid points were written so only report the rejected points.
+			writeError(influxdb.Results{Rejected: werr.Points}, http.StatusOK)
+		} else {
+			writeError(influxdb.Results{Err: err, Rejected: werr.Points}, http.StatusBadRequest)
+		}
 		return
 	}
 }
 
+// isLineProtocol returns true if the request body is sent as text/plain, which
+// is the content type of the line protocol. All other bodies are JSON.
+func isLineProtocol(r *http.Request) bool {
+	typ, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return typ == "text/plain"
+}
+
+// writeErrorStatus returns the HTTP status code for an error from a write.
+// Errors caused by the written data are client errors.
+func writeErrorStatus(err error) int {
+	if _, ok := err.(*influxdb.FieldError); ok {
+		return http.StatusBadRequest
+	}
+
+	switch err {
+	case influxdb.ErrDatabaseNotFound, influxdb.ErrRetentionPolicyNotFound:
+		return http.StatusNotFound
+	case influxdb.ErrDefaultRetentionPolicyNotFound, influxdb.ErrMeasurementNameRequired, influxdb.ErrValuesRequired:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // serveMetastore returns a copy of the metastore.
 func (h *Handler) serveMetastore(w http.ResponseWriter, r *http.Request) {
 	// Set headers.
@@ -326,6 +437,145 @@ func (h *Handler) serveDeleteDataNode(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// serveRunMapper maps the data of a local shard and streams the output back
+// to the data node coordinating the query.
+func (h *Handler) serveRunMapper(w http.ResponseWriter, r *http.Request, u *influxdb.User) {
+	if u != nil && !u.Admin {
+		httpError(w, "admin privileges required", false, http.StatusUnauthorized)
+		return
+	}
+
+	var m influxdb.RemoteMapper
+	if err := gob.NewDecoder(r.Body).Decode(&m); err != nil {
+		httpError(w, err.Error(), false, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if err := h.server.RunMapper(w, &m); err != nil {
+		httpError(w, err.Error(), false, http.StatusInternalServerError)
+	}
+}
+
+// serveRebalance balances shard replicas across the data nodes in the cluster.
+func (h *Handler) serveRebalance(w http.ResponseWriter, r *http.Request, u *influxdb.User) {
+	if u != nil && !u.Admin {
+		httpError(w, "admin privileges required", false, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.server.RebalanceShards(); err != nil {
+		httpError(w, err.Error(), false, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveReplicas returns the replication lag of every replica on the broker.
+func (h *Handler) serveReplicas(w http.ResponseWriter, r *http.Request, u *influxdb.User) {
+	if u != nil && !u.Admin {
+		httpError(w, "admin privileges required", false, http.StatusUnauthorized)
+		return
+	}
+
+	a, err := h.server.ReplicaStatus()
+	if err != nil {
+		httpError(w, err.Error(), false, http.StatusInternalServerError)
+		return
+	}
+	if a == nil {
+		a = make([]*messaging.ReplicaStatus, 0)
+	}
+
+	w.Header().Add("content-type", "application/json")
+	_ = json.NewEncoder(w).Encode(a)
+}
+
+// serveShard streams a snapshot of a local shard to a data node that is
+// becoming an owner of the shard.
+func (h *Handler) serveShard(w http.ResponseWriter, r *http.Request, u *influxdb.User) {
+	if u != nil && !u.Admin {
+		httpError(w, "admin privileges required", false, http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+
+	// Parse shard id.
+	id, err := strconv.ParseUint(q.Get(":id"), 10, 64)
+	if err != nil {
+		httpError(w, "invalid shard id", false, http.StatusBadRequest)
+		return
+	}
+
+	// Wait for the command that added the new owner to be applied locally.
+	if s := q.Get("index"); s != "" {
+		index, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			httpError(w, "invalid index", false, http.StatusBadRequest)
+			return
+		}
+		if err := h.server.Sync(index); err != nil {
+			httpError(w, err.Error(), false, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Set headers.
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%d"`, id))
+
+	if err := h.server.CopyShard(w, id); err == influxdb.ErrShardNotFound {
+		httpError(w, err.Error(), false, http.StatusNotFound)
+	} else if err != nil {
+		httpError(w, err.Error(), false, http.StatusInternalServerError)
+	}
+}
+
+// serveReplicateShard copies a shard from one of the source data nodes.
+func (h *Handler) serveReplicateShard(w http.ResponseWriter, r *http.Request, u *influxdb.User) {
+	if u != nil && !u.Admin {
+		httpError(w, "admin privileges required", false, http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+
+	// Parse shard id.
+	id, err := strconv.ParseUint(q.Get(":id"), 10, 64)
+	if err != nil {
+		httpError(w, "invalid shard id", false, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the index and the source node ids.
+	index, err := strconv.ParseUint(q.Get("index"), 10, 64)
+	if err != nil {
+		httpError(w, "invalid index", false, http.StatusBadRequest)
+		return
+	}
+	var sourceIDs []uint64
+	for _, s := range q["source"] {
+		sourceID, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			httpError(w, "invalid source node id", false, http.StatusBadRequest)
+			return
+		}
+		sourceIDs = append(sourceIDs, sourceID)
+	}
+
+	if err := h.server.ReplicateShard(id, sourceIDs, index); err == influxdb.ErrShardNotFound {
+		httpError(w, err.Error(), false, http.StatusNotFound)
+		return
+	} else if err != nil {
+		httpError(w, err.Error(), false, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // serveProcessContinuousQueries will execute any continuous queries that should be run
 func (h *Handler) serveProcessContinuousQueries(w http.ResponseWriter, r *http.Request, u *influxdb.User) {
 	if err := h.server.RunContinuousQueries(); err != nil {
@@ -460,6 +710,57 @@ func gzipFilter(inner http.Handler) http.Handler {
 	})
 }
 
+// gunzipFilter decompresses request bodies sent with a gzip content encoding.
+// Reads past the handler's maximum body size return ErrBodyTooLarge.
+func gunzipFilter(inner http.Handler, h *Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			inner.ServeHTTP(w, r)
+			return
+		}
+
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			httpError(w, "invalid gzip body: "+err.Error(), false, http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+
+		r.Body = &gzipRequestBody{Reader: gz, max: h.MaxBodySize}
+		r.ContentLength = -1
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+		inner.ServeHTTP(w, r)
+	})
+}
+
+// gzipRequestBody represents a decompressed request body with a size limit.
+type gzipRequestBody struct {
+	*gzip.Reader
+	n   int64 // bytes read
+	max int64 // maximum bytes, unlimited if zero
+}
+
+// Read reads decompressed bytes from the body. Returns ErrBodyTooLarge once
+// the body exceeds the maximum size.
+func (b *gzipRequestBody) Read(p []byte) (int, error) {
+	if b.max == 0 {
+		return b.Reader.Read(p)
+	} else if b.n > b.max {
+		return 0, ErrBodyTooLarge
+	}
+
+	// Read one byte past the limit to detect an oversized body.
+	if remaining := b.max - b.n + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := b.Reader.Read(p)
+	if b.n += int64(n); b.n > b.max {
+		return n - int(b.n-b.max), ErrBodyTooLarge
+	}
+	return n, err
+}
+
 // versionHeader taks a HTTP handler and returns a HTTP handler
 // and adds the X-INFLUXBD-VERSION header to outgoing responses.
 func versionHeader(inner http.Handler, version string) http.Handler {
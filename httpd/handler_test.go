@@ -2,6 +2,7 @@ package httpd_test
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -152,6 +153,35 @@ func TestHandler_Databases(t *testing.T) {
 	}
 }
 
+// Ensure a query can be sent as a gzip compressed form body.
+func TestHandler_Databases_POST(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateDatabase("bar")
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	req, err := http.NewRequest("POST", s.URL+`/query`, strings.NewReader(MustGzip(url.Values{"q": {"SHOW DATABASES"}}.Encode())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	} else if s := strings.TrimSpace(string(body)); s != `{"results":[{"rows":[{"columns":["name"],"values":[["bar"],["foo"]]}]}]}` {
+		t.Fatalf("unexpected body: %s", s)
+	}
+}
+
 func TestHandler_DatabasesPrettyPrinted(t *testing.T) {
 	srvr := OpenAuthlessServer(NewMessagingClient())
 	srvr.CreateDatabase("foo")
@@ -816,6 +846,54 @@ func TestHandler_DeleteUser_DataNodeNotFound(t *testing.T) {
 	}
 }
 
+func TestHandler_Rebalance(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	status, body := MustHTTP("POST", s.URL+`/rebalance`, nil, nil, "")
+	if status != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_Replicas(t *testing.T) {
+	c := NewMessagingClient()
+	c.ReplicaStatusFunc = func() ([]*messaging.ReplicaStatus, error) {
+		return []*messaging.ReplicaStatus{
+			{ID: 1, URL: "http://localhost:8086", LastContact: time.Unix(0, 0).UTC(), Lag: 2,
+				Topics: []*messaging.TopicStatus{{ID: 0, Index: 3, Lag: 2}}},
+		}, nil
+	}
+	srvr := OpenAuthlessServer(c)
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	status, body := MustHTTP("GET", s.URL+`/replicas`, nil, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `[{"id":1,"url":"http://localhost:8086","lastContact":"1970-01-01T00:00:00Z","lag":2,"topics":[{"id":0,"index":3,"lag":2}]}]` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_Shard_ShardNotFound(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	status, body := MustHTTP("GET", s.URL+`/shards/10000`, nil, nil, "")
+	if status != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"shard not found"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
 // Perform a subset of endpoint testing, with authentication enabled.
 
 func TestHandler_AuthenticatedCreateAdminUser(t *testing.T) {
@@ -906,6 +984,57 @@ func TestHandler_AuthenticatedDatabases_UnauthorizedBasicAuth(t *testing.T) {
 	}
 }
 
+func TestHandler_AuthenticatedRunMapper_Unauthorized(t *testing.T) {
+	srvr := OpenAuthenticatedServer(NewMessagingClient())
+	srvr.CreateUser("lisa", "password", true)
+	srvr.CreateUser("maeve", "password", false)
+	s := NewAuthenticatedHTTPServer(srvr)
+	defer s.Close()
+
+	// Running a mapper without credentials should fail.
+	status, _ := MustHTTP("POST", s.URL+`/run_mapper`, nil, nil, "")
+	if status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	// Running a mapper as a non-admin user should fail.
+	status, body := MustHTTP("POST", s.URL+`/run_mapper`, map[string]string{"u": "maeve", "p": "password"}, nil, "")
+	if status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"admin privileges required"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_AuthenticatedShards_Unauthorized(t *testing.T) {
+	srvr := OpenAuthenticatedServer(NewMessagingClient())
+	srvr.CreateUser("lisa", "password", true)
+	srvr.CreateUser("maeve", "password", false)
+	s := NewAuthenticatedHTTPServer(srvr)
+	defer s.Close()
+
+	for _, tt := range []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/shards/1"},
+		{"POST", "/shards/1/replicate"},
+	} {
+		// Requests without credentials should fail.
+		if status, _ := MustHTTP(tt.method, s.URL+tt.path, nil, nil, ""); status != http.StatusUnauthorized {
+			t.Fatalf("%s %s: unexpected status: %d", tt.method, tt.path, status)
+		}
+
+		// Requests from non-admin users should fail.
+		status, body := MustHTTP(tt.method, s.URL+tt.path, map[string]string{"u": "maeve", "p": "password"}, nil, "")
+		if status != http.StatusUnauthorized {
+			t.Fatalf("%s %s: unexpected status: %d", tt.method, tt.path, status)
+		} else if body != `{"error":"admin privileges required"}` {
+			t.Fatalf("%s %s: unexpected body: %s", tt.method, tt.path, body)
+		}
+	}
+}
+
 func TestHandler_GrantAdmin(t *testing.T) {
 	srvr := OpenAuthenticatedServer(NewMessagingClient())
 	// Create a cluster admin that will grant admin to "john".
@@ -1383,7 +1512,7 @@ func TestHandler_serveWriteSeriesFieldTypeConflict(t *testing.T) {
 	}
 
 	status, body := MustHTTP("POST", s.URL+`/write`, nil, nil, `{"database" : "foo", "retentionPolicy" : "bar", "points": [{"name": "cpu", "tags": {"host": "server01"},"values": {"value": "foo"}}]}`)
-	if status != http.StatusInternalServerError {
+	if status != http.StatusBadRequest {
 		t.Errorf("unexpected status: %d", status)
 	}
 
@@ -1395,9 +1524,276 @@ func TestHandler_serveWriteSeriesFieldTypeConflict(t *testing.T) {
 	if len(r.Results) != 0 {
 		t.Fatalf("unexpected results count")
 	}
-	if r.Err.Error() != "field \"value\" is type string, mapped as type number" {
+	if r.Err.Error() != "1 of 1 points rejected, point 0: field \"value\" is type string, mapped as type number" {
 		t.Fatalf("unexpected error returned, actual: %s", r.Err.Error())
 	}
+	if len(r.Rejected) != 1 || r.Rejected[0].Index != 0 || r.Rejected[0].Err.Error() != "field \"value\" is type string, mapped as type number" {
+		t.Fatalf("unexpected rejected points: %s", body)
+	}
+}
+
+func TestHandler_serveWriteSeriesUnsupportedValues(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	status, body := MustHTTP("POST", s.URL+`/write`, nil, nil, `{"database" : "foo", "retentionPolicy" : "bar", "points": [
+		{"name": "cpu", "values": {"value": null}},
+		{"name": "cpu", "values": {"value": {"a": 1}}}
+	]}`)
+	if status != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"2 of 2 points rejected, point 0: field \"value\" is type \u003cnil\u003e, which is not supported","rejected":[{"index":0,"error":"field \"value\" is type \u003cnil\u003e, which is not supported"},{"index":1,"error":"field \"value\" is type map[string]interface {}, which is not supported"}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	// Verify no fields were created.
+	status, body = MustHTTP("GET", s.URL+`/query`, map[string]string{"db": "foo", "q": "SHOW FIELD KEYS"}, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"results":[{}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveWriteSeries_retentionPolicyNotFound(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	status, body := MustHTTP("POST", s.URL+`/write`, nil, nil, `{"database" : "foo", "retentionPolicy" : "bar", "points": [{"name": "cpu", "values": {"value": 100}}]}`)
+	if status != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"retention policy not found"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveWriteSeriesRejectedPoints(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	status, _ := MustHTTP("POST", s.URL+`/write`, nil, nil, `{"database" : "foo", "retentionPolicy" : "bar", "points": [{"name": "cpu", "timestamp": "2009-11-10T23:00:00Z", "values": {"value": 100}}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	// Without the partial option no points are written.
+	batch := `{"database" : "foo", "retentionPolicy" : "bar", "points": [
+		{"name": "cpu", "timestamp": "2009-11-10T23:00:01Z", "values": {"value": 200}},
+		{"name": "cpu", "timestamp": "2009-11-10T23:00:02Z", "values": {"value": "foo"}},
+		{"name": "", "timestamp": "2009-11-10T23:00:03Z", "values": {"value": 300}}
+	]}`
+	status, body := MustHTTP("POST", s.URL+`/write`, nil, nil, batch)
+	if status != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"2 of 3 points rejected, point 1: field \"value\" is type string, mapped as type number","rejected":[{"index":1,"error":"field \"value\" is type string, mapped as type number"},{"index":2,"error":"measurement name required"}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	// With the partial option the valid points are written.
+	status, body = MustHTTP("POST", s.URL+`/write`, map[string]string{"partial": "true"}, nil, batch)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"rejected":[{"index":1,"error":"field \"value\" is type string, mapped as type number"},{"index":2,"error":"measurement name required"}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+	time.Sleep(100 * time.Millisecond) // Ensure data node picks up write.
+
+	status, body = MustHTTP("GET", s.URL+`/query`, map[string]string{"db": "foo", "q": "select value from cpu"}, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"results":[{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:00Z",100],["2009-11-10T23:00:01Z",200]]}]}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveWriteSeriesInteger(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	status, body := MustHTTP("POST", s.URL+`/write`, nil, nil, `{"database" : "foo", "retentionPolicy" : "bar", "points": [
+		{"name": "bytes", "timestamp": "2009-11-10T23:00:00Z", "integers": true, "values": {"value": 9007199254740993}},
+		{"name": "bytes", "timestamp": "2009-11-10T23:00:01Z", "integers": true, "values": {"value": 2}}
+	]}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d, %s", status, body)
+	}
+
+	// Floats cannot be written to an integer field.
+	status, body = MustHTTP("POST", s.URL+`/write`, nil, nil, `{"database" : "foo", "retentionPolicy" : "bar", "points": [{"name": "bytes", "values": {"value": 1.5}}]}`)
+	if status != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"1 of 1 points rejected, point 0: field \"value\" is type float64, mapped as type integer","rejected":[{"index":0,"error":"field \"value\" is type float64, mapped as type integer"}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+	time.Sleep(100 * time.Millisecond) // Ensure data node picks up write.
+
+	status, body = MustHTTP("GET", s.URL+`/query`, map[string]string{"db": "foo", "q": "select value from bytes"}, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"results":[{"rows":[{"name":"bytes","columns":["time","value"],"values":[["2009-11-10T23:00:00Z",9007199254740993],["2009-11-10T23:00:01Z",2]]}]}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	status, body = MustHTTP("GET", s.URL+`/query`, map[string]string{"db": "foo", "q": "select count(value), sum(value), max(value) from bytes where time < now()"}, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"results":[{"rows":[{"name":"bytes","columns":["time","count","sum","max"],"values":[["1970-01-01T00:00:00Z",2,9007199254740995,9007199254740993]]}]}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveWriteSeries_gzip(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	headers := map[string]string{"Content-Encoding": "gzip"}
+	status, body := MustHTTP("POST", s.URL+`/write`, nil, headers, MustGzip(`{"database" : "foo", "retentionPolicy" : "bar", "points": [{"name": "cpu", "tags": {"host": "server01"},"timestamp": "2009-11-10T23:00:00Z", "values": {"value": 100}}]}`))
+	if status != http.StatusOK {
+		t.Log(body)
+		t.Fatalf("unexpected status: %d", status)
+	}
+	time.Sleep(100 * time.Millisecond) // Ensure data node picks up write.
+
+	status, body = MustHTTP("GET", s.URL+`/query`, map[string]string{"db": "foo", "q": "select value from cpu"}, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"results":[{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:00Z",100]]}]}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveWriteSeries_gzipTooLarge(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	s := NewHTTPServer(srvr)
+	s.Handler.MaxBodySize = 20
+	defer s.Close()
+
+	headers := map[string]string{"Content-Encoding": "gzip"}
+	status, body := MustHTTP("POST", s.URL+`/write`, nil, headers, MustGzip(`{"database" : "foo", "points": [{"name": "cpu", "values": {"value": 100}}]}`))
+	if status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"request body too large"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	// Line protocol bodies are limited too.
+	headers["Content-Type"] = "text/plain"
+	status, body = MustHTTP("POST", s.URL+`/write`, map[string]string{"db": "foo"}, headers, MustGzip("cpu value=1\ncpu value=2\ncpu value=3\n"))
+	if status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"request body too large"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveWriteSeries_invalidGzip(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	headers := map[string]string{"Content-Encoding": "gzip"}
+	status, body := MustHTTP("POST", s.URL+`/write`, nil, headers, `{"database" : "foo"}`)
+	if status != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"invalid gzip body: gzip: invalid header"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveWriteSeriesLineProtocol(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	params := map[string]string{"db": "foo", "rp": "bar", "precision": "s"}
+	status, body := MustHTTP("POST", s.URL+`/write`, params, map[string]string{"Content-Type": "text/plain"}, "cpu,host=server01 value=100 1257894000\ncpu,host=server02 value=50,event=\"disk full\" 1257894000\n")
+	if status != http.StatusOK {
+		t.Log(body)
+		t.Fatalf("unexpected status: %d", status)
+	}
+	time.Sleep(100 * time.Millisecond) // Ensure data node picks up write.
+
+	query := map[string]string{"db": "foo", "q": "select value from cpu where host = 'server02'"}
+	status, body = MustHTTP("GET", s.URL+`/query`, query, nil, "")
+	if status != http.StatusOK {
+		t.Log(body)
+		t.Fatalf("unexpected status: %d", status)
+	}
+	if body != `{"results":[{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:00Z",50]]}]}]}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveWriteSeriesLineProtocol_ParseError(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	status, body := MustHTTP("POST", s.URL+`/write`, map[string]string{"db": "foo"}, map[string]string{"Content-Type": "text/plain; charset=utf-8"}, "cpu value=1\ncpu value=x\n")
+	if status != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", status)
+	}
+	if body != `{"error":"line 2: invalid field value: \"x\""}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveWriteSeriesLineProtocol_noDatabaseExists(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	status, body := MustHTTP("POST", s.URL+`/write`, map[string]string{"db": "foo"}, map[string]string{"Content-Type": "text/plain"}, "cpu value=1\n")
+	if status != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", status)
+	}
+	if body != `{"error":"database not found: \"foo\""}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestHandler_serveWriteSeriesLineProtocol_JSONWithDatabaseParam(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	// JSON bodies are decoded as JSON even if a database is in the query string.
+	status, body := MustHTTP("POST", s.URL+`/write`, map[string]string{"db": "foo"}, nil, `{"database" : "foo", "retentionPolicy" : "bar", "points": [{"name": "cpu", "timestamp": "2009-11-10T23:00:00Z", "values": {"value": 100}}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d, %s", status, body)
+	}
 }
 
 func TestHandler_serveShowSeries(t *testing.T) {
@@ -2006,10 +2402,10 @@ func MustHTTP(verb, path string, params, headers map[string]string, body string)
 		req.URL.RawQuery = q.Encode()
 	}
 
+	req.Header.Set("Content-Type", "application/json")
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -2022,6 +2418,18 @@ func MustHTTP(verb, path string, params, headers map[string]string, body string)
 	return resp.StatusCode, strings.TrimRight(string(b), "\n")
 }
 
+// MustGzip returns a gzip compressed string. Panic on error.
+func MustGzip(s string) string {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(s)); err != nil {
+		panic(err)
+	} else if err := gz.Close(); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
 // MustParseURL parses a string into a URL. Panic on error.
 func MustParseURL(s string) *url.URL {
 	u, err := url.Parse(s)
@@ -2126,6 +2534,8 @@ type MessagingClient struct {
 	DeleteReplicaFunc func(replicaID uint64) error
 	SubscribeFunc     func(replicaID, topicID uint64) error
 	UnsubscribeFunc   func(replicaID, topicID uint64) error
+	HeartbeatFunc     func(replicaID uint64, indexes map[uint64]uint64) error
+	ReplicaStatusFunc func() ([]*messaging.ReplicaStatus, error)
 }
 
 // NewMessagingClient returns a new instance of MessagingClient.
@@ -2136,6 +2546,8 @@ func NewMessagingClient() *MessagingClient {
 	c.DeleteReplicaFunc = func(replicaID uint64) error { return nil }
 	c.SubscribeFunc = func(replicaID, topicID uint64) error { return nil }
 	c.UnsubscribeFunc = func(replicaID, topicID uint64) error { return nil }
+	c.HeartbeatFunc = func(replicaID uint64, indexes map[uint64]uint64) error { return nil }
+	c.ReplicaStatusFunc = func() ([]*messaging.ReplicaStatus, error) { return nil, nil }
 	return c
 }
 
@@ -2176,6 +2588,16 @@ func (c *MessagingClient) Unsubscribe(replicaID, topicID uint64) error {
 	return c.UnsubscribeFunc(replicaID, topicID)
 }
 
+// Heartbeat reports the applied index for each topic to the broker.
+func (c *MessagingClient) Heartbeat(replicaID uint64, indexes map[uint64]uint64) error {
+	return c.HeartbeatFunc(replicaID, indexes)
+}
+
+// ReplicaStatus returns the replication status of every replica on the broker.
+func (c *MessagingClient) ReplicaStatus() ([]*messaging.ReplicaStatus, error) {
+	return c.ReplicaStatusFunc()
+}
+
 // C returns a channel for streaming message.
 func (c *MessagingClient) C() <-chan *messaging.Message { return c.c }
 
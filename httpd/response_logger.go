@@ -42,6 +42,16 @@ func (l *responseLogger) WriteHeader(s int) {
 	l.status = s
 }
 
+// Flush sends any buffered data to the client, if supported by the underlying writer.
+func (l *responseLogger) Flush() {
+	if f, ok := l.w.(http.Flusher); ok {
+		if l.status == 0 {
+			l.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
 func (l *responseLogger) Status() int {
 	return l.status
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/influxdb/influxdb/client"
+	"github.com/influxdb/influxdb/influxql"
 )
 
 var (
@@ -81,6 +82,15 @@ var (
 	// ErrShardNotFound is returned writing to a non-existent shard.
 	ErrShardNotFound = errors.New("shard not found")
 
+	// ErrNoShardSource is returned when moving a shard that no remaining data node owns.
+	ErrNoShardSource = errors.New("no data node available to copy shard")
+
+	// ErrInvalidBlock is returned when a shard block cannot be decoded.
+	ErrInvalidBlock = errors.New("invalid block")
+
+	// ErrInvalidPointBatch is returned when a batch of encoded points cannot be decoded.
+	ErrInvalidPointBatch = errors.New("invalid point batch")
+
 	// ErrReadAccessDenied is returned when a user attempts to read
 	// data that he or she does not have permission to read.
 	ErrReadAccessDenied = errors.New("read access denied")
@@ -109,6 +119,18 @@ var (
 	// ErrFieldNotFound
 	ErrFieldNotFound = errors.New("field not found")
 
+	// ErrFieldTypeUnsupported is returned when a field value has a type that cannot be stored.
+	ErrFieldTypeUnsupported = errors.New("field type unsupported")
+
+	// ErrFieldConditionNotSupported is returned when a statement cannot filter on field values.
+	ErrFieldConditionNotSupported = errors.New("field condition not supported")
+
+	// ErrTimeConditionNotSupported is returned when a statement combines a time condition using OR.
+	ErrTimeConditionNotSupported = errors.New("time condition must be combined with AND")
+
+	// ErrSeriesTimeConditionNotSupported is returned when a DROP SERIES statement filters on time.
+	ErrSeriesTimeConditionNotSupported = errors.New("drop series does not support time conditions")
+
 	// ErrSeriesNotFound is returned when looking up a non-existent series by database, name and tags
 	ErrSeriesNotFound = errors.New("series not found")
 
@@ -254,6 +276,76 @@ func isAuthorizationError(err error) bool {
 	return ok
 }
 
+// FieldError represents an invalid field value in a written point.
+// Err is ErrFieldTypeConflict, ErrFieldTypeUnsupported, ErrFieldOverflow or ErrFieldNotFound.
+type FieldError struct {
+	Name  string            // field name
+	Value interface{}       // written value
+	Type  influxql.DataType // type of the existing field on a conflict
+	Err   error
+}
+
+// Error returns a description of the invalid field.
+func (e *FieldError) Error() string {
+	switch e.Err {
+	case ErrFieldTypeConflict:
+		return fmt.Sprintf("field \"%s\" is type %T, mapped as type %s", e.Name, e.Value, e.Type)
+	case ErrFieldTypeUnsupported:
+		return fmt.Sprintf("field \"%s\" is type %T, which is not supported", e.Name, e.Value)
+	default:
+		return fmt.Sprintf("field \"%s\": %s", e.Name, e.Err)
+	}
+}
+
+// PointError represents a point that was rejected from a write.
+type PointError struct {
+	Index int   // position of the point in the write
+	Err   error // reason the point was rejected
+}
+
+// Error returns the reason the point was rejected prefixed with its index.
+func (e *PointError) Error() string {
+	return fmt.Sprintf("point %d: %s", e.Index, e.Err)
+}
+
+// MarshalJSON encodes the point error into JSON.
+func (e *PointError) MarshalJSON() ([]byte, error) {
+	var o struct {
+		Index int    `json:"index"`
+		Err   string `json:"error"`
+	}
+	o.Index = e.Index
+	if e.Err != nil {
+		o.Err = e.Err.Error()
+	}
+	return json.Marshal(&o)
+}
+
+// UnmarshalJSON decodes the data into the point error.
+func (e *PointError) UnmarshalJSON(b []byte) error {
+	var o struct {
+		Index int    `json:"index"`
+		Err   string `json:"error"`
+	}
+	if err := json.Unmarshal(b, &o); err != nil {
+		return err
+	}
+	e.Index = o.Index
+	e.Err = errors.New(o.Err)
+	return nil
+}
+
+// WriteError is returned when points in a write are rejected.
+type WriteError struct {
+	N      int           // number of points in the write
+	Points []*PointError // rejected points in write order
+}
+
+// Error returns the number of rejected points and the first rejection.
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("%d of %d points rejected, %s", len(e.Points), e.N, e.Points[0])
+}
+
 // mustMarshal encodes a value to JSON.
 // This will panic if an error occurs. This should only be used internally when
 // an invalid marshal will cause corruption and a panic is appropriate.
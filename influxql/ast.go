@@ -15,8 +15,10 @@ type DataType string
 const (
 	// Unknown primitive data type.
 	Unknown = DataType("")
-	// Number means the data type is an int or float.
+	// Number means the data type is a float.
 	Number = DataType("number")
+	// Integer means the data type is a 64-bit integer.
+	Integer = DataType("integer")
 	// Boolean means the data type is a boolean.
 	Boolean = DataType("boolean")
 	// String means the data type is a string of text.
@@ -32,8 +34,8 @@ func InspectDataType(v interface{}) DataType {
 	switch v.(type) {
 	case float64:
 		return Number
-	case int:
-		return Number
+	case int, int32, int64:
+		return Integer
 	case bool:
 		return Boolean
 	case string:
@@ -64,10 +66,12 @@ func (*CreateUserStatement) node()            {}
 func (*DeleteStatement) node()                {}
 func (*DropContinuousQueryStatement) node()   {}
 func (*DropDatabaseStatement) node()          {}
+func (*DropMeasurementStatement) node()       {}
 func (*DropRetentionPolicyStatement) node()   {}
 func (*DropSeriesStatement) node()            {}
 func (*DropUserStatement) node()              {}
 func (*GrantStatement) node()                 {}
+func (*RebalanceShardsStatement) node()       {}
 func (*ShowContinuousQueriesStatement) node() {}
 func (*ShowDatabasesStatement) node()         {}
 func (*ShowFieldKeysStatement) node()         {}
@@ -76,6 +80,7 @@ func (*ShowMeasurementsStatement) node()      {}
 func (*ShowSeriesStatement) node()            {}
 func (*ShowTagKeysStatement) node()           {}
 func (*ShowTagValuesStatement) node()         {}
+func (*ShowReplicasStatement) node()          {}
 func (*ShowUsersStatement) node()             {}
 func (*RevokeStatement) node()                {}
 func (*SelectStatement) node()                {}
@@ -152,10 +157,12 @@ func (*CreateUserStatement) stmt()            {}
 func (*DeleteStatement) stmt()                {}
 func (*DropContinuousQueryStatement) stmt()   {}
 func (*DropDatabaseStatement) stmt()          {}
+func (*DropMeasurementStatement) stmt()       {}
 func (*DropRetentionPolicyStatement) stmt()   {}
 func (*DropSeriesStatement) stmt()            {}
 func (*DropUserStatement) stmt()              {}
 func (*GrantStatement) stmt()                 {}
+func (*RebalanceShardsStatement) stmt()       {}
 func (*ShowContinuousQueriesStatement) stmt() {}
 func (*ShowDatabasesStatement) stmt()         {}
 func (*ShowFieldKeysStatement) stmt()         {}
@@ -164,6 +171,7 @@ func (*ShowRetentionPoliciesStatement) stmt() {}
 func (*ShowSeriesStatement) stmt()            {}
 func (*ShowTagKeysStatement) stmt()           {}
 func (*ShowTagValuesStatement) stmt()         {}
+func (*ShowReplicasStatement) stmt()          {}
 func (*ShowUsersStatement) stmt()             {}
 func (*RevokeStatement) stmt()                {}
 func (*SelectStatement) stmt()                {}
@@ -196,6 +204,25 @@ func (*Join) source()        {}
 func (*Measurement) source() {}
 func (*Merge) source()       {}
 
+// FillOption represents the policy for intervals without data in a GROUP BY time() query.
+type FillOption int
+
+const (
+	// DefaultFill emits the aggregate's own value for empty intervals between
+	// the first and last intervals with data. Used when no fill option is given.
+	DefaultFill FillOption = iota
+	// NullFill emits a null value for empty intervals.
+	NullFill
+	// NoFill omits empty intervals.
+	NoFill
+	// NumberFill emits a constant number for empty intervals.
+	NumberFill
+	// PreviousFill emits the value of the previous interval.
+	PreviousFill
+	// LinearFill emits a value interpolated between the surrounding intervals.
+	LinearFill
+)
+
 // SortField represents a field to sort results by.
 type SortField struct {
 	// Name of the field
@@ -533,6 +560,10 @@ type SelectStatement struct {
 	// Expressions used for grouping the selection.
 	Dimensions Dimensions
 
+	// Policy for intervals without data and the value used by NumberFill.
+	Fill      FillOption
+	FillValue interface{}
+
 	// Data source that fields are extracted from.
 	Source Source
 
@@ -549,6 +580,13 @@ type SelectStatement struct {
 	// Returns rows starting at an offset from the first row.
 	Offset int
 
+	// Maximum number of series to be returned.
+	// Unlimited if zero.
+	SLimit int
+
+	// Returns series starting at an offset from the first one.
+	SOffset int
+
 	// memoize the group by interval
 	groupByInterval time.Duration
 }
@@ -562,6 +600,11 @@ func (s *SelectStatement) Clone() *SelectStatement {
 		SortFields: make(SortFields, len(s.SortFields)),
 		Condition:  CloneExpr(s.Condition),
 		Limit:      s.Limit,
+		Offset:     s.Offset,
+		SLimit:     s.SLimit,
+		SOffset:    s.SOffset,
+		Fill:       s.Fill,
+		FillValue:  s.FillValue,
 	}
 	if s.Target != nil {
 		other.Target = &Target{Measurement: s.Target.Measurement, Database: s.Target.Database}
@@ -624,6 +667,18 @@ func (s *SelectStatement) String() string {
 		_, _ = buf.WriteString(" GROUP BY ")
 		_, _ = buf.WriteString(s.Dimensions.String())
 	}
+	switch s.Fill {
+	case NullFill:
+		_, _ = buf.WriteString(" fill(null)")
+	case NoFill:
+		_, _ = buf.WriteString(" fill(none)")
+	case NumberFill:
+		_, _ = fmt.Fprintf(&buf, " fill(%v)", s.FillValue)
+	case PreviousFill:
+		_, _ = buf.WriteString(" fill(previous)")
+	case LinearFill:
+		_, _ = buf.WriteString(" fill(linear)")
+	}
 	if len(s.SortFields) > 0 {
 		_, _ = buf.WriteString(" ORDER BY ")
 		_, _ = buf.WriteString(s.SortFields.String())
@@ -635,6 +690,14 @@ func (s *SelectStatement) String() string {
 		_, _ = buf.WriteString(" OFFSET ")
 		_, _ = buf.WriteString(strconv.Itoa(s.Offset))
 	}
+	if s.SLimit > 0 {
+		_, _ = buf.WriteString(" SLIMIT ")
+		_, _ = buf.WriteString(strconv.Itoa(s.SLimit))
+	}
+	if s.SOffset > 0 {
+		_, _ = buf.WriteString(" SOFFSET ")
+		_, _ = buf.WriteString(strconv.Itoa(s.SOffset))
+	}
 	return buf.String()
 }
 
@@ -660,6 +723,12 @@ func (s *SelectStatement) Aggregated() bool {
 	return v
 }
 
+// TimeAscending returns true if results are sorted by time in chronological order.
+// An unnamed sort field refers to time.
+func (s *SelectStatement) TimeAscending() bool {
+	return len(s.SortFields) == 0 || s.SortFields[0].Ascending
+}
+
 // OnlyTimeDimensions returns true if the statement has a where clause with only time constraints
 func (s *SelectStatement) OnlyTimeDimensions() bool {
 	return s.walkForTime(s.Condition)
@@ -794,6 +863,9 @@ func (s *SelectStatement) Substatement(ref *VarRef) (*SelectStatement, error) {
 		Fields:     Fields{{Expr: ref}},
 		Dimensions: s.Dimensions,
 		Limit:      s.Limit,
+		Offset:     s.Offset,
+		SLimit:     s.SLimit,
+		SOffset:    s.SOffset,
 		SortFields: s.SortFields,
 	}
 
@@ -918,13 +990,13 @@ type DeleteStatement struct {
 // String returns a string representation of the delete statement.
 func (s *DeleteStatement) String() string {
 	var buf bytes.Buffer
-	_, _ = buf.WriteString("DELETE ")
+	_, _ = buf.WriteString("DELETE FROM ")
 	_, _ = buf.WriteString(s.Source.String())
 	if s.Condition != nil {
 		_, _ = buf.WriteString(" WHERE ")
 		_, _ = buf.WriteString(s.Condition.String())
 	}
-	return s.String()
+	return buf.String()
 }
 
 // RequiredPrivileges returns the privilege required to execute a DeleteStatement.
@@ -980,16 +1052,59 @@ func (s *ShowSeriesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
 }
 
+// DropMeasurementStatement represents a command to drop a measurement.
+type DropMeasurementStatement struct {
+	// Name of the measurement to be dropped.
+	Name string
+}
+
+// String returns a string representation of the drop measurement statement.
+func (s *DropMeasurementStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("DROP MEASUREMENT ")
+	_, _ = buf.WriteString(s.Name)
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a DropMeasurementStatement.
+func (s *DropMeasurementStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: WritePrivilege}}
+}
+
 // DropSeriesStatement represents a command for removing a series from the database.
 type DropSeriesStatement struct {
-	Name string
+	// The id of the series being dropped (optional).
+	SeriesID uint32
+
+	// Measurement(s) the series are dropped from (optional).
+	Source Source
+
+	// An expression evaluated on a series' tags (optional).
+	Condition Expr
 }
 
 // String returns a string representation of the drop series statement.
-func (s *DropSeriesStatement) String() string { return fmt.Sprintf("DROP SERIES %s", s.Name) }
+func (s *DropSeriesStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("DROP SERIES")
+
+	if s.SeriesID != 0 {
+		_, _ = buf.WriteString(" ")
+		_, _ = buf.WriteString(strconv.FormatUint(uint64(s.SeriesID), 10))
+	}
+	if s.Source != nil {
+		_, _ = buf.WriteString(" FROM ")
+		_, _ = buf.WriteString(s.Source.String())
+	}
+	if s.Condition != nil {
+		_, _ = buf.WriteString(" WHERE ")
+		_, _ = buf.WriteString(s.Condition.String())
+	}
+	return buf.String()
+}
 
 // RequiredPrivileges returns the privilige reqired to execute a DropSeriesStatement.
-func (s DropSeriesStatement) RequiredPrivileges() ExecutionPrivileges {
+func (s *DropSeriesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: WritePrivilege}}
 }
 
@@ -1015,6 +1130,18 @@ func (s *ShowDatabasesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// RebalanceShardsStatement represents a command for redistributing shard
+// replicas across the data nodes in the cluster.
+type RebalanceShardsStatement struct{}
+
+// String returns a string representation of the rebalance shards command.
+func (s *RebalanceShardsStatement) String() string { return "REBALANCE SHARDS" }
+
+// RequiredPrivileges returns the privilege required to execute a RebalanceShardsStatement.
+func (s *RebalanceShardsStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // CreateContinuousQueryStatement represents a command for creating a continuous query.
 type CreateContinuousQueryStatement struct {
 	// Name of the continuous query to be created.
@@ -1238,6 +1365,20 @@ func (s *ShowTagValuesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
 }
 
+// ShowReplicasStatement represents a command for listing broker replicas
+// and how far behind the broker each replica has applied.
+type ShowReplicasStatement struct{}
+
+// String returns a string representation of the ShowReplicasStatement.
+func (s *ShowReplicasStatement) String() string {
+	return "SHOW REPLICAS"
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a ShowReplicasStatement
+func (s *ShowReplicasStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // ShowUsersStatement represents a command for listing users.
 type ShowUsersStatement struct{}
 
@@ -1696,6 +1837,10 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Source)
 		Walk(v, n.Condition)
 
+	case *DropSeriesStatement:
+		Walk(v, n.Source)
+		Walk(v, n.Condition)
+
 	case *ShowSeriesStatement:
 		Walk(v, n.Source)
 		Walk(v, n.Condition)
@@ -1842,15 +1987,35 @@ func evalBinaryExpr(expr *BinaryExpr, m map[string]interface{}) interface{} {
 	lhs := Eval(expr.LHS, m)
 	rhs := Eval(expr.RHS, m)
 
+	// Integers are compared and combined as floats.
+	if v, ok := lhs.(int64); ok {
+		lhs = float64(v)
+	}
+	if v, ok := rhs.(int64); ok {
+		rhs = float64(v)
+	}
+
+	// Logical operators treat a missing or non-boolean side as false.
+	if expr.Op == AND || expr.Op == OR {
+		lhs, lok := lhs.(bool)
+		rhs, rok := rhs.(bool)
+		if !lok && !rok {
+			return nil
+		} else if expr.Op == AND {
+			return lhs && rhs
+		}
+		return lhs || rhs
+	}
+
 	// Evaluate if both sides are simple types.
 	switch lhs := lhs.(type) {
 	case bool:
-		rhs, _ := rhs.(bool)
+		rhs, ok := rhs.(bool)
 		switch expr.Op {
-		case AND:
-			return lhs && rhs
-		case OR:
-			return lhs || rhs
+		case EQ:
+			return ok && lhs == rhs
+		case NEQ:
+			return ok && lhs != rhs
 		}
 	case float64:
 		rhs, _ := rhs.(float64)
@@ -2189,12 +2354,13 @@ type Valuer interface {
 	Value(key string) (interface{}, bool)
 }
 
-// nowValuer returns only the value for "now()".
-type nowValuer struct {
+// NowValuer returns only the value for "now()".
+type NowValuer struct {
 	Now time.Time
 }
 
-func (v *nowValuer) Value(key string) (interface{}, bool) {
+// Value returns the current time if key is "now()".
+func (v *NowValuer) Value(key string) (interface{}, bool) {
 	if key == "now()" {
 		return v.Now, true
 	}
@@ -17,6 +17,8 @@ func TestInspectDataType(t *testing.T) {
 		typ influxql.DataType
 	}{
 		{float64(100), influxql.Number},
+		{int64(100), influxql.Integer},
+		{int(100), influxql.Integer},
 	} {
 		if typ := influxql.InspectDataType(tt.v); tt.typ != typ {
 			t.Errorf("%d. %v (%s): unexpected type: %s", i, tt.v, tt.typ, typ)
@@ -307,6 +309,17 @@ func TestSelectStatement_OnlyTimeDimensions(t *testing.T) {
 	}
 }
 
+// Ensure a DELETE statement can be converted back to a string.
+func TestDeleteStatement_String(t *testing.T) {
+	stmt, err := influxql.NewParser(strings.NewReader(`DELETE FROM cpu WHERE host = 'serverA' AND time < '2000-01-01T00:00:00Z'`)).ParseStatement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := stmt.String(); s != `DELETE FROM cpu WHERE host = 'serverA' AND time < "2000-01-01 00:00:00"` {
+		t.Fatalf("unexpected string: %s", s)
+	}
+}
+
 // Ensure an AST node can be rewritten.
 func TestRewrite(t *testing.T) {
 	expr := MustParseExpr(`time > 1 OR foo = 2`)
@@ -345,10 +358,14 @@ func TestEval(t *testing.T) {
 		{in: `4 < 6`, out: true},
 		{in: `4 <= 4`, out: true},
 		{in: `4 AND 5`, out: nil},
+		{in: `foo > 4`, out: true, data: map[string]interface{}{"foo": int64(5)}},
+		{in: `foo * 2`, out: float64(10), data: map[string]interface{}{"foo": int64(5)}},
 
 		// Boolean literals.
 		{in: `true AND false`, out: false},
 		{in: `true OR false`, out: true},
+		{in: `foo = true`, out: true, data: map[string]interface{}{"foo": true}},
+		{in: `foo <> true`, out: true, data: map[string]interface{}{"foo": false}},
 
 		// String literals.
 		{in: `'foo' = 'bar'`, out: false},
@@ -359,6 +376,8 @@ func TestEval(t *testing.T) {
 		{in: `foo = 'bar'`, out: true, data: map[string]interface{}{"foo": "bar"}},
 		{in: `foo = 'bar'`, out: nil, data: map[string]interface{}{"foo": nil}},
 		{in: `foo <> 'bar'`, out: true, data: map[string]interface{}{"foo": "xxx"}},
+		{in: `foo > 1 OR bar = 'x'`, out: true, data: map[string]interface{}{"bar": "x"}},
+		{in: `foo > 1 AND bar = 'x'`, out: false, data: map[string]interface{}{"bar": "x"}},
 	} {
 		// Evaluate expression.
 		out := influxql.Eval(MustParseExpr(tt.in), tt.data)
@@ -2,17 +2,42 @@ package influxql
 
 import (
 	"encoding/binary"
+	"encoding/gob"
 	"fmt"
 	"hash/fnv"
 	"math"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
 // how many values we will map before emitting
 const emitBatchSize = 1000
 
+// MaxFillIntervals is the maximum number of intervals a query can fill for
+// each row. It stops a small interval over a large time range from generating
+// an unbounded number of values. Queries that exceed it return an error.
+const MaxFillIntervals = 100000
+
+func init() {
+	// Register expressions and map outputs so they can be encoded when
+	// mapping on remote nodes.
+	gob.Register(&BinaryExpr{})
+	gob.Register(&BooleanLiteral{})
+	gob.Register(&Call{})
+	gob.Register(&DurationLiteral{})
+	gob.Register(&NumberLiteral{})
+	gob.Register(&ParenExpr{})
+	gob.Register(&StringLiteral{})
+	gob.Register(&TimeLiteral{})
+	gob.Register(&VarRef{})
+	gob.Register(&meanMapOutput{})
+	gob.Register(spreadMapOutput{})
+	gob.Register(firstLastMapOutput{})
+	gob.Register([]interface{}{})
+}
+
 // DB represents an interface for creating transactions.
 type DB interface {
 	Begin() (Tx, error)
@@ -31,7 +56,7 @@ type Tx interface {
 	// Creates a list of iterators for a simple select statement.
 	//
 	// The statement must adhere to the following rules:
-	//   1. It can only have a single VarRef field.
+	//   1. It can only have VarRef fields.
 	//   2. It can only have a single source measurement.
 	CreateIterators(*SelectStatement) ([]Iterator, error)
 }
@@ -45,6 +70,26 @@ type Iterator interface {
 	Next() (key int64, data []byte, value interface{})
 }
 
+// ErrIterator represents an iterator that can fail while reading its data.
+// Next returns no more data once an error occurs.
+type ErrIterator interface {
+	Iterator
+
+	// Err returns the error that stopped the iterator, if any.
+	Err() error
+}
+
+// RemoteIterator represents an iterator over data owned by another node.
+// Mappers do not read remote iterators directly. Instead, the map function is
+// executed by the owning node and its output is sent to the emitter.
+type RemoteIterator interface {
+	Iterator
+
+	// MapRemote maps the iterator's data on the owning node using the map
+	// function of call, or MapRawQuery if call is nil.
+	MapRemote(call *Call, interval int64, skipEmpty bool, e *Emitter) error
+}
+
 // Planner represents an object for creating execution plans.
 type Planner struct {
 	DB DB
@@ -68,7 +113,14 @@ func (p *Planner) Plan(stmt *SelectStatement) (*Executor, error) {
 	// Clone the statement to be planned.
 	// Replace instances of "now()" with the current time.
 	stmt = stmt.Clone()
-	stmt.Condition = Reduce(stmt.Condition, &nowValuer{Now: now})
+	stmt.Condition = Reduce(stmt.Condition, &NowValuer{Now: now})
+
+	// Only sorting by time is currently supported.
+	for _, f := range stmt.SortFields {
+		if f.Name != "" && strings.ToLower(f.Name) != "time" {
+			return nil, fmt.Errorf("only ORDER BY time supported at this time")
+		}
+	}
 
 	// Begin an unopened transaction.
 	tx, err := p.DB.Begin()
@@ -87,6 +139,30 @@ func (p *Planner) Plan(stmt *SelectStatement) (*Executor, error) {
 	e.interval = interval
 	e.tags = tags
 
+	// Limit the number of intervals that can be filled between the time bounds.
+	if interval > 0 && stmt.Fill != DefaultFill && stmt.Fill != NoFill {
+		tmin, tmax := TimeRange(stmt.Condition)
+		if tmax.IsZero() {
+			tmax = now
+		}
+		if !tmin.IsZero() {
+			if n := tmax.Sub(tmin) / interval; n > MaxFillIntervals {
+				return nil, fmt.Errorf("too many intervals to fill: %d, max %d", n, MaxFillIntervals)
+			}
+		}
+	}
+
+	// Raw queries for multiple fields read every field in a single pass.
+	if isRawFields(stmt.Fields) {
+		p, err := p.planRawFields(e)
+		if err != nil {
+			return nil, err
+		}
+		e.processors = []Processor{p}
+		e.rawFields = true
+		return e, nil
+	}
+
 	// Generate a processor for each field.
 	e.processors = make([]Processor, len(stmt.Fields))
 	for i, f := range stmt.Fields {
@@ -128,14 +204,53 @@ func (p *Planner) planExpr(e *Executor, expr Expr) (Processor, error) {
 	panic("unreachable")
 }
 
-// planCall generates a processor for a function call.
+// planRawQuery generates a processor for a raw field.
 func (p *Planner) planRawQuery(e *Executor, v *VarRef) (Processor, error) {
 	// Convert the statement to a simplified substatement for the single field.
 	stmt, err := e.stmt.Substatement(v)
 	if err != nil {
 		return nil, err
 	}
+	return p.planRawSubstatement(e, stmt)
+}
+
+// planRawFields generates a single processor for a raw query of multiple fields.
+// Each value emitted by the processor is a slice with one value per field.
+func (p *Planner) planRawFields(e *Executor) (Processor, error) {
+	if _, ok := e.stmt.Source.(*Measurement); !ok {
+		return nil, fmt.Errorf("multiple fields require a single measurement source")
+	}
+
+	stmt := &SelectStatement{
+		Fields:     e.stmt.Fields,
+		Source:     e.stmt.Source,
+		Condition:  e.stmt.Condition,
+		Dimensions: e.stmt.Dimensions,
+		SortFields: e.stmt.SortFields,
+		Limit:      e.stmt.Limit,
+		Offset:     e.stmt.Offset,
+		SLimit:     e.stmt.SLimit,
+		SOffset:    e.stmt.SOffset,
+	}
+	return p.planRawSubstatement(e, stmt)
+}
 
+// isRawFields returns true if fields contains more than one field and all
+// of them are plain variable references.
+func isRawFields(fields Fields) bool {
+	if len(fields) < 2 {
+		return false
+	}
+	for _, f := range fields {
+		if _, ok := f.Expr.(*VarRef); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// planRawSubstatement generates a processor for a single field substatement.
+func (p *Planner) planRawSubstatement(e *Executor, stmt *SelectStatement) (Processor, error) {
 	// Retrieve a list of iterators for the substatement.
 	itrs, err := e.tx.CreateIterators(stmt)
 	if err != nil {
@@ -147,8 +262,10 @@ func (p *Planner) planRawQuery(e *Executor, v *VarRef) (Processor, error) {
 	for i, itr := range itrs {
 		mappers[i] = NewMapper(MapRawQuery, itr, e.interval)
 	}
+	e.mappers = append(e.mappers, mappers...)
 	r := NewReducer(ReduceRawQuery, mappers)
 	r.name = lastIdent(stmt.Source.(*Measurement).Name)
+	r.descending = !stmt.TimeAscending()
 
 	return r, nil
 
@@ -156,6 +273,12 @@ func (p *Planner) planRawQuery(e *Executor, v *VarRef) (Processor, error) {
 
 // planCall generates a processor for a function call.
 func (p *Planner) planCall(e *Executor, c *Call) (Processor, error) {
+	// Derivatives wrap the processor of their argument.
+	switch strings.ToLower(c.Name) {
+	case "derivative", "non_negative_derivative":
+		return p.planDerivative(e, c)
+	}
+
 	// Ensure there is a single argument.
 	if c.Name == "percentile" {
 		if len(c.Args) != 2 {
@@ -177,6 +300,10 @@ func (p *Planner) planCall(e *Executor, c *Call) (Processor, error) {
 		return nil, err
 	}
 
+	// Aggregates read every point in chronological order.
+	// Ordering and limits are applied to the aggregated rows by the executor.
+	stmt.SortFields, stmt.Limit, stmt.Offset = nil, 0, 0
+
 	// Retrieve a list of iterators for the substatement.
 	itrs, err := e.tx.CreateIterators(stmt)
 	if err != nil {
@@ -184,48 +311,88 @@ func (p *Planner) planCall(e *Executor, c *Call) (Processor, error) {
 	}
 
 	// Retrieve map & reduce functions by name.
-	var mapFn MapFunc
-	var reduceFn ReduceFunc
-	switch strings.ToLower(c.Name) {
-	case "count":
-		mapFn, reduceFn = MapCount, ReduceSum
-	case "sum":
-		mapFn, reduceFn = MapSum, ReduceSum
-	case "mean":
-		mapFn, reduceFn = MapMean, ReduceMean
-	case "min":
-		mapFn, reduceFn = MapMin, ReduceMin
-	case "max":
-		mapFn, reduceFn = MapMax, ReduceMax
-	case "spread":
-		mapFn, reduceFn = MapSpread, ReduceSpread
-	case "stddev":
-		mapFn, reduceFn = MapStddev, ReduceStddev
-	case "first":
-		mapFn, reduceFn = MapFirst, ReduceFirst
-	case "last":
-		mapFn, reduceFn = MapLast, ReduceLast
-	case "percentile":
-		lit, ok := c.Args[1].(*NumberLiteral)
-		if !ok {
-			return nil, fmt.Errorf("expected float argument in percentile()")
-		}
-		mapFn, reduceFn = MapEcho, ReducePercentile(lit.Val)
-	default:
-		return nil, fmt.Errorf("function not found: %q", c.Name)
+	mapFn, err := InitializeMapFunc(c)
+	if err != nil {
+		return nil, err
+	}
+	reduceFn, err := InitializeReduceFunc(c)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create mapper and reducer.
+	// Empty intervals are left to the executor when a fill option is used.
 	mappers := make([]*Mapper, len(itrs))
 	for i, itr := range itrs {
 		mappers[i] = NewMapper(mapFn, itr, e.interval)
+		mappers[i].call = c
+		mappers[i].SkipEmpty = e.stmt.Fill != DefaultFill
 	}
+	e.mappers = append(e.mappers, mappers...)
 	r := NewReducer(reduceFn, mappers)
 	r.name = lastIdent(stmt.Source.(*Measurement).Name)
 
 	return r, nil
 }
 
+// planDerivative generates a processor for the rate of change of a raw field
+// or of an aggregate grouped by time.
+func (p *Planner) planDerivative(e *Executor, c *Call) (Processor, error) {
+	// Ensure there is a field or call argument and an optional duration unit.
+	if len(c.Args) == 0 || len(c.Args) > 2 {
+		return nil, fmt.Errorf("invalid number of arguments for %s, expected at least 1 but no more than 2, got %d", c.Name, len(c.Args))
+	}
+	unit := 1 * time.Second
+	if len(c.Args) == 2 {
+		lit, ok := c.Args[1].(*DurationLiteral)
+		if !ok || lit.Val <= 0 {
+			return nil, fmt.Errorf("expected duration argument in %s()", c.Name)
+		}
+		unit = lit.Val
+	}
+
+	nonNegative := strings.ToLower(c.Name) == "non_negative_derivative"
+
+	var input Processor
+	var err error
+	switch arg := c.Args[0].(type) {
+	case *VarRef:
+		stmt, err := e.stmt.Substatement(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		// The first point has no derivative so read one more point than the limit.
+		// Negative rates are dropped so the number of points needed is unknown.
+		if nonNegative {
+			stmt.Limit, stmt.Offset = 0, 0
+		} else if stmt.Limit > 0 {
+			stmt.Limit++
+		}
+		if input, err = p.planRawSubstatement(e, stmt); err != nil {
+			return nil, err
+		}
+	case *Call:
+		if e.interval == 0 {
+			return nil, fmt.Errorf("%s of an aggregate requires a GROUP BY time()", c.Name)
+		}
+		if input, err = p.planCall(e, arg); err != nil {
+			return nil, err
+		}
+
+		// Only compute the rate of change between intervals with data.
+		if r, ok := input.(*Reducer); ok {
+			for _, m := range r.mappers {
+				m.SkipEmpty = true
+			}
+		}
+	default:
+		return nil, fmt.Errorf("expected field or function argument in %s()", c.Name)
+	}
+
+	return newDerivativeProcessor(input, unit, nonNegative), nil
+}
+
 // planBinaryExpr generates a processor for a binary expression.
 // A binary expression represents a join operator between two processors.
 func (p *Planner) planBinaryExpr(e *Executor, expr *BinaryExpr) (Processor, error) {
@@ -253,6 +420,8 @@ type Executor struct {
 	processors []Processor      // per-field processors
 	interval   time.Duration    // group by interval
 	tags       []string         // dimensional tag keys
+	rawFields  bool             // single processor emitting a value per field
+	mappers    []*Mapper        // all mappers used by the processors
 }
 
 // newExecutor returns an executor associated with a transaction and statement.
@@ -287,8 +456,6 @@ func (e *Executor) execute(out chan *Row) {
 	// Ensure the transaction closes after execution.
 	defer e.tx.Close()
 
-	// TODO: Support multi-value rows.
-
 	// Initialize map of rows by encoded tagset.
 	rows := make(map[string]*Row)
 
@@ -308,15 +475,54 @@ loop:
 			for k, v := range m {
 				// Lookup row values and populate data.
 				values := e.createRowValuesIfNotExists(rows, e.processors[0].Name(), k.Timestamp, k.Values)
-				values[i+1] = v
+				if e.rawFields {
+					copy(values[1:], v.([]interface{}))
+				} else {
+					values[i+1] = v
+				}
+			}
+		}
+	}
+
+	// Return an error instead of partial results if data could not be mapped.
+	for _, m := range e.mappers {
+		if err := m.Err(); err != nil {
+			out <- &Row{Err: err}
+			close(out)
+			return
+		}
+	}
+
+	// Fill intervals without data when grouping aggregates by time.
+	if e.interval > 0 && e.stmt.Aggregated() && e.stmt.Fill != DefaultFill && e.stmt.Fill != NoFill {
+		tmin, tmax := TimeRange(e.stmt.Condition)
+		for _, row := range rows {
+			if err := e.fill(row, tmin, tmax); err != nil {
+				out <- &Row{Err: err}
+				close(out)
+				return
 			}
 		}
 	}
 
 	// Normalize rows and values.
+	// Order values by time and apply the offset and limit to each row.
 	// Convert all times to timestamps
 	a := make(Rows, 0, len(rows))
 	for _, row := range rows {
+		if !e.stmt.TimeAscending() {
+			sort.Stable(sort.Reverse(valuesByTime(row.Values)))
+		}
+		if e.stmt.Offset > 0 {
+			if e.stmt.Offset >= len(row.Values) {
+				continue
+			}
+			row.Values = row.Values[e.stmt.Offset:]
+		}
+		if e.stmt.Limit > 0 && len(row.Values) > e.stmt.Limit {
+			row.Values = row.Values[:e.stmt.Limit]
+		}
+
 		for _, values := range row.Values {
 			t := time.Unix(0, values[0].(int64))
 			values[0] = t.UTC()
@@ -334,6 +540,83 @@ loop:
 	close(out)
 }
 
+// fill adds values for every interval without data between tmin and tmax
+// according to the statement's fill option. Without a lower or upper time
+// bound the fill starts at the row's first interval with data or ends at its
+// last interval with data. Returns an error if the row spans more than
+// MaxFillIntervals intervals.
+func (e *Executor) fill(row *Row, tmin, tmax time.Time) error {
+	if len(row.Values) == 0 {
+		return nil
+	}
+
+	// Determine the first and last intervals.
+	interval := e.interval.Nanoseconds()
+	start, end := row.Values[0][0].(int64), row.Values[len(row.Values)-1][0].(int64)
+	if !tmin.IsZero() {
+		start = tmin.UnixNano() - (tmin.UnixNano() % interval)
+	}
+	if !tmax.IsZero() {
+		end = tmax.UnixNano() - (tmax.UnixNano() % interval)
+	}
+	if n := (end - start) / interval; n > MaxFillIntervals {
+		return fmt.Errorf("too many intervals to fill: %d, max %d", n, MaxFillIntervals)
+	}
+
+	existing := row.Values
+	values := make([][]interface{}, 0, len(existing))
+	filled := make([]bool, 0, len(existing))
+	for t := start; t <= end; t += interval {
+		// Use the existing values for the interval, if available.
+		for len(existing) > 0 && existing[0][0].(int64) < t {
+			existing = existing[1:]
+		}
+		if len(existing) > 0 && existing[0][0].(int64) == t {
+			values, filled = append(values, existing[0]), append(filled, false)
+			existing = existing[1:]
+			continue
+		}
+
+		// Otherwise generate values for the empty interval.
+		v := make([]interface{}, len(e.stmt.Fields)+1)
+		v[0] = t
+		switch e.stmt.Fill {
+		case NumberFill:
+			for i := 1; i < len(v); i++ {
+				v[i] = e.stmt.FillValue
+			}
+		case PreviousFill:
+			if n := len(values); n > 0 {
+				copy(v[1:], values[n-1][1:])
+			}
+		}
+		values, filled = append(values, v), append(filled, true)
+	}
+
+	// Interpolate each column between the intervals surrounding a gap.
+	if e.stmt.Fill == LinearFill {
+		for i := 1; i < len(e.stmt.Fields)+1; i++ {
+			prev := -1
+			for j := range values {
+				if filled[j] {
+					continue
+				}
+				if prev >= 0 && j-prev > 1 {
+					y0, ok0 := numberValue(values[prev][i])
+					y1, ok1 := numberValue(values[j][i])
+					for k := prev + 1; ok0 && ok1 && k < j; k++ {
+						values[k][i] = y0 + (y1-y0)*float64(k-prev)/float64(j-prev)
+					}
+				}
+				prev = j
+			}
+		}
+	}
+
+	row.Values = values
+	return nil
+}
+
 // creates a new value set if one does not already exist for a given tagset + timestamp.
 func (e *Executor) createRowValuesIfNotExists(rows map[string]*Row, name string, timestamp int64, tagset string) []interface{} {
 	// TODO: Add "name" to lookup key.
@@ -366,7 +649,7 @@ func (e *Executor) createRowValuesIfNotExists(rows map[string]*Row, name string,
 
 	// If no values exist or last value doesn't match the timestamp then create new.
 	if len(row.Values) == 0 || row.Values[len(row.Values)-1][0] != timestamp {
-		values := make([]interface{}, len(e.processors)+1)
+		values := make([]interface{}, len(e.stmt.Fields)+1)
 		values[0] = timestamp
 		row.Values = append(row.Values, values)
 	}
@@ -374,11 +657,25 @@ func (e *Executor) createRowValuesIfNotExists(rows map[string]*Row, name string,
 	return row.Values[len(row.Values)-1]
 }
 
+// valuesByTime sorts row values by their timestamp column.
+type valuesByTime [][]interface{}
+
+func (p valuesByTime) Len() int           { return len(p) }
+func (p valuesByTime) Less(i, j int) bool { return p[i][0].(int64) < p[j][0].(int64) }
+func (p valuesByTime) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
 // Mapper represents an object for processing iterators.
 type Mapper struct {
 	fn       MapFunc  // map function
+	call     *Call    // function call of the map function, nil for raw queries
 	itr      Iterator // iterators
 	interval int64    // grouping interval
+
+	// Do not map intervals without data.
+	SkipEmpty bool
+
+	mu  sync.Mutex
+	err error // error from reading the iterator
 }
 
 // NewMapper returns a new instance of Mapper with a given function and interval.
@@ -394,10 +691,33 @@ func NewMapper(fn MapFunc, itr Iterator, interval time.Duration) *Mapper {
 // Returns a nil emitter if no data was found.
 func (m *Mapper) Map() *Emitter {
 	e := NewEmitter(1)
-	go m.run(e)
+	if itr, ok := m.itr.(RemoteIterator); ok {
+		go m.runRemote(itr, e)
+	} else {
+		go m.run(e)
+	}
 	return e
 }
 
+// Err returns the error that occurred while mapping the iterator, if any.
+func (m *Mapper) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// runRemote executes the map function on the node that owns the iterator's data.
+func (m *Mapper) runRemote(itr RemoteIterator, e *Emitter) {
+	// Close emitter when we're done.
+	defer func() { _ = e.Close() }()
+
+	if err := itr.MapRemote(m.call, m.interval, m.SkipEmpty, e); err != nil {
+		m.mu.Lock()
+		m.err = err
+		m.mu.Unlock()
+	}
+}
+
 func (m *Mapper) run(e *Emitter) {
 	// Close emitter when we're done.
 	defer func() { _ = e.Close() }()
@@ -425,11 +745,23 @@ func (m *Mapper) run(e *Emitter) {
 		}
 
 		// Execute the map function.
-		m.fn(bufItr, e, tmin)
+		// Intervals without data are skipped if they will be filled later.
+		if k, _, _ := bufItr.Peek(); k != 0 || !m.SkipEmpty {
+			m.fn(bufItr, e, tmin)
+		}
 
 		// Move the interval forward.
 		tmin += m.interval
 	}
+
+	// Save the error if the iterator stopped early.
+	if itr, ok := m.itr.(ErrIterator); ok {
+		if err := itr.Err(); err != nil {
+			m.mu.Lock()
+			m.err = err
+			m.mu.Unlock()
+		}
+	}
 }
 
 // bufIterator represents a buffer iterator.
@@ -480,22 +812,86 @@ func (i *bufIterator) EOF() bool { i.Peek(); return i.buf.key == 0 }
 // MapFunc represents a function used for mapping iterators.
 type MapFunc func(Iterator, *Emitter, int64)
 
+// InitializeMapFunc returns the map function for a function call.
+// Raw queries, which have no call, use MapRawQuery.
+func InitializeMapFunc(c *Call) (MapFunc, error) {
+	if c == nil {
+		return MapRawQuery, nil
+	}
+
+	switch strings.ToLower(c.Name) {
+	case "count":
+		return MapCount, nil
+	case "sum":
+		return MapSum, nil
+	case "mean":
+		return MapMean, nil
+	case "min":
+		return MapMin, nil
+	case "max":
+		return MapMax, nil
+	case "spread":
+		return MapSpread, nil
+	case "stddev":
+		return MapStddev, nil
+	case "first":
+		return MapFirst, nil
+	case "last":
+		return MapLast, nil
+	case "percentile":
+		return MapEcho, nil
+	default:
+		return nil, fmt.Errorf("function not found: %q", c.Name)
+	}
+}
+
+// InitializeReduceFunc returns the reduce function for a function call.
+func InitializeReduceFunc(c *Call) (ReduceFunc, error) {
+	switch strings.ToLower(c.Name) {
+	case "count", "sum":
+		return ReduceSum, nil
+	case "mean":
+		return ReduceMean, nil
+	case "min":
+		return ReduceMin, nil
+	case "max":
+		return ReduceMax, nil
+	case "spread":
+		return ReduceSpread, nil
+	case "stddev":
+		return ReduceStddev, nil
+	case "first":
+		return ReduceFirst, nil
+	case "last":
+		return ReduceLast, nil
+	case "percentile":
+		lit, ok := c.Args[1].(*NumberLiteral)
+		if !ok {
+			return nil, fmt.Errorf("expected float argument in percentile()")
+		}
+		return ReducePercentile(lit.Val), nil
+	default:
+		return nil, fmt.Errorf("function not found: %q", c.Name)
+	}
+}
+
 // MapCount computes the number of values in an iterator.
 func MapCount(itr Iterator, e *Emitter, tmin int64) {
 	n := 0
 	for k, _, _ := itr.Next(); k != 0; k, _, _ = itr.Next() {
 		n++
 	}
-	e.Emit(Key{tmin, itr.Tags()}, float64(n))
+	e.Emit(Key{tmin, itr.Tags()}, int64(n))
 }
 
 // MapSum computes the summation of values in an iterator.
+// The sum of integer values is an integer.
 func MapSum(itr Iterator, e *Emitter, tmin int64) {
-	n := float64(0)
+	var n numberSum
 	for k, _, v := itr.Next(); k != 0; k, _, v = itr.Next() {
-		n += v.(float64)
+		n.add(v)
 	}
-	e.Emit(Key{tmin, itr.Tags()}, n)
+	e.Emit(Key{tmin, itr.Tags()}, n.value())
 }
 
 // Processor represents an object for joining reducer output.
@@ -508,9 +904,10 @@ type Processor interface {
 // Reducer represents an object for processing mapper output.
 // Implements processor.
 type Reducer struct {
-	name    string
-	fn      ReduceFunc // reduce function
-	mappers []*Mapper  // child mappers
+	name       string
+	fn         ReduceFunc // reduce function
+	mappers    []*Mapper  // child mappers
+	descending bool       // mapper output is in reverse time order
 
 	c <-chan map[Key]interface{}
 }
@@ -564,7 +961,11 @@ func (r *Reducer) run(e *Emitter, inputs []<-chan map[Key]interface{}) {
 			if rec == nil {
 				continue
 			}
-			if timestamp == 0 || rec.Key.Timestamp < timestamp {
+			if timestamp == 0 {
+				timestamp = rec.Key.Timestamp
+			} else if !r.descending && rec.Key.Timestamp < timestamp {
+				timestamp = rec.Key.Timestamp
+			} else if r.descending && rec.Key.Timestamp > timestamp {
 				timestamp = rec.Key.Timestamp
 			}
 		}
@@ -645,11 +1046,37 @@ type ReduceFunc func(Key, []interface{}, *Emitter)
 
 // ReduceSum computes the sum of values for each key.
 func ReduceSum(key Key, values []interface{}, e *Emitter) {
-	var n float64
+	var n numberSum
 	for _, v := range values {
-		n += v.(float64)
+		n.add(v)
 	}
-	e.Emit(key, n)
+	e.Emit(key, n.value())
+}
+
+// numberSum accumulates a sum that remains an integer until a float is added.
+type numberSum struct {
+	i       int64
+	f       float64
+	isFloat bool
+}
+
+// add adds an integer or float value to the sum.
+func (s *numberSum) add(v interface{}) {
+	if i, ok := v.(int64); ok {
+		s.i += i
+		return
+	}
+	s.f += v.(float64)
+	s.isFloat = true
+}
+
+// value returns the sum as an int64 if only integers were added.
+// Otherwise returns the sum as a float64.
+func (s *numberSum) value() interface{} {
+	if s.isFloat {
+		return s.f + float64(s.i)
+	}
+	return s.i
 }
 
 // MapMean computes the count and sum of values in an iterator to be combined by the reducer.
@@ -658,7 +1085,7 @@ func MapMean(itr Iterator, e *Emitter, tmin int64) {
 
 	for k, _, v := itr.Next(); k != 0; k, _, v = itr.Next() {
 		out.Count++
-		out.Sum += v.(float64)
+		out.Sum += toFloat64(v)
 	}
 	e.Emit(Key{tmin, itr.Tags()}, out)
 }
@@ -681,81 +1108,67 @@ func ReduceMean(key Key, values []interface{}, e *Emitter) {
 
 // MapMin collects the values to pass to the reducer
 func MapMin(itr Iterator, e *Emitter, tmin int64) {
-	var min float64
-	pointsYielded := false
-
+	var min interface{}
 	for k, _, v := itr.Next(); k != 0; k, _, v = itr.Next() {
-		val := v.(float64)
-		// Initialize min
-		if !pointsYielded {
-			min = val
-			pointsYielded = true
+		if min == nil || numberLess(v, min) {
+			min = v
 		}
-		min = math.Min(min, val)
 	}
-	if pointsYielded {
+	if min != nil {
 		e.Emit(Key{tmin, itr.Tags()}, min)
 	}
 }
 
 // ReduceMin computes the min of value.
 func ReduceMin(key Key, values []interface{}, e *Emitter) {
-	var min float64
-	pointsYielded := false
-
+	var min interface{}
 	for _, v := range values {
-		val := v.(float64)
-		// Initialize min
-		if !pointsYielded {
-			min = val
-			pointsYielded = true
+		if min == nil || numberLess(v, min) {
+			min = v
 		}
-		m := math.Min(min, val)
-		min = m
 	}
-	if pointsYielded {
+	if min != nil {
 		e.Emit(key, min)
 	}
 }
 
 // MapMax collects the values to pass to the reducer
 func MapMax(itr Iterator, e *Emitter, tmax int64) {
-	var max float64
-	pointsYielded := false
-
+	var max interface{}
 	for k, _, v := itr.Next(); k != 0; k, _, v = itr.Next() {
-		val := v.(float64)
-		// Initialize max
-		if !pointsYielded {
-			max = val
-			pointsYielded = true
+		if max == nil || numberLess(max, v) {
+			max = v
 		}
-		max = math.Max(max, val)
 	}
-	if pointsYielded {
+	if max != nil {
 		e.Emit(Key{tmax, itr.Tags()}, max)
 	}
 }
 
 // ReduceMax computes the max of value.
 func ReduceMax(key Key, values []interface{}, e *Emitter) {
-	var max float64
-	pointsYielded := false
-
+	var max interface{}
 	for _, v := range values {
-		val := v.(float64)
-		// Initialize max
-		if !pointsYielded {
-			max = val
-			pointsYielded = true
+		if max == nil || numberLess(max, v) {
+			max = v
 		}
-		max = math.Max(max, val)
 	}
-	if pointsYielded {
+	if max != nil {
 		e.Emit(key, max)
 	}
 }
 
+// numberLess returns true if a is less than b. Integers are only compared
+// as floats when the other value is a float.
+func numberLess(a, b interface{}) bool {
+	if a, ok := a.(int64); ok {
+		if b, ok := b.(int64); ok {
+			return a < b
+		}
+	}
+	return toFloat64(a) < toFloat64(b)
+}
+
 type spreadMapOutput struct {
 	Min, Max float64
 }
@@ -766,7 +1179,7 @@ func MapSpread(itr Iterator, e *Emitter, tmax int64) {
 	pointsYielded := false
 
 	for k, _, v := itr.Next(); k != 0; k, _, v = itr.Next() {
-		val := v.(float64)
+		val := toFloat64(v)
 		// Initialize
 		if !pointsYielded {
 			out.Max = val
@@ -807,7 +1220,7 @@ func MapStddev(itr Iterator, e *Emitter, tmax int64) {
 	var values []float64
 
 	for k, _, v := itr.Next(); k != 0; k, _, v = itr.Next() {
-		values = append(values, v.(float64))
+		values = append(values, toFloat64(v))
 		// Emit in batches.
 		// unbounded emission of data can lead to excessive memory use
 		// or other potential performance problems.
@@ -970,7 +1383,7 @@ func ReducePercentile(percentile float64) ReduceFunc {
 		for _, v := range values {
 			vals := v.([]interface{})
 			for _, v := range vals {
-				allValues = append(allValues, v.(float64))
+				allValues = append(allValues, toFloat64(v))
 			}
 		}
 
@@ -1076,23 +1489,100 @@ func (e *binaryExprEvaluator) run() {
 func (e *binaryExprEvaluator) eval(lhs, rhs interface{}) interface{} {
 	switch e.op {
 	case ADD:
-		return lhs.(float64) + rhs.(float64)
+		return toFloat64(lhs) + toFloat64(rhs)
 	case SUB:
-		return lhs.(float64) - rhs.(float64)
+		return toFloat64(lhs) - toFloat64(rhs)
 	case MUL:
-		return lhs.(float64) * rhs.(float64)
+		return toFloat64(lhs) * toFloat64(rhs)
 	case DIV:
-		rhs := rhs.(float64)
+		rhs := toFloat64(rhs)
 		if rhs == 0 {
 			return float64(0)
 		}
-		return lhs.(float64) / rhs
+		return toFloat64(lhs) / rhs
 	default:
 		// TODO: Validate operation & data types.
 		panic("invalid operation: " + e.op.String())
 	}
 }
 
+// derivativeProcessor represents a processor that computes the rate of change
+// between consecutive values of its input for each tagset.
+type derivativeProcessor struct {
+	input       Processor     // processor being differentiated
+	unit        time.Duration // unit of time for the rate of change
+	nonNegative bool          // drop negative rates of change
+
+	c chan map[Key]interface{}
+}
+
+// newDerivativeProcessor returns a new instance of derivativeProcessor.
+func newDerivativeProcessor(input Processor, unit time.Duration, nonNegative bool) *derivativeProcessor {
+	return &derivativeProcessor{
+		input:       input,
+		unit:        unit,
+		nonNegative: nonNegative,
+		c:           make(chan map[Key]interface{}, 0),
+	}
+}
+
+// Process begins streaming values from the input processor.
+func (p *derivativeProcessor) Process() {
+	p.input.Process()
+	go p.run()
+}
+
+// C returns the streaming data channel.
+func (p *derivativeProcessor) C() <-chan map[Key]interface{} { return p.c }
+
+// Name returns the source name.
+func (p *derivativeProcessor) Name() string { return p.input.Name() }
+
+// run reads the input values and emits the rate of change from the previous
+// value in the same tagset. The rate is emitted at the later of the two times.
+func (p *derivativeProcessor) run() {
+	prev := make(map[string]Record)
+	for m := range p.input.C() {
+		out := make(map[Key]interface{})
+		for k, v := range m {
+			value, ok := numberValue(v)
+			if !ok {
+				continue
+			}
+
+			curr := Record{Key: k, Value: value}
+			last, ok := prev[k.Values]
+			prev[k.Values] = curr
+			if !ok {
+				continue
+			}
+
+			// Order the pair so the rate is correct for descending input too.
+			a, b := last, curr
+			if a.Key.Timestamp > b.Key.Timestamp {
+				a, b = b, a
+			}
+			elapsed := b.Key.Timestamp - a.Key.Timestamp
+			if elapsed == 0 {
+				continue
+			}
+
+			rate := (b.Value.(float64) - a.Value.(float64)) * float64(p.unit) / float64(elapsed)
+			if p.nonNegative && rate < 0 {
+				continue
+			}
+			out[b.Key] = rate
+		}
+
+		if len(out) > 0 {
+			p.c <- out
+		}
+	}
+
+	// Mark the channel as complete.
+	close(p.c)
+}
+
 // literalProcessor represents a processor that continually sends a literal value.
 type literalProcessor struct {
 	val  interface{}
@@ -1254,3 +1744,22 @@ func UnmarshalStrings(b []byte) (ret []string) {
 		b = b[n+2:]
 	}
 }
+
+// toFloat64 converts an integer or float value to a float64.
+func toFloat64(v interface{}) float64 {
+	if i, ok := v.(int64); ok {
+		return float64(i)
+	}
+	return v.(float64)
+}
+
+// numberValue returns v as a float64 and true if v is an integer or float.
+func numberValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -3,6 +3,7 @@ package influxql_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -32,7 +33,7 @@ func TestMapper_Map(t *testing.T) {
 	if data := <-ch; !reflect.DeepEqual(data, map[influxql.Key]interface{}{influxql.Key{Timestamp: 946684860000000000, Values: "\x00\x03foo"}: float64(70)}) {
 		t.Fatalf("unexpected data(1/foo): %#v", data)
 	}
-	if data := <-ch; !reflect.DeepEqual(data, map[influxql.Key]interface{}{influxql.Key{Timestamp: 946684920000000000, Values: "\x00\x03foo"}: float64(0)}) {
+	if data := <-ch; !reflect.DeepEqual(data, map[influxql.Key]interface{}{influxql.Key{Timestamp: 946684920000000000, Values: "\x00\x03foo"}: int64(0)}) {
 		t.Fatalf("unexpected data(2/foo): %#v", data)
 	}
 	if data := <-ch; !reflect.DeepEqual(data, map[influxql.Key]interface{}{influxql.Key{Timestamp: 946684980000000000, Values: "\x00\x03foo"}: float64(50)}) {
@@ -105,6 +106,49 @@ func TestPlanner_Plan_Count(t *testing.T) {
 	}
 }
 
+// Ensure the planner returns an error instead of partial results if an iterator fails.
+func TestPlanner_Plan_ErrIterator(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			&ErrIterator{
+				Iterator: NewIterator(nil, []Point{{"2000-01-01T00:00:00Z", float64(100)}}),
+				err:      errors.New("invalid block"),
+			}}, nil
+	}
+
+	rs := MustPlanAndExecute(NewDB(tx), `2000-01-01T12:00:00Z`,
+		`SELECT count(value) FROM cpu WHERE time >= '2000-01-01'`)
+	if len(rs) != 1 || rs[0].Err == nil || rs[0].Err.Error() != "invalid block" {
+		t.Fatalf("unexpected resultset: %s", jsonify(rs))
+	}
+}
+
+// Ensure the planner keeps integer results for count, sum, min and max.
+func TestPlanner_Plan_IntegerAggregates(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator(nil, []Point{
+				{"2000-01-01T00:00:00Z", int64(9007199254740993)},
+				{"2000-01-01T00:00:10Z", int64(-2)},
+			}),
+			NewIterator(nil, []Point{
+				{"2000-01-01T00:00:20Z", int64(4)},
+			})}, nil
+	}
+
+	// Expected resultset.
+	exp := minify(`[{"name":"cpu","columns":["time","count","sum","min","max"],"values":[["1970-01-01T00:00:00Z",3,9007199254740995,-2,9007199254740993]]}]`)
+
+	// Execute and compare.
+	rs := MustPlanAndExecute(NewDB(tx), `2000-01-01T12:00:00Z`,
+		`SELECT count(value), sum(value), min(value), max(value) FROM cpu WHERE time >= '2000-01-01'`)
+	if act := minify(jsonify(rs)); exp != act {
+		t.Fatalf("unexpected resultset: %s", act)
+	}
+}
+
 // Ensure the planner can plan and execute a mean query
 func TestPlanner_Plan_Mean(t *testing.T) {
 	tx := NewTx()
@@ -620,6 +664,123 @@ func TestPlanner_Plan_GroupByInterval(t *testing.T) {
 	}
 }
 
+// Ensure the planner can compute derivatives of raw values and of aggregates.
+func TestPlanner_Plan_Derivative(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator(nil, []Point{
+				{"2000-01-01T00:00:00Z", float64(10)},
+				{"2000-01-01T00:00:10Z", float64(30)},
+				{"2000-01-01T00:00:20Z", float64(0)},
+				{"2000-01-01T00:01:00Z", float64(40)},
+				{"2000-01-01T00:01:10Z", float64(60)},
+			})}, nil
+	}
+
+	for i, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SELECT derivative(value) FROM cpu`,
+			exp: `[["2000-01-01T00:00:10Z",2],["2000-01-01T00:00:20Z",-3],["2000-01-01T00:01:00Z",1],["2000-01-01T00:01:10Z",2]]`,
+		},
+		{
+			q:   `SELECT non_negative_derivative(value, 10s) FROM cpu`,
+			exp: `[["2000-01-01T00:00:10Z",20],["2000-01-01T00:01:00Z",10],["2000-01-01T00:01:10Z",20]]`,
+		},
+		{
+			q:   `SELECT derivative(max(value), 1m) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(30s)`,
+			exp: `[["2000-01-01T00:01:00Z",30]]`,
+		},
+	} {
+		rs := MustPlanAndExecute(NewDB(tx), "2000-01-01T00:02:00Z", tt.q)
+		if act := jsonify(rs[0].Values); tt.exp != act {
+			t.Errorf("%d. %s: unexpected values: %s", i, tt.q, act)
+		}
+	}
+
+	// Derivatives of aggregates require a time interval.
+	if _, err := PlanAndExecute(NewDB(tx), "2000-01-01T00:02:00Z", `SELECT derivative(max(value)) FROM cpu`); err == nil || err.Error() != "derivative of an aggregate requires a GROUP BY time()" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the planner fills empty intervals according to the fill option.
+func TestPlanner_Plan_GroupByIntervalFill(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator(nil, []Point{
+				{"2000-01-01T09:00:00Z", float64(10)},
+				{"2000-01-01T10:30:00Z", float64(40)},
+				{"2000-01-01T11:30:00Z", float64(50)},
+			})}, nil
+	}
+
+	for i, tt := range []struct {
+		fill string
+		exp  string
+	}{
+		{fill: ``, exp: `[10,0,0,40,0,50]`},
+		{fill: `fill(null)`, exp: `[10,null,null,40,null,50]`},
+		{fill: `fill(none)`, exp: `[10,40,50]`},
+		{fill: `fill(0)`, exp: `[10,0,0,40,0,50]`},
+		{fill: `fill(previous)`, exp: `[10,10,10,40,40,50]`},
+		{fill: `fill(linear)`, exp: `[10,20,30,40,45,50]`},
+	} {
+		// Query for data since 3 hours ago until now, grouped every 30 minutes.
+		rs := MustPlanAndExecute(NewDB(tx), "2000-01-01T12:00:00Z", `
+			SELECT sum(value)
+			FROM cpu
+			WHERE time >= now() - 3h AND time < now()
+			GROUP BY time(30m) `+tt.fill)
+
+		// Extract the values from the single row.
+		var values []interface{}
+		for _, v := range rs[0].Values {
+			values = append(values, v[1])
+		}
+		if act := jsonify(values); tt.exp != act {
+			t.Errorf("%d. %s: unexpected values: %s", i, tt.fill, act)
+		}
+	}
+}
+
+// Ensure the planner rejects fills over too many intervals.
+func TestPlanner_Plan_GroupByIntervalFill_ErrTooManyIntervals(t *testing.T) {
+	_, err := PlanAndExecute(NewDB(NewTx()), "2001-01-01T00:00:00Z", `
+		SELECT sum(value)
+		FROM cpu
+		WHERE time >= now() - 365d
+		GROUP BY time(1s) fill(null)`)
+	if err == nil || err.Error() != "too many intervals to fill: 31536000, max 100000" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the executor rejects fills over too many intervals between the
+// first and last intervals with data when the query has no time bounds.
+func TestPlanner_Plan_GroupByIntervalFill_Unbounded_ErrTooManyIntervals(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator(nil, []Point{
+				{"2000-01-01T00:00:00Z", float64(10)},
+				{"2000-01-03T00:00:00Z", float64(20)},
+			})}, nil
+	}
+
+	rs := MustPlanAndExecute(NewDB(tx), "2000-01-03T00:00:00Z", `
+		SELECT sum(value)
+		FROM cpu
+		GROUP BY time(1s) fill(null)`)
+	if len(rs) != 1 || rs[0].Err == nil || rs[0].Err.Error() != "too many intervals to fill: 172800, max 100000" {
+		t.Fatalf("unexpected rows: %s", jsonify(rs))
+	}
+}
+
 // Ensure the planner can plan and execute a query grouped by interval and tag.
 func TestPlanner_Plan_GroupByIntervalAndTag(t *testing.T) {
 	tx := NewTx()
@@ -777,6 +938,15 @@ func (i *Iterator) Next() (key int64, data []byte, value interface{}) {
 	return p.Time(), nil, p.Value
 }
 
+// ErrIterator represents an iterator that fails after reading its points.
+type ErrIterator struct {
+	*Iterator
+	err error
+}
+
+// Err returns the iterator's error.
+func (i *ErrIterator) Err() error { return i.err }
+
 // Point represents a single value at a given time.
 type Point struct {
 	Timestamp string // ISO-8601 formatted timestamp.
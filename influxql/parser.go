@@ -87,6 +87,8 @@ func (p *Parser) ParseStatement() (Statement, error) {
 		return p.parseRevokeStatement()
 	case ALTER:
 		return p.parseAlterStatement()
+	case REBALANCE:
+		return p.parseRebalanceShardsStatement()
 	default:
 		return nil, newParseError(tokstr(tok, lit), []string{"SELECT"}, pos)
 	}
@@ -109,6 +111,8 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		return nil, newParseError(tokstr(tok, lit), []string{"KEYS", "VALUES"}, pos)
 	case MEASUREMENTS:
 		return p.parseShowMeasurementsStatement()
+	case REPLICAS:
+		return p.parseShowReplicasStatement()
 	case RETENTION:
 		tok, pos, lit := p.scanIgnoreWhitespace()
 		if tok == POLICIES {
@@ -129,7 +133,7 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		return p.parseShowUsersStatement()
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"CONTINUOUS", "DATABASES", "FIELD", "MEASUREMENTS", "RETENTION", "SERIES", "TAG", "USERS"}, pos)
+	return nil, newParseError(tokstr(tok, lit), []string{"CONTINUOUS", "DATABASES", "FIELD", "MEASUREMENTS", "REPLICAS", "RETENTION", "SERIES", "TAG", "USERS"}, pos)
 }
 
 // parseCreateStatement parses a string and returns a create statement.
@@ -170,9 +174,11 @@ func (p *Parser) parseDropStatement() (Statement, error) {
 		return p.parseDropRetentionPolicyStatement()
 	} else if tok == USER {
 		return p.parseDropUserStatement()
+	} else if tok == MEASUREMENT {
+		return p.parseDropMeasurementStatement()
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"SERIES", "CONTINUOUS"}, pos)
+	return nil, newParseError(tokstr(tok, lit), []string{"SERIES", "CONTINUOUS", "MEASUREMENT"}, pos)
 }
 
 // parseAlterStatement parses a string and returns an alter statement.
@@ -528,6 +534,11 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 		return nil, err
 	}
 
+	// Parse fill options: "fill(<option>)".
+	if stmt.Fill, stmt.FillValue, err = p.parseFill(); err != nil {
+		return nil, err
+	}
+
 	// Parse sort: "ORDER BY FIELD+".
 	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
 		return nil, err
@@ -543,6 +554,16 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 		return nil, err
 	}
 
+	// Parse series limit: "SLIMIT <n>".
+	if stmt.SLimit, err = p.parseOptionalTokenAndInt(SLIMIT); err != nil {
+		return nil, err
+	}
+
+	// Parse series offset: "SOFFSET <n>".
+	if stmt.SOffset, err = p.parseOptionalTokenAndInt(SOFFSET); err != nil {
+		return nil, err
+	}
+
 	return stmt, nil
 }
 
@@ -816,6 +837,12 @@ func (p *Parser) parseTagKeys() ([]string, error) {
 	return tagKeys, nil
 }
 
+// parseShowReplicasStatement parses a string and returns a ShowReplicasStatement.
+// This function assumes the "SHOW REPLICAS" tokens have been consumed.
+func (p *Parser) parseShowReplicasStatement() (*ShowReplicasStatement, error) {
+	return &ShowReplicasStatement{}, nil
+}
+
 // parseShowUsersStatement parses a string and returns a ShowUsersStatement.
 // This function assumes the "SHOW USERS" tokens have been consumed.
 func (p *Parser) parseShowUsersStatement() (*ShowUsersStatement, error) {
@@ -864,13 +891,36 @@ func (p *Parser) parseShowFieldKeysStatement() (*ShowFieldKeysStatement, error)
 // This function assumes the "DROP SERIES" tokens have already been consumed.
 func (p *Parser) parseDropSeriesStatement() (*DropSeriesStatement, error) {
 	stmt := &DropSeriesStatement{}
+	var err error
 
-	// Read the name of the series to drop.
-	lit, err := p.parseIdent()
-	if err != nil {
+	// Parse the id of the series to drop, if specified.
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok == NUMBER {
+		p.unscan()
+		id, err := p.parseInt(1, math.MaxInt32)
+		if err != nil {
+			return nil, err
+		}
+		stmt.SeriesID = uint32(id)
+		return stmt, nil
+	} else if tok != FROM && tok != WHERE {
+		return nil, newParseError(tokstr(tok, lit), []string{"number", "FROM", "WHERE"}, pos)
+	}
+	p.unscan()
+
+	// Parse optional FROM.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == FROM {
+		if stmt.Source, err = p.parseSource(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	// Parse condition: "WHERE EXPR".
+	if stmt.Condition, err = p.parseCondition(); err != nil {
 		return nil, err
 	}
-	stmt.Name = lit
 
 	return stmt, nil
 }
@@ -895,6 +945,15 @@ func (p *Parser) parseShowDatabasesStatement() (*ShowDatabasesStatement, error)
 	return stmt, nil
 }
 
+// parseRebalanceShardsStatement parses a string and returns a RebalanceShardsStatement.
+// This function assumes the "REBALANCE" token has already been consumed.
+func (p *Parser) parseRebalanceShardsStatement() (*RebalanceShardsStatement, error) {
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != SHARDS {
+		return nil, newParseError(tokstr(tok, lit), []string{"SHARDS"}, pos)
+	}
+	return &RebalanceShardsStatement{}, nil
+}
+
 // parseCreateContinuousQueriesStatement parses a string and returns a CreateContinuousQueryStatement.
 // This function assumes the "CREATE CONTINUOUS" tokens have already been consumed.
 func (p *Parser) parseCreateContinuousQueryStatement() (*CreateContinuousQueryStatement, error) {
@@ -989,6 +1048,21 @@ func (p *Parser) parseDropDatabaseStatement() (*DropDatabaseStatement, error) {
 	return stmt, nil
 }
 
+// parseDropMeasurementStatement parses a string and returns a DropMeasurementStatement.
+// This function assumes the DROP MEASUREMENT tokens have already been consumed.
+func (p *Parser) parseDropMeasurementStatement() (*DropMeasurementStatement, error) {
+	stmt := &DropMeasurementStatement{}
+
+	// Parse the name of the measurement to be dropped.
+	lit, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = lit
+
+	return stmt, nil
+}
+
 // parseDropRetentionPolicyStatement parses a string and returns a DropRetentionPolicyStatement.
 // This function assumes the DROP RETENTION POLICY tokens have been consumed.
 func (p *Parser) parseDropRetentionPolicyStatement() (*DropRetentionPolicyStatement, error) {
@@ -1302,6 +1376,43 @@ func (p *Parser) parseDimension() (*Dimension, error) {
 	return &Dimension{Expr: expr}, nil
 }
 
+// parseFill parses the optional fill() call following the dimensions.
+func (p *Parser) parseFill() (FillOption, interface{}, error) {
+	// Return the default if the next token is not "fill".
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	p.unscan()
+	if tok != IDENT || strings.ToLower(lit) != "fill" {
+		return DefaultFill, nil, nil
+	}
+
+	// Parse the call and its single argument.
+	expr, err := p.ParseExpr()
+	if err != nil {
+		return DefaultFill, nil, err
+	}
+	call, ok := expr.(*Call)
+	if !ok || len(call.Args) != 1 {
+		return DefaultFill, nil, &ParseError{Message: "fill requires an argument, e.g.: 0, null, none, previous, linear", Pos: pos}
+	}
+
+	switch arg := call.Args[0].(type) {
+	case *NumberLiteral:
+		return NumberFill, arg.Val, nil
+	case *VarRef:
+		switch strings.ToLower(arg.Val) {
+		case "null":
+			return NullFill, nil, nil
+		case "none":
+			return NoFill, nil, nil
+		case "previous":
+			return PreviousFill, nil, nil
+		case "linear":
+			return LinearFill, nil, nil
+		}
+	}
+	return DefaultFill, nil, &ParseError{Message: fmt.Sprintf("invalid fill option: %s", call.Args[0].String()), Pos: pos}
+}
+
 // parseOptionalTokenAndInt parses the specified token followed
 // by an int, if it exists.
 func (p *Parser) parseOptionalTokenAndInt(t Token) (int, error) {
@@ -1395,10 +1506,11 @@ func (p *Parser) parseSortField() (*SortField, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
 	if tok == IDENT || tok == STRING {
 		field.Name = lit
-		// Check for optional ASC or DESC token.
+		// Check for optional ASC or DESC token. Fields sort ascending by default.
 		tok, pos, lit = p.scanIgnoreWhitespace()
 		if tok != ASC && tok != DESC {
 			p.unscan()
+			field.Ascending = true
 			return field, nil
 		}
 	} else if tok != ASC && tok != DESC {
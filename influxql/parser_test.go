@@ -82,6 +82,41 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement with SLIMIT and SOFFSET
+		{
+			s: `SELECT field1 FROM myseries GROUP BY host LIMIT 10 SLIMIT 20 SOFFSET 5`,
+			stmt: &influxql.SelectStatement{
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "field1"}}},
+				Source:     &influxql.Measurement{Name: "myseries"},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.VarRef{Val: "host"}}},
+				Limit:      10,
+				SLimit:     20,
+				SOffset:    5,
+			},
+		},
+
+		// SELECT statement with fill
+		{
+			s: `SELECT sum(value) FROM cpu GROUP BY time(10s) fill(previous)`,
+			stmt: &influxql.SelectStatement{
+				Fields:     []*influxql.Field{{Expr: &influxql.Call{Name: "sum", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}}},
+				Source:     &influxql.Measurement{Name: "cpu"},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: 10 * time.Second}}}}},
+				Fill:       influxql.PreviousFill,
+			},
+		},
+		{
+			s: `SELECT sum(value) FROM cpu GROUP BY time(10s) fill(1) LIMIT 10`,
+			stmt: &influxql.SelectStatement{
+				Fields:     []*influxql.Field{{Expr: &influxql.Call{Name: "sum", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}}},
+				Source:     &influxql.Measurement{Name: "cpu"},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: 10 * time.Second}}}}},
+				Fill:       influxql.NumberFill,
+				FillValue:  float64(1),
+				Limit:      10,
+			},
+		},
+
 		// SELECT statement with JOIN
 		{
 			s: `SELECT field1 FROM join(aa,"bb", cc) JOIN cc`,
@@ -128,7 +163,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				Source: &influxql.Measurement{Name: "myseries"},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -154,6 +189,12 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.ShowDatabasesStatement{},
 		},
 
+		// REBALANCE SHARDS
+		{
+			s:    `REBALANCE SHARDS`,
+			stmt: &influxql.RebalanceShardsStatement{},
+		},
+
 		// SHOW SERIES statement
 		{
 			s:    `SHOW SERIES`,
@@ -171,7 +212,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -189,7 +230,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -224,7 +265,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -244,7 +285,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -292,6 +333,12 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SHOW REPLICAS
+		{
+			s:    `SHOW REPLICAS`,
+			stmt: &influxql.ShowReplicasStatement{},
+		},
+
 		// SHOW USERS
 		{
 			s:    `SHOW USERS`,
@@ -310,7 +357,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				},
 				SortFields: []*influxql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
@@ -319,8 +366,33 @@ func TestParser_ParseStatement(t *testing.T) {
 
 		// DROP SERIES statement
 		{
-			s:    `DROP SERIES myseries`,
-			stmt: &influxql.DropSeriesStatement{Name: "myseries"},
+			s:    `DROP SERIES 1`,
+			stmt: &influxql.DropSeriesStatement{SeriesID: 1},
+		},
+		{
+			s:    `DROP SERIES FROM src`,
+			stmt: &influxql.DropSeriesStatement{Source: &influxql.Measurement{Name: "src"}},
+		},
+		{
+			s: `DROP SERIES WHERE host = 'hosta.influxdb.org'`,
+			stmt: &influxql.DropSeriesStatement{
+				Condition: &influxql.BinaryExpr{
+					Op:  influxql.EQ,
+					LHS: &influxql.VarRef{Val: "host"},
+					RHS: &influxql.StringLiteral{Val: "hosta.influxdb.org"},
+				},
+			},
+		},
+		{
+			s: `DROP SERIES FROM src WHERE host = 'hosta.influxdb.org'`,
+			stmt: &influxql.DropSeriesStatement{
+				Source: &influxql.Measurement{Name: "src"},
+				Condition: &influxql.BinaryExpr{
+					Op:  influxql.EQ,
+					LHS: &influxql.VarRef{Val: "host"},
+					RHS: &influxql.StringLiteral{Val: "hosta.influxdb.org"},
+				},
+			},
 		},
 
 		// SHOW CONTINUOUS QUERIES statement
@@ -406,6 +478,12 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// DROP MEASUREMENT statement
+		{
+			s:    `DROP MEASUREMENT cpu`,
+			stmt: &influxql.DropMeasurementStatement{Name: "cpu"},
+		},
+
 		// DROP CONTINUOUS QUERY statement
 		{
 			s:    `DROP CONTINUOUS QUERY myquery`,
@@ -597,6 +675,11 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT field1 FROM myseries OFFSET`, err: `found EOF, expected number at line 1, char 36`},
 		{s: `SELECT field1 FROM myseries OFFSET 10.5`, err: `fractional parts not allowed in OFFSET at line 1, char 36`},
 		{s: `SELECT field1 FROM myseries OFFSET 0`, err: `OFFSET must be > 0 at line 1, char 36`},
+		{s: `SELECT sum(value) FROM cpu GROUP BY time(10s) fill()`, err: `fill requires an argument, e.g.: 0, null, none, previous, linear at line 1, char 47`},
+		{s: `SELECT sum(value) FROM cpu GROUP BY time(10s) fill(foo)`, err: `invalid fill option: foo at line 1, char 47`},
+		{s: `SELECT field1 FROM myseries SLIMIT`, err: `found EOF, expected number at line 1, char 36`},
+		{s: `SELECT field1 FROM myseries SLIMIT 0`, err: `SLIMIT must be > 0 at line 1, char 36`},
+		{s: `SELECT field1 FROM myseries SOFFSET 10.5`, err: `fractional parts not allowed in SOFFSET at line 1, char 37`},
 		{s: `SELECT field1 FROM myseries ORDER`, err: `found EOF, expected BY at line 1, char 35`},
 		{s: `SELECT field1 FROM myseries ORDER BY /`, err: `found /, expected identifier, ASC, or DESC at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries ORDER BY 1`, err: `found 1, expected identifier, ASC, or DESC at line 1, char 38`},
@@ -608,14 +691,17 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `DELETE`, err: `found EOF, expected FROM at line 1, char 8`},
 		{s: `DELETE FROM`, err: `found EOF, expected identifier at line 1, char 13`},
 		{s: `DELETE FROM myseries WHERE`, err: `found EOF, expected identifier, string, number, bool at line 1, char 28`},
-		{s: `DROP SERIES`, err: `found EOF, expected identifier at line 1, char 13`},
+		{s: `DROP SERIES`, err: `found EOF, expected number, FROM, WHERE at line 1, char 13`},
+		{s: `DROP SERIES 1.5`, err: `number must be an integer at line 1, char 13`},
+		{s: `DROP SERIES myseries`, err: `found myseries, expected number, FROM, WHERE at line 1, char 13`},
 		{s: `SHOW CONTINUOUS`, err: `found EOF, expected QUERIES at line 1, char 17`},
 		{s: `SHOW RETENTION`, err: `found EOF, expected POLICIES at line 1, char 16`},
 		{s: `SHOW RETENTION POLICIES`, err: `found EOF, expected identifier at line 1, char 25`},
-		{s: `SHOW FOO`, err: `found FOO, expected CONTINUOUS, DATABASES, FIELD, MEASUREMENTS, RETENTION, SERIES, TAG, USERS at line 1, char 6`},
+		{s: `SHOW FOO`, err: `found FOO, expected CONTINUOUS, DATABASES, FIELD, MEASUREMENTS, REPLICAS, RETENTION, SERIES, TAG, USERS at line 1, char 6`},
 		{s: `DROP CONTINUOUS`, err: `found EOF, expected QUERY at line 1, char 17`},
 		{s: `DROP CONTINUOUS QUERY`, err: `found EOF, expected identifier at line 1, char 23`},
-		{s: `DROP FOO`, err: `found FOO, expected SERIES, CONTINUOUS at line 1, char 6`},
+		{s: `DROP FOO`, err: `found FOO, expected SERIES, CONTINUOUS, MEASUREMENT at line 1, char 6`},
+		{s: `DROP MEASUREMENT`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `DROP DATABASE`, err: `found EOF, expected identifier at line 1, char 15`},
 		{s: `DROP RETENTION`, err: `found EOF, expected POLICY at line 1, char 16`},
 		{s: `DROP RETENTION POLICY`, err: `found EOF, expected identifier at line 1, char 23`},
@@ -627,6 +713,8 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `CREATE USER testuser WITH PASSWORD`, err: `found EOF, expected string at line 1, char 36`},
 		{s: `CREATE USER testuser WITH PASSWORD 'pwd' WITH`, err: `found EOF, expected ALL at line 1, char 47`},
 		{s: `CREATE USER testuser WITH PASSWORD 'pwd' WITH ALL`, err: `found EOF, expected PRIVILEGES at line 1, char 51`},
+		{s: `REBALANCE`, err: `found EOF, expected SHARDS at line 1, char 11`},
+		{s: `REBALANCE SERIES`, err: `found SERIES, expected SHARDS at line 1, char 11`},
 		{s: `GRANT`, err: `found EOF, expected READ, WRITE, ALL [PRIVILEGES] at line 1, char 7`},
 		{s: `GRANT BOGUS`, err: `found BOGUS, expected READ, WRITE, ALL [PRIVILEGES] at line 1, char 7`},
 		{s: `GRANT READ`, err: `found EOF, expected ON at line 1, char 12`},
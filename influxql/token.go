@@ -94,11 +94,16 @@ const (
 	QUERIES
 	QUERY
 	READ
+	REBALANCE
+	REPLICAS
 	REPLICATION
 	RETENTION
 	REVOKE
 	SELECT
 	SERIES
+	SHARDS
+	SLIMIT
+	SOFFSET
 	TAG
 	TO
 	USER
@@ -186,11 +191,16 @@ var tokens = [...]string{
 	QUERIES:      "QUERIES",
 	QUERY:        "QUERY",
 	READ:         "READ",
+	REBALANCE:    "REBALANCE",
+	REPLICAS:     "REPLICAS",
 	REPLICATION:  "REPLICATION",
 	RETENTION:    "RETENTION",
 	REVOKE:       "REVOKE",
 	SELECT:       "SELECT",
 	SERIES:       "SERIES",
+	SHARDS:       "SHARDS",
+	SLIMIT:       "SLIMIT",
+	SOFFSET:      "SOFFSET",
 	TAG:          "TAG",
 	TO:           "TO",
 	USER:         "USER",
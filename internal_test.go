@@ -3,9 +3,18 @@ package influxdb
 // This file is run within the "influxdb" package and allows for internal unit tests.
 
 import (
+	"encoding/gob"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/boltdb/bolt"
 	"github.com/influxdb/influxdb/influxql"
 )
 
@@ -31,6 +40,54 @@ func TestMeasurement_uniqueTagValues(t *testing.T) {
 	}
 }
 
+// Ensure a field codec returns an error instead of panicking on invalid values.
+func TestFieldCodec_EncodeFields_Error(t *testing.T) {
+	m := NewMeasurement("cpu")
+	m.createFieldIfNotExists("value", influxql.Number)
+	codec := NewFieldCodec(m)
+
+	for i, tt := range []struct {
+		values map[string]interface{}
+		err    string
+	}{
+		{values: map[string]interface{}{"other": float64(1)}, err: `field "other": field not found`},
+		{values: map[string]interface{}{"value": "foo"}, err: `field "value" is type string, mapped as type number`},
+		{values: map[string]interface{}{"value": nil}, err: `field "value" is type <nil>, which is not supported`},
+		{values: map[string]interface{}{"value": []interface{}{float64(1)}}, err: `field "value" is type []interface {}, which is not supported`},
+	} {
+		if _, err := codec.EncodeFields(tt.values); err == nil || err.Error() != tt.err {
+			t.Errorf("%d. unexpected error: exp=%s, got=%v", i, tt.err, err)
+		} else if _, ok := err.(*FieldError); !ok {
+			t.Errorf("%d. unexpected error type: %T", i, err)
+		}
+	}
+}
+
+// Ensure the field codec stores integers without losing precision.
+func TestFieldCodec_Integer(t *testing.T) {
+	m := NewMeasurement("cpu")
+	m.createFieldIfNotExists("count", influxql.Integer)
+	m.createFieldIfNotExists("value", influxql.Number)
+	codec := NewFieldCodec(m)
+
+	// Integers written to a number field are stored as floats.
+	b, err := codec.EncodeFields(map[string]interface{}{"count": int64(9007199254740993), "value": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := codec.DecodeFields(b); !reflect.DeepEqual(values, map[uint8]interface{}{1: int64(9007199254740993), 2: float64(5)}) {
+		t.Fatalf("unexpected values: %#v", values)
+	}
+	if v, err := codec.DecodeByID(1, b); err != nil || v != int64(9007199254740993) {
+		t.Fatalf("unexpected value: %#v (%v)", v, err)
+	}
+
+	// Floats cannot be written to an integer field.
+	if _, err := codec.EncodeFields(map[string]interface{}{"count": float64(1)}); err == nil || err.Error() != `field "count" is type float64, mapped as type integer` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 // Ensure a measurement can expand an expression for all possible tag values used.
 func TestMeasurement_expandExpr(t *testing.T) {
 	m := NewMeasurement("cpu")
@@ -90,6 +147,496 @@ func TestMeasurement_expandExpr(t *testing.T) {
 	}
 }
 
+// Ensure a block can encode and decode points with mixed field types.
+func TestBlock_Marshal(t *testing.T) {
+	points := []blockPoint{
+		{timestamp: 1000, values: map[uint8]interface{}{1: float64(100), 2: true, 3: "foo", 4: int64(9007199254740993)}},
+		{timestamp: 2000, values: map[uint8]interface{}{1: float64(100)}},
+		{timestamp: 3000, values: map[uint8]interface{}{1: float64(-12.5), 3: "", 4: int64(math.MinInt64)}},
+		{timestamp: 3500, values: map[uint8]interface{}{2: false, 4: int64(math.MaxInt64)}},
+		{timestamp: 9000, values: map[uint8]interface{}{1: float64(1e300), 2: true, 3: "bar"}},
+	}
+
+	other, err := unmarshalBlock(marshalBlock(points))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(points, other) {
+		t.Fatalf("mismatch:\n\nexp=%#v\n\ngot=%#v", points, other)
+	}
+}
+
+// Ensure regular points are compressed well below their raw size.
+func TestBlock_Marshal_Compression(t *testing.T) {
+	var points []blockPoint
+	for i := 0; i < maxPointsPerBlock; i++ {
+		points = append(points, blockPoint{timestamp: int64(i) * 10e9, values: map[uint8]interface{}{1: float64(i % 10)}})
+	}
+
+	b := marshalBlock(points)
+	if n := len(b); n > 4*len(points) {
+		t.Fatalf("block too large: %d bytes", n)
+	}
+	if other, err := unmarshalBlock(b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(points, other) {
+		t.Fatal("mismatch")
+	}
+}
+
+// Ensure a truncated block returns an error instead of panicking.
+func TestBlock_Unmarshal_ErrInvalidBlock(t *testing.T) {
+	b := marshalBlock([]blockPoint{
+		{timestamp: 1000, values: map[uint8]interface{}{1: float64(100), 3: "foo"}},
+		{timestamp: 2000, values: map[uint8]interface{}{1: float64(200), 3: "bar"}},
+	})
+	for i := 0; i < len(b); i++ {
+		if _, err := unmarshalBlock(b[:i]); err != ErrInvalidBlock {
+			t.Fatalf("%d. unexpected error: %v", i, err)
+		}
+	}
+}
+
+// Ensure points can be written out of order and are split into multiple blocks.
+func TestBlock_writeBlocks(t *testing.T) {
+	db := mustOpenBolt()
+	defer db.Close()
+
+	// Write points in reverse order across several batches.
+	var exp []blockPoint
+	for i := 2*maxPointsPerBlock + 10; i > 0; i-- {
+		p := blockPoint{timestamp: int64(i), values: map[uint8]interface{}{1: float64(i)}}
+		exp = append([]blockPoint{p}, exp...)
+		if err := db.Update(func(tx *bolt.Tx) error {
+			b, _ := tx.CreateBucketIfNotExists([]byte("series"))
+			return writeBlocks(b, []blockPoint{p}, true)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Overwrite a point and attempt to write without overwriting.
+	exp[9].values = map[uint8]interface{}{2: "updated"}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("series"))
+		if err := writeBlocks(b, []blockPoint{{timestamp: 10, values: map[uint8]interface{}{2: "updated"}}}, true); err != nil {
+			return err
+		}
+		return writeBlocks(b, []blockPoint{{timestamp: 10, values: map[uint8]interface{}{2: "ignored"}}}, false)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read all blocks back.
+	var got []blockPoint
+	if err := db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte("series")).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			points, err := unmarshalBlock(v)
+			if err != nil {
+				return err
+			} else if len(points) > maxPointsPerBlock {
+				t.Fatalf("block too large: %d points", len(points))
+			} else if int64(btou64(k)) != points[0].timestamp {
+				t.Fatalf("unexpected block key: %d", btou64(k))
+			}
+			got = append(got, points...)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(exp, got) {
+		t.Fatalf("mismatch: exp=%d points, got=%d points", len(exp), len(got))
+	}
+}
+
+// Ensure points appended one at a time are merged into a small number of blocks.
+func TestBlock_writeBlocks_Append(t *testing.T) {
+	db := mustOpenBolt()
+	defer db.Close()
+
+	var exp []blockPoint
+	for i := 1; i <= 2*maxPointsPerBlock+10; i++ {
+		p := blockPoint{timestamp: int64(i), values: map[uint8]interface{}{1: float64(i)}}
+		exp = append(exp, p)
+		if err := db.Update(func(tx *bolt.Tx) error {
+			b, _ := tx.CreateBucketIfNotExists([]byte("series"))
+			return writeBlocks(b, []blockPoint{p}, true)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Read all blocks back.
+	var got []blockPoint
+	var n int
+	if err := db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte("series")).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			points, err := unmarshalBlock(v)
+			if err != nil {
+				return err
+			} else if int64(btou64(k)) != points[0].timestamp {
+				t.Fatalf("unexpected block key: %d", btou64(k))
+			}
+			got = append(got, points...)
+			n++
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(exp, got) {
+		t.Fatalf("mismatch: exp=%d points, got=%d points", len(exp), len(got))
+	} else if n > 16 {
+		t.Fatalf("too many blocks: %d", n)
+	}
+}
+
+// Ensure a range of points can be removed from a series bucket.
+func TestBlock_deleteBlockRange(t *testing.T) {
+	db := mustOpenBolt()
+	defer db.Close()
+
+	// Write points across several blocks.
+	var points []blockPoint
+	for i := 1; i <= 2*maxPointsPerBlock+10; i++ {
+		points = append(points, blockPoint{timestamp: int64(i), values: map[uint8]interface{}{1: float64(i)}})
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b, _ := tx.CreateBucketIfNotExists([]byte("series"))
+		return writeBlocks(b, points, true)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete a range spanning a block boundary.
+	min, max := int64(maxPointsPerBlock-5), int64(maxPointsPerBlock+5)
+	if err := db.Update(func(tx *bolt.Tx) error {
+		return deleteBlockRange(tx.Bucket([]byte("series")), min, max)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var exp []blockPoint
+	for _, p := range points {
+		if p.timestamp < min || p.timestamp > max {
+			exp = append(exp, p)
+		}
+	}
+
+	// Read all blocks back.
+	var got []blockPoint
+	if err := db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte("series")).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			points, err := unmarshalBlock(v)
+			if err != nil {
+				return err
+			} else if int64(btou64(k)) != points[0].timestamp {
+				t.Fatalf("unexpected block key: %d", btou64(k))
+			}
+			got = append(got, points...)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(exp, got) {
+		t.Fatalf("mismatch: exp=%d points, got=%d points", len(exp), len(got))
+	}
+}
+
+// Ensure a remote iterator fails over to another owner when one cannot be reached.
+func TestRemoteIterator_MapRemote_Failover(t *testing.T) {
+	// Create an owner that is down and an owner that returns mapper output.
+	down := httptest.NewServer(nil)
+	down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m RemoteMapper
+		if err := gob.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Error(err)
+		} else if m.ShardID != 10 || m.Call.Name != "count" || m.Interval != 5 {
+			t.Errorf("unexpected request: %#v", m)
+		}
+
+		enc := gob.NewEncoder(w)
+		enc.Encode(&mapperOutput{Key: influxql.Key{Timestamp: 100, Values: "x"}, Value: float64(2)})
+		enc.Encode(&mapperOutput{Done: true})
+	}))
+	defer up.Close()
+
+	itr := &remoteIterator{
+		tags:   "x",
+		urls:   []*url.URL{mustParseURL(down.URL), mustParseURL(up.URL)},
+		mapper: RemoteMapper{ShardID: 10},
+	}
+
+	e := influxql.NewEmitter(2)
+	if err := itr.MapRemote(&influxql.Call{Name: "count"}, 5, false, e); err != nil {
+		t.Fatal(err)
+	}
+	e.Close()
+
+	var a []map[influxql.Key]interface{}
+	for m := range e.C() {
+		a = append(a, m)
+	}
+	if !reflect.DeepEqual(a, []map[influxql.Key]interface{}{{influxql.Key{Timestamp: 100, Values: "x"}: float64(2)}}) {
+		t.Fatalf("unexpected output: %#v", a)
+	}
+}
+
+// Ensure a remote iterator returns an error if the stream ends unexpectedly.
+func TestRemoteIterator_MapRemote_ErrUnexpectedEOF(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gob.NewEncoder(w).Encode(&mapperOutput{Key: influxql.Key{Timestamp: 100}, Value: float64(2)})
+	}))
+	defer s.Close()
+
+	itr := &remoteIterator{urls: []*url.URL{mustParseURL(s.URL)}}
+	e := influxql.NewEmitter(2)
+	if err := itr.MapRemote(nil, 0, false, e); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// Ensure a shard can merge the data of another shard store without
+// overwriting its existing points.
+func TestShard_merge(t *testing.T) {
+	src, dst := mustOpenShard(), mustOpenShard()
+	defer src.Close()
+	defer dst.Close()
+
+	if err := src.writeSeriesBatch(map[uint32][]blockPoint{
+		1: {{timestamp: 10, values: map[uint8]interface{}{1: float64(100)}}, {timestamp: 20, values: map[uint8]interface{}{1: float64(200)}}},
+		2: {{timestamp: 10, values: map[uint8]interface{}{1: float64(300)}}},
+	}, true); err != nil {
+		t.Fatal(err)
+	} else if err := dst.writeSeries(1, 20, map[uint8]interface{}{1: float64(-1)}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Merge from a snapshot of the source shard.
+	f, _ := ioutil.TempFile("", "influxdb-")
+	defer os.Remove(f.Name())
+	if err := src.store.View(func(tx *bolt.Tx) error { return tx.Copy(f) }); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if ids, err := dst.merge(f.Name()); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(ids, []uint32{1, 2}) {
+		t.Fatalf("unexpected series ids: %v", ids)
+	}
+
+	for i, tt := range []struct {
+		seriesID  uint32
+		timestamp int64
+		value     float64
+	}{
+		{seriesID: 1, timestamp: 10, value: 100},
+		{seriesID: 1, timestamp: 20, value: -1},
+		{seriesID: 2, timestamp: 10, value: 300},
+	} {
+		if b, err := dst.readSeries(tt.seriesID, tt.timestamp); err != nil {
+			t.Fatalf("%d. unexpected error: %s", i, err)
+		} else if !reflect.DeepEqual(b, marshalFieldValues(map[uint8]interface{}{1: tt.value})) {
+			t.Fatalf("%d. unexpected values: %x", i, b)
+		}
+	}
+}
+
+// Ensure a shard without a format is migrated from a key per point to blocks.
+func TestShard_migrate(t *testing.T) {
+	// Write points in the old format.
+	db := mustOpenBolt()
+	path := db.Path()
+	codec := NewFieldCodec(&Measurement{Fields: []*Field{{ID: 1, Name: "value", Type: influxql.Number}}})
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, seriesID := range []uint32{1, 2} {
+			b, _ := tx.CreateBucket(u32tob(seriesID))
+			for _, timestamp := range []int64{10, 20} {
+				v, _ := codec.EncodeFields(map[string]interface{}{"value": float64(timestamp)})
+				if err := b.Put(u64tob(uint64(timestamp)), v); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db.DB.Close()
+
+	sh := newShard()
+	if err := sh.open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer (&testShard{sh}).Close()
+
+	// The store should be detected as the old format.
+	if err := sh.store.View(func(tx *bolt.Tx) error {
+		if v := formatVersion(tx); v != shardFormatPoints {
+			t.Fatalf("unexpected format: %d", v)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Migrate with the second series removed.
+	if err := sh.migrate(func(seriesID uint32) *FieldCodec {
+		if seriesID == 2 {
+			return nil
+		}
+		return codec
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tt := range []struct {
+		seriesID  uint32
+		timestamp int64
+		values    []byte
+	}{
+		{seriesID: 1, timestamp: 10, values: marshalFieldValues(map[uint8]interface{}{1: float64(10)})},
+		{seriesID: 1, timestamp: 20, values: marshalFieldValues(map[uint8]interface{}{1: float64(20)})},
+		{seriesID: 2, timestamp: 10, values: nil},
+	} {
+		if b, err := sh.readSeries(tt.seriesID, tt.timestamp); err != nil {
+			t.Fatalf("%d. unexpected error: %s", i, err)
+		} else if !reflect.DeepEqual(b, tt.values) {
+			t.Fatalf("%d. unexpected values: %x", i, b)
+		}
+	}
+	if err := sh.store.View(func(tx *bolt.Tx) error {
+		if v := formatVersion(tx); v != shardFormatBlocks {
+			t.Fatalf("unexpected format: %d", v)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure a cursor stops and saves the error when a block cannot be decoded.
+func TestSeriesCursor_ErrInvalidBlock(t *testing.T) {
+	sh := mustOpenShard()
+	defer sh.Close()
+	if err := sh.store.Update(func(tx *bolt.Tx) error {
+		b, _ := tx.CreateBucket(u32tob(1))
+		return b.Put(u64tob(10), []byte{0xFF})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := sh.beginRead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sh.endRead(tx)
+
+	c := &seriesCursor{id: 1, cur: tx.Bucket(u32tob(1)).Cursor()}
+	itr := &shardIterator{cursors: []*seriesCursor{c}}
+	if key, _, _ := c.Next(1, 0, math.MaxInt64); key != 0 {
+		t.Fatalf("unexpected key: %d", key)
+	} else if err := itr.Err(); err == nil || err.Error() != "series 1: invalid block" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a detached shard is not deleted until its open reads have ended.
+func TestShard_drop(t *testing.T) {
+	sh := mustOpenShard()
+	path := sh.store.Path()
+	defer os.Remove(path)
+
+	tx, err := sh.beginRead()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// New reads should fail once the shard is detached.
+	store := sh.detach()
+	if _, err := sh.beginRead(); err != ErrShardNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- sh.drop(store) }()
+	select {
+	case <-done:
+		t.Fatal("shard dropped during read")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// Ending the read should let the drop finish.
+	if err := sh.endRead(tx); err != nil {
+		t.Fatal(err)
+	} else if err := <-done; err != nil {
+		t.Fatal(err)
+	} else if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected shard to be removed: %v", err)
+	}
+}
+
+// mustParseURL parses a URL. Panic on error.
+func mustParseURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err.Error())
+	}
+	return u
+}
+
+// mustOpenBolt opens a bolt database in a temporary location.
+func mustOpenBolt() *boltDB {
+	f, _ := ioutil.TempFile("", "influxdb-")
+	f.Close()
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	if err != nil {
+		panic(err.Error())
+	}
+	return &boltDB{db}
+}
+
+// testShard is a test wrapper for Shard which removes its store on close.
+type testShard struct {
+	*Shard
+}
+
+func mustOpenShard() *testShard {
+	f, _ := ioutil.TempFile("", "influxdb-")
+	f.Close()
+	sh := newShard()
+	if err := sh.open(f.Name()); err != nil {
+		panic(err.Error())
+	}
+	return &testShard{sh}
+}
+
+// Close closes and removes the shard store.
+func (sh *testShard) Close() error {
+	defer os.Remove(sh.store.Path())
+	return sh.close()
+}
+
+// boltDB is a test wrapper for bolt.DB which removes its file on close.
+type boltDB struct {
+	*bolt.DB
+}
+
+// Close closes and removes the database.
+func (db *boltDB) Close() error {
+	defer os.Remove(db.Path())
+	return db.DB.Close()
+}
+
 // MustParseExpr parses an expression string and returns its AST representation.
 func MustParseExpr(s string) influxql.Expr {
 	expr, err := influxql.ParseExpr(s)
@@ -0,0 +1,204 @@
+package influxdb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// DefaultLinePrecision is the precision of line protocol timestamps when
+// no precision is specified.
+const DefaultLinePrecision = "n"
+
+// LineError represents an error parsing a line of the line protocol.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+// Error returns the error message prefixed with the line number.
+func (e *LineError) Error() string { return fmt.Sprintf("line %d: %s", e.Line, e.Err) }
+
+// ParseLineProtocol parses newline-delimited points in the line protocol format:
+//
+//	measurement[,tag=value...] field=value[,field=value...] [timestamp]
+//
+// Commas, spaces and equal signs in measurements, tag keys, tag values and
+// field keys are escaped with a backslash. Field values are floats, integers
+// suffixed with "i", booleans or double quoted strings. The timestamp is an
+// integer epoch in the given precision (h, m, s, ms, u or n). Points without a
+// timestamp use the current time. Blank lines and lines starting with "#" are
+// ignored.
+func ParseLineProtocol(r io.Reader, precision string) ([]Point, error) {
+	if precision == "" {
+		precision = DefaultLinePrecision
+	}
+	if _, err := client.EpochToTime(0, precision); err != nil {
+		return nil, err
+	}
+
+	var points []Point
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if s := strings.TrimSpace(line); s != "" && s[0] != '#' {
+			p, perr := parseLine(s, precision)
+			if perr != nil {
+				return nil, &LineError{Line: n, Err: perr}
+			}
+			points = append(points, p)
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	return points, nil
+}
+
+// parseLine parses a single line into a point.
+func parseLine(s, precision string) (Point, error) {
+	var p Point
+
+	// Split the line into key, fields and timestamp sections.
+	sections := splitLine(s, ' ', true)
+	if len(sections) == 1 {
+		return p, ErrValuesRequired
+	} else if len(sections) > 3 {
+		return p, fmt.Errorf("unexpected text after timestamp: %q", strings.Join(sections[3:], " "))
+	}
+
+	// Parse the measurement name and tags.
+	keys := splitLine(sections[0], ',', false)
+	p.Name = unescapeLine(keys[0])
+	if p.Name == "" {
+		return p, ErrMeasurementNameRequired
+	}
+	for _, kv := range keys[1:] {
+		a := splitLine(kv, '=', false)
+		if len(a) != 2 || a[0] == "" || a[1] == "" {
+			return p, fmt.Errorf("invalid tag: %q", kv)
+		}
+		if p.Tags == nil {
+			p.Tags = make(map[string]string)
+		}
+		p.Tags[unescapeLine(a[0])] = unescapeLine(a[1])
+	}
+
+	// Parse the field values.
+	p.Values = make(map[string]interface{})
+	for _, kv := range splitLine(sections[1], ',', true) {
+		i := indexUnescaped(kv, '=')
+		if i <= 0 {
+			return p, fmt.Errorf("invalid field: %q", kv)
+		}
+		v, err := parseLineValue(kv[i+1:])
+		if err != nil {
+			return p, err
+		}
+		p.Values[unescapeLine(kv[:i])] = v
+	}
+
+	// Parse the timestamp or use the current time.
+	if len(sections) == 3 {
+		epoch, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid timestamp: %q", sections[2])
+		}
+		if p.Timestamp, err = client.EpochToTime(epoch, precision); err != nil {
+			return p, err
+		}
+	} else {
+		p.Timestamp = client.SetPrecision(time.Now(), precision)
+	}
+
+	return p, nil
+}
+
+// parseLineValue parses a field value as a string, boolean, integer or float.
+func parseLineValue(s string) (interface{}, error) {
+	switch s {
+	case "t", "T", "true", "True", "TRUE":
+		return true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return false, nil
+	}
+
+	// Strings are enclosed in double quotes with embedded quotes escaped.
+	if strings.HasPrefix(s, `"`) {
+		if len(s) < 2 || indexUnescaped(s[1:], '"') != len(s)-2 {
+			return nil, fmt.Errorf("unterminated string: %s", s)
+		}
+		return stringUnescaper.Replace(s[1 : len(s)-1]), nil
+	}
+
+	// Integers are suffixed with an "i".
+	if strings.HasSuffix(s, "i") {
+		i, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field value: %q", s)
+		}
+		return i, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("invalid field value: %q", s)
+	}
+	return f, nil
+}
+
+// splitLine splits s on every sep that is not escaped by a backslash. If
+// quoted is true then separators within double quotes are also ignored.
+// Empty sections between repeated spaces are dropped.
+func splitLine(s string, sep byte, quoted bool) []string {
+	var a []string
+	var inQuote bool
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case quoted && s[i] == '"':
+			inQuote = !inQuote
+		case !inQuote && s[i] == sep:
+			if sep != ' ' || i > start {
+				a = append(a, s[start:i])
+			}
+			start = i + 1
+		}
+	}
+	return append(a, s[start:])
+}
+
+// indexUnescaped returns the index of the first c in s that is not escaped by
+// a backslash. Returns -1 if c is not found.
+func indexUnescaped(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// lineUnescaper removes backslashes from escaped commas, spaces and equal signs.
+var lineUnescaper = strings.NewReplacer(`\,`, `,`, `\ `, ` `, `\=`, `=`)
+
+// stringUnescaper removes backslashes from escaped quotes and backslashes.
+var stringUnescaper = strings.NewReplacer(`\"`, `"`, `\\`, `\`)
+
+// unescapeLine removes escape characters from a measurement, tag or field key.
+func unescapeLine(s string) string { return lineUnescaper.Replace(s) }
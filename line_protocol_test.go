@@ -0,0 +1,104 @@
+package influxdb_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb"
+)
+
+// Ensure the line protocol parser can parse points.
+func TestParseLineProtocol(t *testing.T) {
+	var tests = []struct {
+		s         string
+		precision string
+		points    []influxdb.Point
+		err       string
+	}{
+		// Measurement, tags, fields and timestamp.
+		{
+			s: `cpu,host=serverA,region=us-west value=1.5,ok=true,msg="disk \"sda\" full, 90%" 1000000000`,
+			points: []influxdb.Point{
+				{
+					Name:      "cpu",
+					Tags:      map[string]string{"host": "serverA", "region": "us-west"},
+					Timestamp: time.Unix(1, 0),
+					Values:    map[string]interface{}{"value": float64(1.5), "ok": true, "msg": `disk "sda" full, 90%`},
+				},
+			},
+		},
+
+		// Escaped measurement, tags and field keys across multiple lines.
+		{
+			s:         "# comment\n\nfree\\ mem,mount\\=point=/mnt\\,a used\\ pct=-2e3,f=F 10\r\nload x=1 20\n",
+			precision: "s",
+			points: []influxdb.Point{
+				{
+					Name:      "free mem",
+					Tags:      map[string]string{"mount=point": "/mnt,a"},
+					Timestamp: time.Unix(10, 0),
+					Values:    map[string]interface{}{"used pct": float64(-2000), "f": false},
+				},
+				{
+					Name:      "load",
+					Timestamp: time.Unix(20, 0),
+					Values:    map[string]interface{}{"x": float64(1)},
+				},
+			},
+		},
+
+		// Integers are suffixed with an "i".
+		{
+			s: `disk used=9007199254740993i,free=-5i 1`,
+			points: []influxdb.Point{
+				{
+					Name:      "disk",
+					Timestamp: time.Unix(0, 1),
+					Values:    map[string]interface{}{"used": int64(9007199254740993), "free": int64(-5)},
+				},
+			},
+		},
+
+		// Errors report the line number.
+		{s: "cpu value=1\ncpu", err: `line 2: values required`},
+		{s: "cpu value=1\n\n,host=a value=1", err: `line 3: measurement name required`},
+		{s: `cpu,host value=1`, err: `line 1: invalid tag: "host"`},
+		{s: `cpu,host= value=1`, err: `line 1: invalid tag: "host="`},
+		{s: `cpu value`, err: `line 1: invalid field: "value"`},
+		{s: `cpu =1`, err: `line 1: invalid field: "=1"`},
+		{s: `cpu value=abc`, err: `line 1: invalid field value: "abc"`},
+		{s: `cpu value=NaN`, err: `line 1: invalid field value: "NaN"`},
+		{s: `cpu value=1.5i`, err: `line 1: invalid field value: "1.5i"`},
+		{s: `cpu value=99999999999999999999i`, err: `line 1: invalid field value: "99999999999999999999i"`},
+		{s: `cpu value="abc`, err: `line 1: unterminated string: "abc`},
+		{s: `cpu value="`, err: `line 1: unterminated string: "`},
+		{s: `cpu value="a\"`, err: `line 1: unterminated string: "a\"`},
+		{s: `cpu value=1 10s`, err: `line 1: invalid timestamp: "10s"`},
+		{s: `cpu value=1 10 20`, err: `line 1: unexpected text after timestamp: "20"`},
+		{s: `cpu value=1 10`, precision: "y", err: `Unknowm precision "y"`},
+	}
+
+	for i, tt := range tests {
+		points, err := influxdb.ParseLineProtocol(strings.NewReader(tt.s), tt.precision)
+		if errstr(err) != tt.err {
+			t.Errorf("%d. %q: error mismatch:\n  exp=%s\n  got=%s\n\n", i, tt.s, tt.err, err)
+		} else if tt.err == "" && !reflect.DeepEqual(tt.points, points) {
+			t.Errorf("%d. %q\n\npoints mismatch:\n\nexp=%#v\n\ngot=%#v\n\n", i, tt.s, tt.points, points)
+		}
+	}
+}
+
+// Ensure points without a timestamp use the current time.
+func TestParseLineProtocol_DefaultTimestamp(t *testing.T) {
+	now := time.Now()
+	points, err := influxdb.ParseLineProtocol(strings.NewReader(`cpu value=1`), "")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(points) != 1 {
+		t.Fatalf("unexpected point count: %d", len(points))
+	} else if points[0].Timestamp.Before(now) {
+		t.Fatalf("unexpected timestamp: %s", points[0].Timestamp)
+	}
+}
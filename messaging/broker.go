@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/url"
 	"os"
@@ -13,6 +14,7 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/influxdb/influxdb/raft"
 )
@@ -20,6 +22,10 @@ import (
 // BroadcastTopicID is the topic used to communicate with all replicas.
 const BroadcastTopicID = uint64(0)
 
+// DefaultMaxSegmentSize is the default size, in bytes, at which a topic
+// starts writing to a new segment file.
+const DefaultMaxSegmentSize = 10 * 1024 * 1024 // 10MB
+
 // Broker represents distributed messaging system segmented into topics.
 // Each topic represents a linear series of events.
 type Broker struct {
@@ -31,16 +37,20 @@ type Broker struct {
 	replicas map[uint64]*Replica // replica by id
 	topics   map[uint64]*topic   // topics by id
 
+	// The size, in bytes, at which a topic starts writing to a new segment.
+	MaxSegmentSize int64
+
 	Logger *log.Logger
 }
 
 // NewBroker returns a new instance of a Broker with default values.
 func NewBroker() *Broker {
 	b := &Broker{
-		log:      raft.NewLog(),
-		replicas: make(map[uint64]*Replica),
-		topics:   make(map[uint64]*topic),
-		Logger:   log.New(os.Stderr, "[broker] ", log.LstdFlags),
+		log:            raft.NewLog(),
+		replicas:       make(map[uint64]*Replica),
+		topics:         make(map[uint64]*topic),
+		MaxSegmentSize: DefaultMaxSegmentSize,
+		Logger:         log.New(os.Stderr, "[broker] ", log.LstdFlags),
 	}
 	b.log.FSM = (*brokerFSM)(b)
 	return b
@@ -65,6 +75,18 @@ func (b *Broker) SetLogOutput(w io.Writer) {
 	b.log.SetLogOutput(w)
 }
 
+// SetSnapshotThreshold sets the number of applied log entries kept in memory.
+// Must be called before the broker is opened.
+func (b *Broker) SetSnapshotThreshold(n uint64) {
+	b.log.SnapshotThreshold = n
+}
+
+// SetTransport sets the transport used to communicate with other brokers.
+// Must be called before the broker is opened.
+func (b *Broker) SetTransport(t raft.Transport) {
+	b.log.Transport = t
+}
+
 // Open initializes the log.
 // The broker then must be initialized or join a cluster before it can be used.
 func (b *Broker) Open(path string, u *url.URL) error {
@@ -226,25 +248,13 @@ func (b *Broker) createSnapshotHeader() (*snapshotHeader, error) {
 	// Create parent header.
 	s := &snapshotHeader{}
 
-	// Append topics.
+	// Append topics and the current size of each segment.
 	for _, t := range b.topics {
-		// Retrieve current topic file size.
-		var sz int64
-		if t.file != nil {
-			fi, err := t.file.Stat()
-			if err != nil {
-				return nil, err
-			}
-			sz = fi.Size()
+		st := &snapshotTopic{ID: t.id, Index: t.index}
+		for _, seg := range t.segments {
+			st.Segments = append(st.Segments, &segment{Index: seg.Index, Size: seg.Size, path: seg.path})
 		}
-
-		// Append topic to the snapshot.
-		s.Topics = append(s.Topics, &snapshotTopic{
-			ID:    t.id,
-			Index: t.index,
-			Size:  sz,
-			path:  t.path,
-		})
+		s.Topics = append(s.Topics, st)
 	}
 
 	// Append replicas and the current index for each topic.
@@ -344,9 +354,10 @@ func (b *Broker) Replicas() []*Replica {
 // initializes a new topic object.
 func (b *Broker) createTopic(id uint64) *topic {
 	t := &topic{
-		id:       id,
-		path:     filepath.Join(b.path, strconv.FormatUint(uint64(id), 10)),
-		replicas: make(map[uint64]*Replica),
+		id:             id,
+		path:           filepath.Join(b.path, strconv.FormatUint(uint64(id), 10)),
+		maxSegmentSize: b.MaxSegmentSize,
+		replicas:       make(map[uint64]*Replica),
 	}
 	b.topics[t.id] = t
 	return t
@@ -425,7 +436,8 @@ func (b *Broker) mustApplyDeleteReplica(m *Message) {
 	}
 
 	// Remove replica from all subscribed topics.
-	for topicID := range r.topics {
+	topicIDs := r.Topics()
+	for _, topicID := range topicIDs {
 		if t := b.topics[topicID]; t != nil {
 			delete(t.replicas, r.id)
 		}
@@ -438,6 +450,13 @@ func (b *Broker) mustApplyDeleteReplica(m *Message) {
 	// Remove replica from broker.
 	delete(b.replicas, c.ID)
 
+	// Remove segments that were only retained for the replica.
+	for _, topicID := range topicIDs {
+		if t := b.topics[topicID]; t != nil {
+			b.truncateTopic(t)
+		}
+	}
+
 	b.mustSave()
 }
 
@@ -514,14 +533,139 @@ func (b *Broker) mustApplyUnsubscribe(m *Message) {
 		delete(r.topics, c.TopicID)
 	}
 
-	// Remove replica from topic.
+	// Remove replica from topic and remove segments only retained for it.
 	if t := b.topics[c.TopicID]; t != nil {
 		delete(t.replicas, c.ReplicaID)
+		b.truncateTopic(t)
+	}
+
+	b.mustSave()
+}
+
+// Acknowledge records the highest index of a topic that a replica has
+// durably applied. Segments are removed from the topic once every subscribed
+// replica has acknowledged all of their messages.
+func (b *Broker) Acknowledge(replicaID, topicID, index uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Ensure replica exists and is subscribed to the topic.
+	if r := b.replicas[replicaID]; r == nil {
+		return ErrReplicaNotFound
+	} else if _, ok := r.topics[topicID]; !ok {
+		return ErrSubscriptionNotFound
+	}
+
+	// Issue command to acknowledge the index.
+	return b.PublishSync(&Message{
+		Type: AcknowledgeMessageType,
+		Data: mustMarshalJSON(&AcknowledgeCommand{ReplicaID: replicaID, TopicID: topicID, Index: index}),
+	})
+}
+
+func (b *Broker) mustApplyAcknowledge(m *Message) {
+	var c AcknowledgeCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// Ignore if the replica is not subscribed or the index has not moved forward.
+	r := b.replicas[c.ReplicaID]
+	if r == nil {
+		return
+	} else if index, ok := r.topics[c.TopicID]; !ok || c.Index <= index {
+		return
+	}
+
+	// Move the replica's high water mark forward.
+	r.topics[c.TopicID] = c.Index
+
+	// Remove segments that every replica has acknowledged.
+	if t := b.topics[c.TopicID]; t != nil {
+		b.truncateTopic(t)
 	}
 
 	b.mustSave()
 }
 
+// Heartbeat records contact from a replica and acknowledges the highest index
+// that it has applied for each topic. Topics that the replica is not subscribed
+// to and indices that have already been acknowledged are ignored.
+//
+// Contact times are only tracked by the leader so heartbeats must be sent to it.
+func (b *Broker) Heartbeat(replicaID uint64, indexes map[uint64]uint64) error {
+	if !b.IsLeader() {
+		return raft.ErrNotLeader
+	}
+
+	// Record the contact and determine which topics have moved forward.
+	b.mu.Lock()
+	r := b.replicas[replicaID]
+	if r == nil {
+		b.mu.Unlock()
+		return ErrReplicaNotFound
+	}
+	r.lastContact = time.Now()
+
+	var topicIDs []uint64
+	for topicID, index := range indexes {
+		if i, ok := r.topics[topicID]; ok && index > i {
+			topicIDs = append(topicIDs, topicID)
+		}
+	}
+	b.mu.Unlock()
+	sort.Sort(uint64Slice(topicIDs))
+
+	// Acknowledge the new indices.
+	for _, topicID := range topicIDs {
+		if err := b.Acknowledge(replicaID, topicID, indexes[topicID]); err == ErrReplicaNotFound || err == ErrSubscriptionNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplicaStatus returns the replication status of every replica.
+func (b *Broker) ReplicaStatus() []*ReplicaStatus {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	a := make([]*ReplicaStatus, 0, len(b.replicas))
+	for _, r := range b.replicas {
+		rs := &ReplicaStatus{ID: r.id, URL: r.URL.String(), LastContact: r.lastContact}
+		for _, topicID := range r.Topics() {
+			ts := &TopicStatus{ID: topicID, Index: r.topics[topicID]}
+			if t := b.topics[topicID]; t != nil && t.index > ts.Index {
+				ts.Lag = t.index - ts.Index
+			}
+			if ts.Lag > rs.Lag {
+				rs.Lag = ts.Lag
+			}
+			rs.Topics = append(rs.Topics, ts)
+		}
+		a = append(a, rs)
+	}
+	sort.Sort(replicaStatuses(a))
+	return a
+}
+
+// truncateTopic removes the segments of a topic that have been acknowledged by
+// every subscribed replica. Errors are logged since the segments can be
+// removed by a later truncation.
+func (b *Broker) truncateTopic(t *topic) {
+	// Find the lowest index acknowledged by the subscribed replicas.
+	index := t.index
+	for _, r := range b.replicas {
+		if i, ok := r.topics[t.id]; ok && i < index {
+			index = i
+		}
+	}
+
+	if err := t.truncate(index); err != nil {
+		b.Logger.Printf("truncate topic: id=%d, err=%s", t.id, err)
+	}
+}
+
 // brokerFSM implements the raft.FSM interface for the broker.
 // This is implemented as a separate type because it is not meant to be exported.
 type brokerFSM Broker
@@ -551,6 +695,8 @@ func (fsm *brokerFSM) MustApply(e *raft.LogEntry) {
 			b.mustApplySubscribe(m)
 		case UnsubscribeMessageType:
 			b.mustApplyUnsubscribe(m)
+		case AcknowledgeMessageType:
+			b.mustApplyAcknowledge(m)
 		}
 	} else {
 		// Internal raft commands should be broadcast out as no-ops.
@@ -561,10 +707,13 @@ func (fsm *brokerFSM) MustApply(e *raft.LogEntry) {
 	// Set the raft index.
 	m.Index = e.Index
 
-	// Write to the topic.
-	t := b.createTopicIfNotExists(m.TopicID)
-	if err := t.encode(m); err != nil {
-		panic("encode: " + err.Error())
+	// Write to the topic. Acknowledgements only change broker state and are
+	// not broadcast since replicas would acknowledge them in turn.
+	if m.Type != AcknowledgeMessageType {
+		t := b.createTopicIfNotExists(m.TopicID)
+		if err := t.encode(m); err != nil {
+			panic("encode: " + err.Error())
+		}
 	}
 
 	// Save highest applied index.
@@ -610,10 +759,12 @@ func (fsm *brokerFSM) Snapshot(w io.Writer) (uint64, error) {
 		return 0, fmt.Errorf("write header: %s", err)
 	}
 
-	// Stream each topic sequentially.
+	// Stream each topic's segments sequentially.
 	for _, t := range hdr.Topics {
-		if _, err := copyFileN(w, t.path, t.Size); err != nil {
-			return 0, err
+		for _, seg := range t.Segments {
+			if _, err := copyFileN(w, seg.path, seg.Size); err != nil {
+				return 0, err
+			}
 		}
 	}
 
@@ -653,19 +804,23 @@ func (fsm *brokerFSM) Restore(r io.Reader) error {
 		t := b.createTopic(st.ID)
 		t.index = st.Index
 
-		// Remove existing file if it exists.
-		if err := os.Remove(t.path); err != nil && !os.IsNotExist(err) {
+		// Remove existing segments if they exist.
+		if err := os.RemoveAll(t.path); err != nil {
+			return err
+		} else if err := os.MkdirAll(t.path, 0700); err != nil {
 			return err
 		}
 
-		// Open new empty topic file.
-		if err := t.open(); err != nil {
-			return fmt.Errorf("open topic: %s", err)
+		// Copy segments from snapshot into files.
+		for _, seg := range st.Segments {
+			if err := copyToFile(filepath.Join(t.path, strconv.FormatUint(seg.Index, 10)), r, seg.Size); err != nil {
+				return fmt.Errorf("copy topic: %s", err)
+			}
 		}
 
-		// Copy data from snapshot into file.
-		if _, err := io.CopyN(t.file, r, st.Size); err != nil {
-			return fmt.Errorf("copy topic: %s", err)
+		// Open the topic's segments.
+		if err := t.open(); err != nil {
+			return fmt.Errorf("open topic: %s", err)
 		}
 	}
 
@@ -697,6 +852,20 @@ func copyFileN(w io.Writer, path string, n int64) (int64, error) {
 	return io.CopyN(w, f, n)
 }
 
+// copyToFile copies n bytes from a reader to a new file at path.
+func copyToFile(path string, r io.Reader, n int64) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := io.CopyN(f, r, n); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
 // snapshotHeader represents the header of a snapshot.
 type snapshotHeader struct {
 	Replicas []*snapshotReplica `json:"replicas"`
@@ -721,11 +890,9 @@ type snapshotReplica struct {
 }
 
 type snapshotTopic struct {
-	ID    uint64 `json:"id"`
-	Index uint64 `json:"index"`
-	Size  int64  `json:"size"`
-
-	path string
+	ID       uint64     `json:"id"`
+	Index    uint64     `json:"index"`
+	Segments []*segment `json:"segments"`
 }
 
 type snapshotReplicaTopic struct {
@@ -734,13 +901,18 @@ type snapshotReplicaTopic struct {
 }
 
 // topic represents a single named queue of messages.
-// Each topic is identified by a unique path.
+// Each topic is identified by a unique path. Messages are stored in a
+// directory of segment files so that old messages can be removed once
+// every replica has received them.
 type topic struct {
 	id    uint64 // unique identifier
 	index uint64 // highest index written
-	path  string // on-disk path
+	path  string // on-disk directory of segments
 
-	file *os.File // on-disk representation
+	file     *os.File // active segment
+	segments segments // on-disk segments, in index order
+
+	maxSegmentSize int64 // size at which a new segment is started
 
 	replicas map[uint64]*Replica // replicas subscribed to topic
 }
@@ -749,21 +921,56 @@ type topic struct {
 func (t *topic) open() error {
 	assert(t.file == nil, "topic already open: %d", t.id)
 
-	// Ensure the parent directory exists.
-	if err := os.MkdirAll(filepath.Dir(t.path), 0700); err != nil {
+	// Convert a topic stored as a single file into a segment.
+	if err := t.migrate(); err != nil {
+		return fmt.Errorf("migrate: %s", err)
+	}
+
+	// Ensure the topic directory exists.
+	if err := os.MkdirAll(t.path, 0700); err != nil {
 		return err
 	}
 
-	// Open the writer to the on-disk file.
-	f, err := os.OpenFile(t.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	// Read the segments from disk.
+	a, err := readSegments(t.path)
 	if err != nil {
 		return err
 	}
-	t.file = f
+	t.segments = a
+
+	// Reopen the last segment for writing, if one exists.
+	if len(a) > 0 {
+		f, err := os.OpenFile(a[len(a)-1].path, os.O_RDWR|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		t.file = f
+	}
 
 	return nil
 }
 
+// migrate moves a topic written as a single file into the first segment of
+// the topic's directory.
+func (t *topic) migrate() error {
+	fi, err := os.Stat(t.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if fi.IsDir() {
+		return nil
+	}
+
+	tmp := t.path + ".migrate"
+	if err := os.Rename(t.path, tmp); err != nil {
+		return err
+	} else if err := os.MkdirAll(t.path, 0700); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(t.path, "0"))
+}
+
 // close closes the underlying file.
 func (t *topic) Close() error {
 	// Close file.
@@ -771,6 +978,39 @@ func (t *topic) Close() error {
 		_ = t.file.Close()
 		t.file = nil
 	}
+	t.segments = nil
+	return nil
+}
+
+// roll closes the active segment and starts a new segment at a given index.
+func (t *topic) roll(index uint64) error {
+	if t.file != nil {
+		_ = t.file.Close()
+		t.file = nil
+	}
+
+	// Create the new segment file.
+	path := filepath.Join(t.path, strconv.FormatUint(index, 10))
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	t.file = f
+	t.segments = append(t.segments, &segment{Index: index, path: path})
+
+	return nil
+}
+
+// truncate removes segments that only contain messages on or before index.
+// The active segment is never removed.
+func (t *topic) truncate(index uint64) error {
+	for len(t.segments) > 1 && t.segments[1].Index <= index+1 {
+		if err := os.Remove(t.segments[0].path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		t.segments[0] = nil
+		t.segments = t.segments[1:]
+	}
 	return nil
 }
 
@@ -779,39 +1019,25 @@ func (t *topic) Close() error {
 func (t *topic) writeTo(r *Replica, index uint64) (int64, error) {
 	// TODO: If index is too old then return an error.
 
-	// Open topic file for reading.
-	// If it doesn't exist then just exit immediately.
-	f, err := os.Open(t.path)
-	if os.IsNotExist(err) {
-		return 0, nil
-	} else if err != nil {
+	// Read segments from disk since the topic can be written to concurrently.
+	a, err := readSegments(t.path)
+	if err != nil {
 		return 0, err
 	}
-	defer func() { _ = f.Close() }()
-
-	// Stream out all messages until EOF.
-	var total int64
-	dec := NewMessageDecoder(bufio.NewReader(f))
-	for {
-		// Decode message.
-		var m Message
-		if err := dec.Decode(&m); err == io.EOF {
-			break
-		} else if err != nil {
-			return total, fmt.Errorf("decode: %s", err)
-		}
 
-		// Ignore message if it's on or before high water mark.
-		if m.Index <= index {
-			continue
-		}
+	// Skip segments that only contain messages on or before the index.
+	for len(a) > 1 && a[1].Index <= index+1 {
+		a = a[1:]
+	}
 
-		// Write message out to stream.
-		n, err := m.WriteTo(r)
+	// Stream out each remaining segment.
+	var total int64
+	for _, seg := range a {
+		n, err := seg.writeTo(r, index)
+		total += n
 		if err != nil {
-			return total, fmt.Errorf("write to: %s", err)
+			return total, err
 		}
-		total += n
 	}
 
 	return total, nil
@@ -829,6 +1055,13 @@ func (t *topic) encode(m *Message) error {
 	// Ensure message is in-order.
 	assert(m.Index > t.index, "topic message out of order: %d -> %d", t.index, m.Index)
 
+	// Start a new segment if there are no segments or the last one is full.
+	if len(t.segments) == 0 || t.segments[len(t.segments)-1].Size >= t.maxSegmentSize {
+		if err := t.roll(m.Index); err != nil {
+			return fmt.Errorf("roll: %s", err)
+		}
+	}
+
 	// Encode message.
 	b := make([]byte, messageHeaderSize+len(m.Data))
 	copy(b, m.marshalHeader())
@@ -838,6 +1071,7 @@ func (t *topic) encode(m *Message) error {
 	if _, err := t.file.Write(b); err != nil {
 		return fmt.Errorf("encode header: %s", err)
 	}
+	t.segments[len(t.segments)-1].Size += int64(len(b))
 
 	// Move up high water mark on the topic.
 	t.index = m.Index
@@ -850,6 +1084,86 @@ func (t *topic) encode(m *Message) error {
 	return nil
 }
 
+// segment represents a file containing a range of a topic's messages.
+// A segment is named by the lowest index that it can contain and holds
+// every message before the next segment's index.
+type segment struct {
+	Index uint64 `json:"index"`
+	Size  int64  `json:"size"`
+
+	path string
+}
+
+// writeTo writes the messages in the segment after a given index to a replica.
+func (s *segment) writeTo(r *Replica, index uint64) (int64, error) {
+	// Open segment file for reading.
+	// If it has been truncated then just exit immediately.
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	// Stream out all messages until EOF.
+	var total int64
+	dec := NewMessageDecoder(bufio.NewReader(f))
+	for {
+		// Decode message.
+		var m Message
+		if err := dec.Decode(&m); err == io.EOF {
+			break
+		} else if err != nil {
+			return total, fmt.Errorf("decode: %s", err)
+		}
+
+		// Ignore message if it's on or before high water mark.
+		if m.Index <= index {
+			continue
+		}
+
+		// Write message out to stream.
+		n, err := m.WriteTo(r)
+		if err != nil {
+			return total, fmt.Errorf("write to: %s", err)
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+// segments represents a list of segments sortable by index.
+type segments []*segment
+
+func (a segments) Len() int           { return len(a) }
+func (a segments) Less(i, j int) bool { return a[i].Index < a[j].Index }
+func (a segments) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// readSegments returns the segments in a topic directory, sorted by index.
+// Files that are not named by an index are ignored.
+func readSegments(path string) (segments, error) {
+	fis, err := ioutil.ReadDir(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var a segments
+	for _, fi := range fis {
+		index, err := strconv.ParseUint(fi.Name(), 10, 64)
+		if err != nil || fi.IsDir() {
+			continue
+		}
+		a = append(a, &segment{Index: index, Size: fi.Size(), path: filepath.Join(path, fi.Name())})
+	}
+	sort.Sort(a)
+
+	return a, nil
+}
+
 type replicas []*Replica
 
 func (a replicas) Len() int           { return len(a) }
@@ -869,6 +1183,8 @@ type Replica struct {
 	done   chan struct{} // notify when current writer is removed
 
 	topics map[uint64]uint64 // current index for each subscribed topic
+
+	lastContact time.Time // time of the last heartbeat, not replicated
 }
 
 // newReplica returns a new Replica instance associated with a broker.
@@ -971,6 +1287,35 @@ func (r *Replica) WriteTo(w io.Writer) (int64, error) {
 	return 0, nil
 }
 
+// ReplicaStatus represents how far a replica has applied its subscribed topics.
+type ReplicaStatus struct {
+	ID          uint64         `json:"id"`
+	URL         string         `json:"url"`
+	LastContact time.Time      `json:"lastContact"` // zero if no heartbeat has been received
+	Lag         uint64         `json:"lag"`         // highest lag across all topics
+	Topics      []*TopicStatus `json:"topics"`
+}
+
+// TopicStatus represents how far a replica has applied a single topic.
+type TopicStatus struct {
+	ID    uint64 `json:"id"`
+	Index uint64 `json:"index"` // highest index acknowledged by the replica
+	Lag   uint64 `json:"lag"`   // number of indices behind the topic's index
+}
+
+type replicaStatuses []*ReplicaStatus
+
+func (a replicaStatuses) Len() int           { return len(a) }
+func (a replicaStatuses) Less(i, j int) bool { return a[i].ID < a[j].ID }
+func (a replicaStatuses) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// topicIndex represents the highest index applied by a replica for a topic.
+// It is used to encode heartbeats.
+type topicIndex struct {
+	TopicID uint64 `json:"topicID"`
+	Index   uint64 `json:"index"`
+}
+
 // CreateReplica creates a new replica.
 type CreateReplicaCommand struct {
 	ID  uint64 `json:"id"`
@@ -994,6 +1339,13 @@ type UnsubscribeCommand struct {
 	TopicID   uint64 `json:"topicID"`   // topic id
 }
 
+// AcknowledgeCommand moves a replica's high water mark for a topic forward.
+type AcknowledgeCommand struct {
+	ReplicaID uint64 `json:"replicaID"` // replica id
+	TopicID   uint64 `json:"topicID"`   // topic id
+	Index     uint64 `json:"index"`     // highest index applied by the replica
+}
+
 // MessageType represents the type of message.
 type MessageType uint16
 
@@ -1009,6 +1361,7 @@ const (
 
 	SubscribeMessageType   = BrokerMessageType | MessageType(0x20)
 	UnsubscribeMessageType = BrokerMessageType | MessageType(0x21)
+	AcknowledgeMessageType = BrokerMessageType | MessageType(0x22)
 )
 
 // The size of the encoded message header, in bytes.
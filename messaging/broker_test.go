@@ -7,7 +7,9 @@ import (
 	"io/ioutil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
@@ -194,6 +196,132 @@ func TestBroker_Unsubscribe_ErrReplicaNotFound(t *testing.T) {
 	}
 }
 
+// Ensure the broker removes topic segments once every replica has acknowledged them.
+func TestBroker_Acknowledge(t *testing.T) {
+	b := NewBroker(nil)
+	defer b.Close()
+	b.MaxSegmentSize = 1
+
+	// Subscribe two replicas to a topic and write a segment per message.
+	b.CreateReplica(2000, &url.URL{Host: "localhost"})
+	b.CreateReplica(3000, &url.URL{Host: "localhost"})
+	b.Subscribe(2000, 20)
+	b.Subscribe(3000, 20)
+	var indices []uint64
+	for _, data := range []string{"0000", "1111", "2222"} {
+		index, err := b.Publish(&messaging.Message{Type: 100, TopicID: 20, Data: []byte(data)})
+		if err != nil {
+			t.Fatalf("publish: %s", err)
+		} else if err := b.Sync(index); err != nil {
+			t.Fatalf("sync: %s", err)
+		}
+		indices = append(indices, index)
+	}
+	if n := b.MustSegmentN(20); n != 3 {
+		t.Fatalf("unexpected segment count: %d", n)
+	}
+
+	// Segments are retained until every subscribed replica acknowledges them.
+	if err := b.Acknowledge(2000, 20, indices[1]); err != nil {
+		t.Fatalf("acknowledge: %s", err)
+	} else if n := b.MustSegmentN(20); n != 3 {
+		t.Fatalf("unexpected segment count after first ack: %d", n)
+	}
+	if err := b.Acknowledge(3000, 20, indices[1]); err != nil {
+		t.Fatalf("acknowledge: %s", err)
+	} else if n := b.MustSegmentN(20); n != 1 {
+		t.Fatalf("unexpected segment count after second ack: %d", n)
+	}
+
+	// Ensure the replica resumes streaming after its acknowledged index.
+	if a := Messages(b.MustReadAll(2000)).Unicasted(); len(a) != 1 {
+		t.Fatalf("message count mismatch: %d", len(a))
+	} else if m := a[0]; string(m.Data) != "2222" {
+		t.Fatalf("unexpected message: %s", m.Data)
+	}
+}
+
+// Ensure that acknowledging a topic without a subscription returns an error.
+func TestBroker_Acknowledge_ErrSubscriptionNotFound(t *testing.T) {
+	b := NewBroker(nil)
+	defer b.Close()
+	b.CreateReplica(2000, &url.URL{Host: "localhost"})
+	if err := b.Acknowledge(2000, 20, 1); err != messaging.ErrSubscriptionNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure that a heartbeat acknowledges topics and is reported in the replica status.
+func TestBroker_Heartbeat(t *testing.T) {
+	b := NewBroker(nil)
+	defer b.Close()
+	b.CreateReplica(2000, &url.URL{Host: "localhost"})
+	b.CreateReplica(3000, &url.URL{Host: "localhost"})
+	b.Subscribe(2000, 20)
+	b.Subscribe(3000, 20)
+	var index uint64
+	for _, data := range []string{"0000", "1111", "2222"} {
+		index, _ = b.Publish(&messaging.Message{Type: 100, TopicID: 20, Data: []byte(data)})
+	}
+	if err := b.Sync(index); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+
+	// Report the last index for replica 2000. Unsubscribed topics are ignored.
+	if err := b.Heartbeat(2000, map[uint64]uint64{20: index, 30: index}); err != nil {
+		t.Fatalf("heartbeat: %s", err)
+	}
+
+	a := b.ReplicaStatus()
+	if len(a) != 2 {
+		t.Fatalf("unexpected replica count: %d", len(a))
+	} else if a[0].ID != 2000 || a[0].LastContact.IsZero() || len(a[0].Topics) != 2 {
+		t.Fatalf("unexpected status(0): %#v", a[0])
+	} else if ts := a[0].Topics[1]; ts.ID != 20 || ts.Index != index || ts.Lag != 0 {
+		t.Fatalf("unexpected topic status(0): %#v", ts)
+	} else if a[1].ID != 3000 || !a[1].LastContact.IsZero() || len(a[1].Topics) != 2 || a[1].Lag < index {
+		t.Fatalf("unexpected status(1): %#v", a[1])
+	} else if ts := a[1].Topics[1]; ts.ID != 20 || ts.Lag != index {
+		t.Fatalf("unexpected topic status(1): %#v", ts)
+	}
+}
+
+// Ensure that acknowledgements from a heartbeat are not broadcast to replicas,
+// which would cause every heartbeat to acknowledge the previous one.
+func TestBroker_Heartbeat_NotBroadcast(t *testing.T) {
+	b := NewBroker(nil)
+	defer b.Close()
+	b.CreateReplica(2000, &url.URL{Host: "localhost"})
+	b.Subscribe(2000, 20)
+	index, _ := b.Publish(&messaging.Message{Type: 100, TopicID: 20, Data: []byte("0000")})
+	if err := b.Sync(index); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+
+	// Acknowledge every topic up to its current index.
+	ts := b.ReplicaStatus()[0].Topics[0]
+	if ts.ID != messaging.BroadcastTopicID {
+		t.Fatalf("unexpected topic status: %#v", ts)
+	}
+	if err := b.Heartbeat(2000, map[uint64]uint64{messaging.BroadcastTopicID: ts.Index + ts.Lag, 20: index}); err != nil {
+		t.Fatalf("heartbeat: %s", err)
+	}
+
+	// The replica should not lag behind on any topic.
+	if a := b.ReplicaStatus(); a[0].Lag != 0 {
+		t.Fatalf("unexpected status: %#v", a[0])
+	}
+}
+
+// Ensure that a heartbeat from an unknown replica returns an error.
+func TestBroker_Heartbeat_ErrReplicaNotFound(t *testing.T) {
+	b := NewBroker(nil)
+	defer b.Close()
+	if err := b.Heartbeat(2000, map[uint64]uint64{20: 1}); err != messaging.ErrReplicaNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
 // Benchmarks a single broker without HTTP.
 func BenchmarkBroker_Publish(b *testing.B) {
 	br := NewBroker(nil)
@@ -276,6 +404,15 @@ func (b *Broker) MustReadAll(replicaID uint64) (a []*messaging.Message) {
 	return
 }
 
+// MustSegmentN returns the number of segment files for a topic. Panic on error.
+func (b *Broker) MustSegmentN(topicID uint64) int {
+	fis, err := ioutil.ReadDir(filepath.Join(b.Path(), strconv.FormatUint(topicID, 10)))
+	if err != nil {
+		panic("read dir: " + err.Error())
+	}
+	return len(fis)
+}
+
 // Messages represents a collection of messages.
 // This type provides helper functions.
 type Messages []*messaging.Message
@@ -367,6 +367,106 @@ func (c *Client) Unsubscribe(replicaID, topicID uint64) error {
 	return nil
 }
 
+// Acknowledge notifies the broker of the highest index of a topic that a
+// replica has durably applied so that older messages can be removed.
+func (c *Client) Acknowledge(replicaID, topicID, index uint64) error {
+	var resp *http.Response
+	var err error
+
+	u := *c.LeaderURL()
+	for {
+		u.Path = "/messaging/acknowledge"
+		u.RawQuery = url.Values{
+			"replicaID": {strconv.FormatUint(replicaID, 10)},
+			"topicID":   {strconv.FormatUint(topicID, 10)},
+			"index":     {strconv.FormatUint(index, 10)},
+		}.Encode()
+		resp, err = http.Post(u.String(), "application/octet-stream", nil)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		// If a temporary redirect occurs then update the leader and retry.
+		// If a non-204 status is returned then an error occurred.
+		if resp.StatusCode == http.StatusTemporaryRedirect {
+			redirectURL, err := url.Parse(resp.Header.Get("Location"))
+			if err != nil {
+				return fmt.Errorf("bad redirect: %s", resp.Header.Get("Location"))
+			}
+			u = *redirectURL
+			continue
+		} else if resp.StatusCode != http.StatusNoContent {
+			return errors.New(resp.Header.Get("X-Broker-Error"))
+		}
+		break
+	}
+
+	return nil
+}
+
+// Heartbeat notifies the broker that a replica is alive and sends the highest
+// index that it has durably applied for each topic.
+func (c *Client) Heartbeat(replicaID uint64, indexes map[uint64]uint64) error {
+	var resp *http.Response
+	var err error
+
+	// Encode the topic indices.
+	a := make([]*topicIndex, 0, len(indexes))
+	for topicID, index := range indexes {
+		a = append(a, &topicIndex{TopicID: topicID, Index: index})
+	}
+	body, _ := json.Marshal(a)
+
+	u := *c.LeaderURL()
+	for {
+		u.Path = "/messaging/heartbeat"
+		u.RawQuery = url.Values{"replicaID": {strconv.FormatUint(replicaID, 10)}}.Encode()
+		resp, err = http.Post(u.String(), "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		// If a temporary redirect occurs then update the leader and retry.
+		// If a non-204 status is returned then an error occurred.
+		if resp.StatusCode == http.StatusTemporaryRedirect {
+			redirectURL, err := url.Parse(resp.Header.Get("Location"))
+			if err != nil {
+				return fmt.Errorf("bad redirect: %s", resp.Header.Get("Location"))
+			}
+			u = *redirectURL
+			continue
+		} else if resp.StatusCode != http.StatusNoContent {
+			return errors.New(resp.Header.Get("X-Broker-Error"))
+		}
+		break
+	}
+
+	return nil
+}
+
+// ReplicaStatus returns the replication status of every replica from the broker.
+func (c *Client) ReplicaStatus() ([]*ReplicaStatus, error) {
+	u := *c.LeaderURL()
+	u.Path = "/messaging/replicas"
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("replica status(%d): %s", resp.StatusCode, resp.Header.Get("X-Broker-Error"))
+	}
+
+	var a []*ReplicaStatus
+	if err := json.NewDecoder(resp.Body).Decode(&a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
 // streamer connects to a broker server and streams the replica's messages.
 func (c *Client) streamer(done chan chan struct{}) {
 	for {
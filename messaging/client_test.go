@@ -231,6 +231,56 @@ func TestClient_Unsubscribe_Err(t *testing.T) {
 	}
 }
 
+// Ensure that a client can acknowledge an index for a subscription.
+func TestClient_Acknowledge(t *testing.T) {
+	c := OpenClient(0)
+	defer c.Close()
+	c.Server.Broker().CreateReplica(100, &url.URL{Host: "localhost"})
+	c.Server.Broker().Subscribe(100, 200)
+
+	if err := c.Acknowledge(100, 200, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure that a client can passthrough an error while acknowledging an index.
+func TestClient_Acknowledge_Err(t *testing.T) {
+	c := OpenClient(0)
+	defer c.Close()
+	c.Server.Broker().CreateReplica(100, &url.URL{Host: "localhost"})
+	if err := c.Acknowledge(100, 200, 2); err == nil || err.Error() != `subscription not found` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure that a client can send a heartbeat and read the replica status.
+func TestClient_Heartbeat(t *testing.T) {
+	c := OpenClient(0)
+	defer c.Close()
+	c.Server.Broker().CreateReplica(100, &url.URL{Host: "localhost"})
+	c.Server.Broker().Subscribe(100, 200)
+
+	if err := c.Heartbeat(100, map[uint64]uint64{200: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a, err := c.ReplicaStatus()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(a) != 2 || a[1].ID != 100 || a[1].LastContact.IsZero() {
+		t.Fatalf("unexpected replica status: %#v", a)
+	}
+}
+
+// Ensure that a client can passthrough an error while sending a heartbeat.
+func TestClient_Heartbeat_Err(t *testing.T) {
+	c := OpenClient(0)
+	defer c.Close()
+	if err := c.Heartbeat(100, map[uint64]uint64{200: 2}); err == nil || err.Error() != `replica not found` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 // Client represents a test wrapper for the broker client.
 type Client struct {
 	clientConfig string // Temporary file for client config.
@@ -45,4 +45,11 @@ var (
 
 	// ErrTopicRequired is returned publishing a message without a topic ID.
 	ErrTopicRequired = errors.New("topic required")
+
+	// ErrIndexRequired is returned when acknowledging a topic without an index.
+	ErrIndexRequired = errors.New("index required")
+
+	// ErrSubscriptionNotFound is returned when referencing a topic that the
+	// replica is not subscribed to.
+	ErrSubscriptionNotFound = errors.New("subscription not found")
 )
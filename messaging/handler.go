@@ -1,6 +1,7 @@
 package messaging
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -58,7 +59,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		}
 	case "/messaging/replicas":
-		if r.Method == "POST" {
+		if r.Method == "GET" {
+			h.replicaStatus(w, r)
+		} else if r.Method == "POST" {
 			h.createReplica(w, r)
 		} else if r.Method == "DELETE" {
 			h.deleteReplica(w, r)
@@ -73,6 +76,18 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		} else {
 			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		}
+	case "/messaging/heartbeat":
+		if r.Method == "POST" {
+			h.heartbeat(w, r)
+		} else {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	case "/messaging/acknowledge":
+		if r.Method == "POST" {
+			h.acknowledge(w, r)
+		} else {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
 	default:
 		http.NotFound(w, r)
 	}
@@ -143,6 +158,12 @@ func (h *Handler) publish(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Broker-Index", strconv.FormatUint(index, 10))
 }
 
+// replicaStatus writes the replication status of every replica as JSON.
+func (h *Handler) replicaStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.broker.ReplicaStatus())
+}
+
 // createReplica creates a new replica with a given ID.
 func (h *Handler) createReplica(w http.ResponseWriter, r *http.Request) {
 	// Read the replica ID.
@@ -263,6 +284,86 @@ func (h *Handler) unsubscribe(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// heartbeat records contact from a replica and the highest index it has
+// applied for each topic.
+func (h *Handler) heartbeat(w http.ResponseWriter, r *http.Request) {
+	// Read the replica ID.
+	var replicaID uint64
+	if n, err := strconv.ParseUint(r.URL.Query().Get("replicaID"), 10, 64); err != nil {
+		h.error(w, ErrReplicaIDRequired, http.StatusBadRequest)
+		return
+	} else {
+		replicaID = uint64(n)
+	}
+
+	// Read the topic indices from the body.
+	var a []*topicIndex
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		h.error(w, err, http.StatusBadRequest)
+		return
+	}
+	indexes := make(map[uint64]uint64, len(a))
+	for _, ti := range a {
+		indexes[ti.TopicID] = ti.Index
+	}
+
+	// Record the heartbeat on the broker.
+	if err := h.broker.Heartbeat(replicaID, indexes); err == raft.ErrNotLeader {
+		h.redirectToLeader(w, r)
+		return
+	} else if err == ErrReplicaNotFound {
+		h.error(w, err, http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.error(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// acknowledge records the highest index of a topic applied by a replica.
+func (h *Handler) acknowledge(w http.ResponseWriter, r *http.Request) {
+	// Read the replica ID.
+	var replicaID uint64
+	if n, err := strconv.ParseUint(r.URL.Query().Get("replicaID"), 10, 64); err != nil {
+		h.error(w, ErrReplicaIDRequired, http.StatusBadRequest)
+		return
+	} else {
+		replicaID = uint64(n)
+	}
+
+	// Read the topic ID.
+	var topicID uint64
+	if n, err := strconv.ParseUint(r.URL.Query().Get("topicID"), 10, 64); err != nil {
+		h.error(w, ErrTopicRequired, http.StatusBadRequest)
+		return
+	} else {
+		topicID = uint64(n)
+	}
+
+	// Read the index.
+	var index uint64
+	if n, err := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); err != nil {
+		h.error(w, ErrIndexRequired, http.StatusBadRequest)
+		return
+	} else {
+		index = uint64(n)
+	}
+
+	// Acknowledge the index for the replica's subscription.
+	if err := h.broker.Acknowledge(replicaID, topicID, index); err == raft.ErrNotLeader {
+		h.redirectToLeader(w, r)
+		return
+	} else if err == ErrReplicaNotFound || err == ErrSubscriptionNotFound {
+		h.error(w, err, http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.error(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // error writes an error to the client and sets the status code.
 func (h *Handler) error(w http.ResponseWriter, err error, code int) {
 	s := err.Error()
@@ -1,6 +1,7 @@
 package messaging_test
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -347,6 +348,79 @@ func TestHandler_unsubscribe(t *testing.T) {
 	}
 }
 
+// Ensure a handler can acknowledge a replica's index for a topic.
+func TestHandler_acknowledge(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Handler.Broker().CreateReplica(200, &url.URL{Host: "localhost"})
+	s.Handler.Broker().Subscribe(200, 100)
+
+	// Send request to the broker.
+	resp, _ := http.Post(s.URL+`/messaging/acknowledge?replicaID=200&topicID=100&index=2`, "application/octet-stream", nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d (%s)", resp.StatusCode, resp.Header.Get("X-Broker-Error"))
+	}
+}
+
+// Ensure a handler returns an error when acknowledging without an index.
+func TestHandler_acknowledge_ErrIndexRequired(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	resp, _ := http.Post(s.URL+`/messaging/acknowledge?replicaID=200&topicID=100`, "application/octet-stream", nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	} else if resp.Header.Get("X-Broker-Error") != "index required" {
+		t.Fatalf("unexpected error: %s", resp.Header.Get("X-Broker-Error"))
+	}
+}
+
+// Ensure a handler can receive a heartbeat from a replica.
+func TestHandler_heartbeat(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Handler.Broker().CreateReplica(200, &url.URL{Host: "localhost"})
+	s.Handler.Broker().Subscribe(200, 100)
+
+	// Send request to the broker.
+	resp, _ := http.Post(s.URL+`/messaging/heartbeat?replicaID=200`, "application/json", strings.NewReader(`[{"topicID":100,"index":2}]`))
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d (%s)", resp.StatusCode, resp.Header.Get("X-Broker-Error"))
+	} else if a := s.Handler.Broker().ReplicaStatus(); len(a) != 1 || a[0].LastContact.IsZero() {
+		t.Fatalf("unexpected replica status: %#v", a)
+	}
+}
+
+// Ensure a handler returns an error when a heartbeat is from an unknown replica.
+func TestHandler_heartbeat_ErrReplicaNotFound(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	resp, _ := http.Post(s.URL+`/messaging/heartbeat?replicaID=200`, "application/json", strings.NewReader(`[]`))
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	} else if resp.Header.Get("X-Broker-Error") != "replica not found" {
+		t.Fatalf("unexpected error: %s", resp.Header.Get("X-Broker-Error"))
+	}
+}
+
+// Ensure a handler can return the replication status of replicas.
+func TestHandler_replicaStatus(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Handler.Broker().CreateReplica(200, &url.URL{Scheme: "http", Host: "localhost"})
+
+	resp, _ := http.Get(s.URL + `/messaging/replicas`)
+	defer resp.Body.Close()
+	if b, _ := ioutil.ReadAll(resp.Body); resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	} else if string(b) != `[{"id":200,"url":"http://localhost","lastContact":"0001-01-01T00:00:00Z","lag":1,"topics":[{"id":0,"index":1,"lag":1}]}]`+"\n" {
+		t.Fatalf("unexpected body: %s", b)
+	}
+}
+
 // Ensure a handler returns an error when unsubscribing without a replica id.
 func TestHandler_unsubscribe_ErrReplicaIDRequired(t *testing.T) {
 	s := NewServer()
@@ -214,6 +214,30 @@ func (tx *metatx) createSeries(database, name string, tags map[string]string) (*
 	return s, nil
 }
 
+// dropSeries removes a series from the metastore.
+func (tx *metatx) dropSeries(database, name string, id uint32) error {
+	b := tx.Bucket([]byte("Databases")).Bucket([]byte(database)).Bucket([]byte("Series")).Bucket([]byte(name))
+	if b == nil {
+		return nil
+	}
+
+	idBytes := make([]byte, 4)
+	*(*uint32)(unsafe.Pointer(&idBytes[0])) = id
+	return b.Delete(idBytes)
+}
+
+// dropMeasurement removes a measurement and all of its series from the metastore.
+func (tx *metatx) dropMeasurement(database, name string) error {
+	b := tx.Bucket([]byte("Databases")).Bucket([]byte(database))
+	if err := b.Bucket([]byte("Measurements")).Delete([]byte(name)); err != nil {
+		return err
+	}
+	if err := b.Bucket([]byte("Series")).DeleteBucket([]byte(name)); err != nil && err != bolt.ErrBucketNotFound {
+		return err
+	}
+	return nil
+}
+
 // loops through all the measurements and series in a database
 func (tx *metatx) indexDatabase(db *database) {
 	// get the bucket that holds series data for the database
@@ -2,6 +2,7 @@ package raft
 
 import (
 	"encoding/binary"
+	"hash/crc32"
 	"io"
 )
 
@@ -64,15 +65,25 @@ func (dec *LogEntryDecoder) Decode(e *LogEntry) error {
 	} else if err != nil {
 		return err
 	}
+
+	// Verify the header before trusting the data size.
+	if crc32.ChecksumIEEE(b[0:24]) != binary.BigEndian.Uint32(b[24:28]) {
+		return ErrInvalidChecksum
+	}
 	sz := binary.BigEndian.Uint64(b[0:8]) & 0x00FFFFFFFFFFFFFF
 	e.Index = binary.BigEndian.Uint64(b[8:16])
 	e.Term = binary.BigEndian.Uint64(b[16:24])
 
-	// Read data.
+	// Read and verify data.
 	data := make([]byte, sz)
-	if _, err := io.ReadFull(dec.r, data); err != nil && err != io.EOF {
+	if _, err := io.ReadFull(dec.r, data); err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
 		return err
 	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(b[28:32]) {
+		return ErrInvalidChecksum
+	}
 	e.Data = data
 
 	return nil
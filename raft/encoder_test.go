@@ -22,7 +22,7 @@ func TestLogEntryEncoder_Encode(t *testing.T) {
 	}
 
 	// Check that the encoded bytes match what's expected.
-	exp := []byte{0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 4, 5, 6}
+	exp := []byte{0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 0x47, 0xa4, 0xe8, 0x98, 0x6c, 0x5c, 0x20, 0xbe, 4, 5, 6}
 	if v := buf.Bytes(); !bytes.Equal(exp, v) {
 		t.Fatalf("value:\n\nexp: %x\n\ngot: %x\n\n", exp, v)
 	}
@@ -30,7 +30,7 @@ func TestLogEntryEncoder_Encode(t *testing.T) {
 
 // Ensure that the encoder can handle write errors during encoding of header.
 func TestLogEntryEncoder_Encode_ErrShortWrite_Header(t *testing.T) {
-	w := newLimitWriter(23)
+	w := newLimitWriter(31)
 	enc := raft.NewLogEntryEncoder(w)
 	if err := enc.Encode(&raft.LogEntry{Data: []byte{0, 0, 0, 0}}); err != io.ErrShortWrite {
 		t.Fatalf("unexpected error: %s", err)
@@ -39,7 +39,7 @@ func TestLogEntryEncoder_Encode_ErrShortWrite_Header(t *testing.T) {
 
 // Ensure that the encoder can handle write errors during encoding of the data.
 func TestLogEntryEncoder_Encode_ErrShortWrite_Data(t *testing.T) {
-	w := newLimitWriter(25)
+	w := newLimitWriter(33)
 	enc := raft.NewLogEntryEncoder(w)
 	if err := enc.Encode(&raft.LogEntry{Data: []byte{0, 0, 0, 0}}); err != io.ErrShortWrite {
 		t.Fatalf("unexpected error: %s", err)
@@ -48,7 +48,7 @@ func TestLogEntryEncoder_Encode_ErrShortWrite_Data(t *testing.T) {
 
 // Ensure that log entries can be decoded from a reader.
 func TestLogEntryDecoder_Decode(t *testing.T) {
-	buf := bytes.NewBuffer([]byte{0x10, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 4, 5, 6})
+	buf := bytes.NewBuffer([]byte{0x10, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 0x38, 0xcf, 0x97, 0xa5, 0x6c, 0x5c, 0x20, 0xbe, 4, 5, 6})
 
 	// Create a blank entry and an expected result.
 	entry := &raft.LogEntry{}
@@ -84,9 +84,10 @@ func TestLogEntryDecoder_Decode_ErrUnexpectedEOF_Type(t *testing.T) {
 		buf []byte
 	}{
 		{[]byte{0x10}}, // type flag only
-		{[]byte{0x10, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0}},             // partial header
-		{[]byte{0x10, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0}},          // full header, no data
-		{[]byte{0x10, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 4, 5}}, // full header, partial data
+		{[]byte{0x10, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0}},                                                             // partial header
+		{[]byte{0x10, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 0x38, 0xcf, 0x97, 0xa5, 0x6c, 0x5c, 0x20}},             // partial checksum
+		{[]byte{0x10, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 0x38, 0xcf, 0x97, 0xa5, 0x6c, 0x5c, 0x20, 0xbe}},       // full header, no data
+		{[]byte{0x10, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 0x38, 0xcf, 0x97, 0xa5, 0x6c, 0x5c, 0x20, 0xbe, 4, 5}}, // full header, partial data
 	} {
 		var e raft.LogEntry
 		dec := raft.NewLogEntryDecoder(bytes.NewReader(tt.buf))
@@ -96,6 +97,23 @@ func TestLogEntryDecoder_Decode_ErrUnexpectedEOF_Type(t *testing.T) {
 	}
 }
 
+// Ensure the decoder returns a checksum error when reading corrupt entries.
+func TestLogEntryDecoder_Decode_ErrInvalidChecksum(t *testing.T) {
+	for i, tt := range []struct {
+		buf []byte
+	}{
+		{[]byte{0x10, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 4, 0x38, 0xcf, 0x97, 0xa5, 0x6c, 0x5c, 0x20, 0xbe, 4, 5, 6}}, // corrupt term
+		{[]byte{0x10, 0, 0, 0, 0, 0xFF, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 0x38, 0xcf, 0x97, 0xa5, 0x6c, 0x5c, 0x20, 0xbe}},       // corrupt size
+		{[]byte{0x10, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 0x38, 0xcf, 0x97, 0xa5, 0x6c, 0x5c, 0x20, 0xbe, 4, 0, 6}}, // corrupt data
+	} {
+		var e raft.LogEntry
+		dec := raft.NewLogEntryDecoder(bytes.NewReader(tt.buf))
+		if err := dec.Decode(&e); err != raft.ErrInvalidChecksum {
+			t.Errorf("%d. unexpected error: %s", i, err)
+		}
+	}
+}
+
 // Ensure that random entries can be encoded and decoded correctly.
 func TestLogEntryEncodeDecode(t *testing.T) {
 	f := func(entries []raft.LogEntry) bool {
@@ -50,4 +50,26 @@ var (
 
 	// ErrDuplicateNodeURL is returned when adding a node with an existing URL.
 	ErrDuplicateNodeURL = errors.New("duplicate node url")
+
+	// ErrRemoveLeader is returned when removing the leader from the cluster.
+	ErrRemoveLeader = errors.New("cannot remove leader")
+
+	// ErrTransferInProgress is returned when transferring leadership while
+	// another transfer is in progress.
+	ErrTransferInProgress = errors.New("leadership transfer in progress")
+
+	// ErrTransferTimeout is returned when a leadership transfer does not
+	// complete within an election timeout.
+	ErrTransferTimeout = errors.New("leadership transfer timeout")
+
+	// ErrInvalidChecksum is returned when decoding a corrupt log entry.
+	ErrInvalidChecksum = errors.New("invalid checksum")
+
+	// ErrIncompatibleFormat is returned when a follower streams the log using
+	// a different log entry format than the leader.
+	ErrIncompatibleFormat = errors.New("incompatible log entry format")
+
+	// ErrNonContiguousIndex is returned when a log entry read from the leader
+	// does not directly follow the last entry in the log.
+	ErrNonContiguousIndex = errors.New("non-contiguous index")
 )
@@ -15,8 +15,11 @@ type Handler struct {
 		AddPeer(u *url.URL) (uint64, *Config, error)
 		RemovePeer(id uint64) error
 		Heartbeat(term, commitIndex, leaderID uint64) (currentIndex, currentTerm uint64, err error)
-		WriteEntriesTo(w io.Writer, id, term, index uint64) error
+		WriteEntriesTo(w io.Writer, id, term, index, lastLogTerm uint64) error
 		RequestVote(term, candidateID, lastLogIndex, lastLogTerm uint64) (uint64, error)
+		TimeoutNow(term uint64) error
+		TransferLeadership(id uint64) error
+		Leader() (id uint64, u *url.URL)
 	}
 }
 
@@ -33,6 +36,12 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.serveStream(w, r)
 	case "vote":
 		h.serveRequestVote(w, r)
+	case "timeout":
+		h.serveTimeoutNow(w, r)
+	case "transfer":
+		h.serveTransfer(w, r)
+	case "tcp":
+		h.serveTCP(w, r)
 	case "ping":
 		w.WriteHeader(http.StatusOK)
 	default:
@@ -77,8 +86,6 @@ func (h *Handler) serveJoin(w http.ResponseWriter, r *http.Request) {
 
 // serveLeave removes a member from the cluster.
 func (h *Handler) serveLeave(w http.ResponseWriter, r *http.Request) {
-	// TODO(benbjohnson): Redirect to leader.
-
 	// Parse arguments.
 	id, err := strconv.ParseUint(r.FormValue("id"), 10, 64)
 	if err != nil {
@@ -88,7 +95,10 @@ func (h *Handler) serveLeave(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Remove a peer from the log.
-	if err := h.Log.RemovePeer(id); err != nil {
+	if err := h.Log.RemovePeer(id); err == ErrNotLeader {
+		h.redirectToLeader(w, r)
+		return
+	} else if err != nil {
 		w.Header().Set("X-Raft-Error", err.Error())
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -139,7 +149,7 @@ func (h *Handler) serveHeartbeat(w http.ResponseWriter, r *http.Request) {
 // serveStream provides a streaming log endpoint.
 func (h *Handler) serveStream(w http.ResponseWriter, r *http.Request) {
 	var err error
-	var id, index, term uint64
+	var id, index, term, lastLogTerm uint64
 
 	// Parse client's id.
 	if id, err = strconv.ParseUint(r.FormValue("id"), 10, 64); err != nil {
@@ -155,6 +165,13 @@ func (h *Handler) serveStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Reject clients that encode log entries differently.
+	if r.FormValue("format") != strconv.Itoa(logEntryFormat) {
+		w.Header().Set("X-Raft-Error", ErrIncompatibleFormat.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
 	// Parse starting index.
 	if s := r.FormValue("index"); s != "" {
 		if index, err = strconv.ParseUint(r.FormValue("index"), 10, 64); err != nil {
@@ -164,10 +181,19 @@ func (h *Handler) serveStream(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Parse the term of the client's entry at the starting index.
+	if s := r.FormValue("lastLogTerm"); s != "" {
+		if lastLogTerm, err = strconv.ParseUint(s, 10, 64); err != nil {
+			w.Header().Set("X-Raft-Error", "invalid last log term")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
 	// TODO(benbjohnson): Redirect to leader.
 
 	// Write to the response.
-	if err := h.Log.WriteEntriesTo(w, id, term, index); err != nil && err != io.EOF {
+	if err := h.Log.WriteEntriesTo(w, id, term, index, lastLogTerm); err != nil && err != io.EOF {
 		w.Header().Set("X-Raft-Error", err.Error())
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -216,3 +242,61 @@ func (h *Handler) serveRequestVote(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// serveTimeoutNow starts an election on the underlying log.
+func (h *Handler) serveTimeoutNow(w http.ResponseWriter, r *http.Request) {
+	// Parse arguments.
+	term, err := strconv.ParseUint(r.FormValue("term"), 10, 64)
+	if err != nil {
+		w.Header().Set("X-Raft-Error", "invalid term")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Start the election.
+	if err := h.Log.TimeoutNow(term); err != nil {
+		w.Header().Set("X-Raft-Error", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// serveTransfer transfers leadership to another member of the cluster.
+func (h *Handler) serveTransfer(w http.ResponseWriter, r *http.Request) {
+	// Parse arguments.
+	id, err := strconv.ParseUint(r.FormValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("X-Raft-Error", "invalid raft id")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Transfer leadership from the log.
+	if err := h.Log.TransferLeadership(id); err == ErrNotLeader {
+		h.redirectToLeader(w, r)
+		return
+	} else if err != nil {
+		w.Header().Set("X-Raft-Error", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// redirectToLeader redirects the request to the current leader.
+// Returns ErrNotLeader if there is no known leader.
+func (h *Handler) redirectToLeader(w http.ResponseWriter, r *http.Request) {
+	if _, u := h.Log.Leader(); u != nil {
+		redirectURL := *r.URL
+		redirectURL.Scheme = u.Scheme
+		redirectURL.Host = u.Host
+		http.Redirect(w, r, redirectURL.String(), http.StatusTemporaryRedirect)
+		return
+	}
+
+	w.Header().Set("X-Raft-Error", ErrNotLeader.Error())
+	w.WriteHeader(http.StatusInternalServerError)
+}
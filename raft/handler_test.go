@@ -120,6 +120,106 @@ func TestHandler_HandleLeave_Error(t *testing.T) {
 	}
 }
 
+// Ensure that leaving through a follower is redirected to the leader.
+func TestHandler_HandleLeave_ErrNotLeader(t *testing.T) {
+	h := NewHandler()
+	h.RemovePeerFunc = func(id uint64) error { return raft.ErrNotLeader }
+	h.LeaderFunc = func() (uint64, *url.URL) { return 1, &url.URL{Scheme: "http", Host: "leader:8086"} }
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	req, _ := http.NewRequest("GET", s.URL+"/raft/leave?id=2", nil)
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	} else if loc := resp.Header.Get("Location"); loc != "http://leader:8086/raft/leave?id=2" {
+		t.Fatalf("unexpected location: %s", loc)
+	}
+}
+
+// Ensure leadership can be transferred over HTTP.
+func TestHandler_HandleTransfer(t *testing.T) {
+	h := NewHandler()
+	h.TransferFunc = func(id uint64) error {
+		if id != 2 {
+			t.Fatalf("unexpected id: %d", id)
+		}
+		return nil
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	resp, err := http.Get(s.URL + "/transfer?id=2")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", resp.StatusCode, resp.Header.Get("X-Raft-Error"))
+	}
+}
+
+// Ensure that transferring with an invalid query string or without a leader returns an error.
+func TestHandler_HandleTransfer_Error(t *testing.T) {
+	h := NewHandler()
+	h.TransferFunc = func(id uint64) error {
+		if id == 1 {
+			return raft.ErrNotLeader
+		}
+		return raft.ErrTransferTimeout
+	}
+	h.LeaderFunc = func() (uint64, *url.URL) { return 0, nil }
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	for i, tt := range []struct {
+		query string
+		code  int
+		err   string
+	}{
+		{query: `id=xxx`, code: http.StatusBadRequest, err: `invalid raft id`},
+		{query: `id=1`, code: http.StatusInternalServerError, err: `not leader`},
+		{query: `id=2`, code: http.StatusInternalServerError, err: `leadership transfer timeout`},
+	} {
+		resp, err := http.Get(s.URL + "/transfer?" + tt.query)
+		if err != nil {
+			t.Fatalf("%d. unexpected error: %s", i, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.code {
+			t.Fatalf("%d. unexpected status: %d", i, resp.StatusCode)
+		} else if s := resp.Header.Get("X-Raft-Error"); s != tt.err {
+			t.Fatalf("%d. unexpected raft error: %s", i, s)
+		}
+	}
+}
+
+// Ensure an election can be started over HTTP.
+func TestHandler_HandleTimeoutNow(t *testing.T) {
+	h := NewHandler()
+	h.TimeoutNowFunc = func(term uint64) error {
+		if term != 3 {
+			t.Fatalf("unexpected term: %d", term)
+		}
+		return nil
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	resp, err := http.Get(s.URL + "/timeout?term=3")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", resp.StatusCode, resp.Header.Get("X-Raft-Error"))
+	}
+}
+
 // Ensure a heartbeat can be sent over HTTP.
 func TestHandler_HandleHeartbeat(t *testing.T) {
 	h := NewHandler()
@@ -203,13 +303,17 @@ func TestHandler_HandleHeartbeat_ErrClosed(t *testing.T) {
 // Ensure a stream can be retrieved over HTTP.
 func TestHandler_HandleStream(t *testing.T) {
 	h := NewHandler()
-	h.WriteEntriesToFunc = func(w io.Writer, id, term, index uint64) error {
+	h.WriteEntriesToFunc = func(w io.Writer, id, term, index, lastLogTerm uint64) error {
 		if w == nil {
 			t.Fatalf("expected writer")
 		} else if id != 1 {
 			t.Fatalf("unexpected id: %d", id)
 		} else if term != 2 {
 			t.Fatalf("unexpected term: %d", term)
+		} else if index != 3 {
+			t.Fatalf("unexpected index: %d", index)
+		} else if lastLogTerm != 1 {
+			t.Fatalf("unexpected last log term: %d", lastLogTerm)
 		}
 
 		w.Write([]byte("ok"))
@@ -219,7 +323,7 @@ func TestHandler_HandleStream(t *testing.T) {
 	defer s.Close()
 
 	// Connect to stream.
-	resp, err := http.Get(s.URL + "/stream?id=1&term=2")
+	resp, err := http.Get(s.URL + "/stream?id=1&term=2&format=2&index=3&lastLogTerm=1")
 	if err != nil {
 		t.Fatalf("unexpected error: %s", err)
 	} else if resp.StatusCode != http.StatusOK {
@@ -239,7 +343,7 @@ func TestHandler_HandleStream(t *testing.T) {
 // Ensure that requesting a stream with an invalid term will return an error.
 func TestHandler_HandleStream_Error(t *testing.T) {
 	h := NewHandler()
-	h.WriteEntriesToFunc = func(w io.Writer, id, term, index uint64) error {
+	h.WriteEntriesToFunc = func(w io.Writer, id, term, index, lastLogTerm uint64) error {
 		return raft.ErrNotLeader
 	}
 	s := httptest.NewServer(h)
@@ -251,9 +355,12 @@ func TestHandler_HandleStream_Error(t *testing.T) {
 		err   string
 	}{
 		{query: `id=1&term=XXX&index=0`, code: http.StatusBadRequest, err: `invalid term`},
-		{query: `id=1&term=1&index=XXX`, code: http.StatusBadRequest, err: `invalid index`},
+		{query: `id=1&term=1&index=0`, code: http.StatusBadRequest, err: `incompatible log entry format`},
+		{query: `id=1&term=1&format=1&index=0`, code: http.StatusBadRequest, err: `incompatible log entry format`},
+		{query: `id=1&term=1&format=2&index=XXX`, code: http.StatusBadRequest, err: `invalid index`},
+		{query: `id=1&term=1&format=2&index=0&lastLogTerm=XXX`, code: http.StatusBadRequest, err: `invalid last log term`},
 		{query: `id=XXX&term=1&index=XXX`, code: http.StatusBadRequest, err: `invalid id`},
-		{query: `id=0&term=1&index=2`, code: http.StatusInternalServerError, err: `not leader`},
+		{query: `id=0&term=1&format=2&index=2`, code: http.StatusInternalServerError, err: `not leader`},
 	}
 	for i, tt := range tests {
 		resp, err := http.Get(s.URL + "/stream?" + tt.query)
@@ -369,8 +476,11 @@ type Handler struct {
 	AddPeerFunc        func(u *url.URL) (uint64, *raft.Config, error)
 	RemovePeerFunc     func(id uint64) error
 	HeartbeatFunc      func(term, commitIndex, leaderID uint64) (currentIndex, currentTerm uint64, err error)
-	WriteEntriesToFunc func(w io.Writer, id, term, index uint64) error
+	WriteEntriesToFunc func(w io.Writer, id, term, index, lastLogTerm uint64) error
 	RequestVoteFunc    func(term, candidateID, lastLogIndex, lastLogTerm uint64) (uint64, error)
+	TimeoutNowFunc     func(term uint64) error
+	TransferFunc       func(id uint64) error
+	LeaderFunc         func() (uint64, *url.URL)
 }
 
 // NewHandler returns a new instance of Handler.
@@ -387,10 +497,14 @@ func (h *Handler) Heartbeat(term, commitIndex, leaderID uint64) (currentIndex, c
 	return h.HeartbeatFunc(term, commitIndex, leaderID)
 }
 
-func (h *Handler) WriteEntriesTo(w io.Writer, id, term, index uint64) error {
-	return h.WriteEntriesToFunc(w, id, term, index)
+func (h *Handler) WriteEntriesTo(w io.Writer, id, term, index, lastLogTerm uint64) error {
+	return h.WriteEntriesToFunc(w, id, term, index, lastLogTerm)
 }
 
 func (h *Handler) RequestVote(term, candidateID, lastLogIndex, lastLogTerm uint64) (uint64, error) {
 	return h.RequestVoteFunc(term, candidateID, lastLogIndex, lastLogTerm)
 }
+
+func (h *Handler) TimeoutNow(term uint64) error       { return h.TimeoutNowFunc(term) }
+func (h *Handler) TransferLeadership(id uint64) error { return h.TransferFunc(id) }
+func (h *Handler) Leader() (uint64, *url.URL)         { return h.LeaderFunc() }
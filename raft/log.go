@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
 	"log"
@@ -40,7 +41,17 @@ type FSM interface {
 	Restore(r io.Reader) error
 }
 
-const logEntryHeaderSize = 8 + 8 + 8 // sz+index+term
+const logEntryHeaderSize = 8 + 8 + 8 + 4 + 4 // sz+index+term+header checksum+data checksum
+
+// logEntryFormat is the version of the log entry encoding. Followers send it
+// when connecting to the leader's stream so a leader using a different
+// encoding rejects the stream instead of sending entries that would be misread.
+// Nodes using different versions cannot replicate so every node in a cluster
+// must be upgraded together.
+const logEntryFormat = 2
+
+// DefaultSnapshotThreshold is the default number of applied entries kept in memory.
+const DefaultSnapshotThreshold = 1000
 
 // State represents whether the log is a follower, candidate, or leader.
 type State int
@@ -75,8 +86,9 @@ type Log struct {
 	path   string  // data directory
 	config *Config // cluster configuration
 
-	state State         // current node state
-	ch    chan struct{} // state change channel
+	state        State         // current node state
+	ch           chan struct{} // state change channel
+	transferring bool          // leadership transfer in progress
 
 	term        uint64    // current election term
 	lastLogTerm uint64    // highest term in the log
@@ -102,13 +114,7 @@ type Log struct {
 	FSM FSM
 
 	// The transport used to communicate with other nodes in the cluster.
-	Transport interface {
-		Join(u *url.URL, nodeURL *url.URL) (uint64, *Config, error)
-		Leave(u *url.URL, id uint64) error
-		Heartbeat(u *url.URL, term, commitIndex, leaderID uint64) (lastIndex, currentTerm uint64, err error)
-		ReadFrom(u *url.URL, id, term, index uint64) (io.ReadCloser, error)
-		RequestVote(u *url.URL, term, candidateID, lastLogIndex, lastLogTerm uint64) (uint64, error)
-	}
+	Transport Transport
 
 	// Clock is an abstraction of time.
 	Clock interface {
@@ -122,6 +128,10 @@ type Log struct {
 	// Rand returns a random number.
 	Rand func() int64
 
+	// The number of applied entries kept in memory before they are truncated.
+	// Followers that are behind the retained entries are sent a snapshot.
+	SnapshotThreshold uint64
+
 	// Sets whether trace messages are logged.
 	DebugEnabled bool
 
@@ -132,9 +142,10 @@ type Log struct {
 // NewLog creates a new instance of Log with reasonable defaults.
 func NewLog() *Log {
 	l := &Log{
-		Clock:     NewClock(),
-		Transport: &HTTPTransport{},
-		Rand:      rand.Int63,
+		Clock:             NewClock(),
+		Transport:         &HTTPTransport{},
+		Rand:              rand.Int63,
+		SnapshotThreshold: DefaultSnapshotThreshold,
 	}
 	l.SetLogOutput(os.Stderr)
 	return l
@@ -301,6 +312,11 @@ func (l *Log) close() error {
 	}
 	l.writers = nil
 
+	// Close any connections held by the transport.
+	if t, ok := l.Transport.(io.Closer); ok {
+		_ = t.Close()
+	}
+
 	l.tracef("close")
 
 	// Clear log info.
@@ -601,6 +617,14 @@ func (l *Log) setState(state State) {
 		_ = l.reader.Close()
 	}
 
+	// Disconnect followers when stepping down so they reconnect to the new leader.
+	if l.state == Leader && state != Leader {
+		for _, w := range l.writers {
+			_ = w.Close()
+		}
+		l.writers = nil
+	}
+
 	// Set the new state.
 	l.state = state
 
@@ -629,32 +653,35 @@ func (l *Log) followerLoop(done chan struct{}) {
 			l.mu.Unlock()
 			return
 		}
-		id, index, term := l.id, l.index, l.term
+		id, index, term, lastLogTerm := l.id, l.index, l.term, l.lastLogTerm
 		_, u := l.leader()
+		removed := l.removed()
 		l.mu.Unlock()
 
 		// If no leader exists then wait momentarily and retry.
-		if u == nil {
+		// Logs removed from the cluster no longer stream from the leader.
+		if u == nil || removed {
 			l.tracef("followerLoop: no leader")
 			time.Sleep(1 * time.Millisecond)
 			continue
 		}
 
 		// Connect to leader.
-		l.tracef("followerLoop: read from: %s, id=%d, term=%d, index=%d", u.String(), id, term, index)
-		r, err := l.Transport.ReadFrom(u, id, term, index)
+		l.tracef("followerLoop: read from: %s, id=%d, term=%d, index=%d, lastLogTerm=%d", u.String(), id, term, index, lastLogTerm)
+		r, err := l.Transport.ReadFrom(u, id, term, index, lastLogTerm)
 		if err != nil {
 			l.Logger.Printf("connect stream: %s", err)
 		}
 
 		// Stream the log in from a separate goroutine.
+		// Reconnect whenever the stream ends, such as when the leader steps down.
 		rch = make(chan struct{})
 		go func(u *url.URL, term, index uint64, rch chan struct{}) {
 			// Attach the stream to the log.
 			if err := l.ReadFrom(r); err != nil {
 				l.tracef("followerLoop: read from: disconnect: %s", err)
-				close(rch)
 			}
+			close(rch)
 		}(u, term, index, rch)
 
 		// Check if the state has changed, stream has closed, or an
@@ -897,6 +924,14 @@ func (l *Log) internalApply(typ LogEntryType, command []byte) (index uint64, err
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	// Hold commands until a leadership transfer completes. If leadership is
+	// transferred then the command is rejected and can be sent to the new leader.
+	for l.transferring {
+		l.mu.Unlock()
+		time.Sleep(1 * time.Millisecond)
+		l.mu.Lock()
+	}
+
 	// Do not apply if this node is closed.
 	// Do not apply if this node is not the leader.
 	if l.state == Stopped {
@@ -1057,15 +1092,6 @@ func (l *Log) applier(done chan chan struct{}) {
 			l.tracef("applier: entries: len=%d, min=%d, start=%d, end=%d <%d:%d>", len(l.entries), min, startIndex, endIndex, startIndex-min, endIndex-min+1)
 			entries := l.entries[startIndex-min : endIndex-min+1]
 
-			// Determine low water mark for entries to cut off.
-			max := endIndex
-			for _, w := range l.writers {
-				if w.snapshotIndex > 0 && w.snapshotIndex < max {
-					max = w.snapshotIndex
-				}
-			}
-			l.entries = l.entries[max-min:]
-
 			// Iterate over each entry and apply it.
 			for _, e := range entries {
 				// l.tracef("applier: entry: idx=%d", e.Index)
@@ -1091,6 +1117,9 @@ func (l *Log) applier(done chan chan struct{}) {
 				l.appliedIndex++
 			}
 
+			// Truncate applied entries once there are too many in memory.
+			l.compact()
+
 			return nil
 		}()
 
@@ -1109,6 +1138,39 @@ func (l *Log) applier(done chan chan struct{}) {
 	}
 }
 
+// compact truncates applied entries once more than the snapshot threshold are
+// held in memory. The applied state is kept by the FSM so followers that need
+// truncated entries are sent a snapshot instead. Entries needed by writers that
+// are still sending a snapshot are retained. Must be called with a lock.
+func (l *Log) compact() {
+	if len(l.entries) == 0 {
+		return
+	}
+
+	// Ignore if the number of applied entries is within the threshold.
+	min := l.entries[0].Index
+	if l.appliedIndex < min || l.appliedIndex-min+1 <= l.SnapshotThreshold {
+		return
+	}
+
+	// Determine low water mark for entries to cut off.
+	max := l.appliedIndex - l.SnapshotThreshold + 1
+	for _, w := range l.writers {
+		if w.snapshotIndex > 0 && w.snapshotIndex < max {
+			max = w.snapshotIndex
+		}
+	}
+	if max <= min {
+		return
+	}
+	l.tracef("compact: min=%d, max=%d, applied=%d", min, max, l.appliedIndex)
+
+	// Copy the retained entries so the truncated entries can be released.
+	entries := make([]*LogEntry, len(l.entries)-int(max-min))
+	copy(entries, l.entries[max-min:])
+	l.entries = entries
+}
+
 // mustApplyInitialize a log initialization command by parsing and setting the configuration.
 func (l *Log) mustApplyInitialize(e *LogEntry) {
 	// Parse the configuration from the log entry.
@@ -1160,12 +1222,47 @@ func (l *Log) mustApplyAddPeer(e *LogEntry) {
 }
 
 // mustApplyRemovePeer removes a node from the cluster configuration.
-func (l *Log) mustApplyRemovePeer(e *LogEntry) error {
-	// TODO(benbjohnson): Clone configuration.
-	// TODO(benbjohnson): Remove node from configuration.
-	// TODO(benbjohnson): Set configuration index.
-	// TODO(benbjohnson): Write configuration.
-	return nil
+func (l *Log) mustApplyRemovePeer(e *LogEntry) {
+	// Unmarshal node from entry data.
+	var n *ConfigNode
+	if err := json.Unmarshal(e.Data, &n); err != nil {
+		panic("unmarshal: " + err.Error())
+	}
+
+	// Clone configuration and remove the node.
+	// The node may have already been removed by a previous command.
+	config := l.config.Clone()
+	if err := config.RemoveNode(n.ID); err != nil {
+		l.Logger.Printf("apply: remove node: %d not found", n.ID)
+		return
+	}
+
+	// Set configuration index.
+	config.Index = e.Index
+
+	// Write configuration.
+	if err := l.writeConfig(config); err != nil {
+		panic("write config: " + err.Error())
+	}
+	l.config = config
+
+	// Stop streaming to the removed node.
+	for i := 0; i < len(l.writers); i++ {
+		if w := l.writers[i]; w.id == n.ID {
+			l.removeWriter(w)
+			i--
+		}
+	}
+
+	if n.ID == l.id {
+		l.Logger.Printf("log removed from cluster: id=%d", l.id)
+	}
+}
+
+// removed returns true if the log is no longer in the cluster configuration.
+// Removed logs do not start elections or stream from the leader.
+func (l *Log) removed() bool {
+	return l.config != nil && l.config.NodeByID(l.id) == nil
 }
 
 // AddPeer creates a new peer in the cluster.
@@ -1200,13 +1297,133 @@ func (l *Log) AddPeer(u *url.URL) (uint64, *Config, error) {
 }
 
 // RemovePeer removes an existing peer from the cluster by id.
+// The leader cannot be removed. Leadership must be transferred first.
 func (l *Log) RemovePeer(id uint64) error {
+	// Validate the node under lock.
+	if err := func() error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if l.state != Leader {
+			return ErrNotLeader
+		} else if id == l.id {
+			return ErrRemoveLeader
+		} else if l.config.NodeByID(id) == nil {
+			return ErrNodeNotFound
+		}
+		return nil
+	}(); err != nil {
+		return err
+	}
+
+	// Apply command.
+	b, _ := json.Marshal(&ConfigNode{ID: id})
+	index, err := l.internalApply(LogEntryRemovePeer, b)
+	if err != nil {
+		return err
+	}
+	return l.Wait(index)
+}
+
+// TransferLeadership hands leadership to another node in the cluster.
+//
+// Commands are held while the node catches up to the leader's log. The node
+// is then asked to start an election immediately so the cluster does not wait
+// for an election timeout. The transfer is abandoned if the leader has not
+// stepped down within an election timeout.
+func (l *Log) TransferLeadership(id uint64) error {
+	// Validate the target node under lock.
+	var leaderID uint64
+	var u *url.URL
+	if err := func() error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if l.state != Leader {
+			return ErrNotLeader
+		} else if id == l.id {
+			return ErrInvalidNodeID
+		}
+
+		n := l.config.NodeByID(id)
+		if n == nil {
+			return ErrNodeNotFound
+		}
+		leaderID, u = l.id, n.URL
+		return nil
+	}(); err != nil {
+		return err
+	}
+
+	// Append an entry in the current term so the target's log is at least as
+	// up-to-date as every other node's log once it has caught up.
+	if _, err := l.internalApply(LogEntryNop, nil); err != nil {
+		return err
+	}
+
+	// Hold new commands until the transfer completes.
 	l.mu.Lock()
-	defer l.mu.Unlock()
+	if l.transferring {
+		l.mu.Unlock()
+		return ErrTransferInProgress
+	} else if l.state != Leader {
+		l.mu.Unlock()
+		return ErrNotLeader
+	}
+	l.transferring = true
+	term, index := l.term, l.index
+	l.mu.Unlock()
 
-	// TODO(benbjohnson): Apply removePeerCommand.
+	defer func() {
+		l.mu.Lock()
+		l.transferring = false
+		l.mu.Unlock()
+	}()
 
-	return nil
+	l.Logger.Printf("transfer leadership: id=%d, term=%d, index=%d", id, term, index)
+	timeout := time.After(DefaultElectionTimeout)
+
+	// Wait for the target to catch up to the leader's log.
+	for {
+		l.mu.Lock()
+		commitIndex, state := l.commitIndex, l.state
+		l.mu.Unlock()
+		if state != Leader {
+			return ErrNotLeader
+		}
+
+		peerIndex, _, err := l.Transport.Heartbeat(u, term, commitIndex, leaderID)
+		if err == nil && peerIndex >= index {
+			break
+		}
+
+		select {
+		case <-timeout:
+			return ErrTransferTimeout
+		case <-time.After(1 * time.Millisecond):
+		}
+	}
+
+	// Request the target to start an election.
+	if err := l.Transport.TimeoutNow(u, term); err != nil {
+		return err
+	}
+
+	// Wait for the new leader to depose this log.
+	for {
+		l.mu.Lock()
+		state := l.state
+		l.mu.Unlock()
+		if state != Leader {
+			return nil
+		}
+
+		select {
+		case <-timeout:
+			return ErrTransferTimeout
+		case <-time.After(1 * time.Millisecond):
+		}
+	}
 }
 
 // Heartbeat establishes dominance by the current leader.
@@ -1258,6 +1475,7 @@ func (l *Log) RequestVote(term, candidateID, lastLogIndex, lastLogTerm uint64) (
 	//   1. Candidate is requesting a vote from an earlier term. (§5.1)
 	//   2. Already voted for a different candidate in this term. (§5.2)
 	//   3. Candidate log is less up-to-date than local log. (§5.4)
+	//   4. Candidate has been removed from the cluster.
 	if term < l.term {
 		return l.term, ErrStaleTerm
 	} else if term == l.term && l.votedFor != 0 && l.votedFor != candidateID {
@@ -1266,6 +1484,8 @@ func (l *Log) RequestVote(term, candidateID, lastLogIndex, lastLogTerm uint64) (
 		return l.term, ErrOutOfDateLog
 	} else if lastLogTerm == l.term && lastLogIndex < l.index {
 		return l.term, ErrOutOfDateLog
+	} else if l.config != nil && l.config.NodeByID(candidateID) == nil {
+		return l.term, ErrNodeNotFound
 	}
 
 	// Vote for candidate.
@@ -1276,6 +1496,38 @@ func (l *Log) RequestVote(term, candidateID, lastLogIndex, lastLogTerm uint64) (
 	return l.term, nil
 }
 
+// TimeoutNow starts an election immediately instead of waiting for an
+// election timeout. This is requested by the leader to transfer leadership.
+func (l *Log) TimeoutNow(term uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Check if log is closed.
+	if !l.opened() || l.state == Stopped {
+		return ErrClosed
+	}
+
+	// Ignore requests from a previous term or if an election has already begun.
+	if term < l.term {
+		return ErrStaleTerm
+	} else if l.state != Follower {
+		return nil
+	} else if l.removed() {
+		return ErrNodeNotFound
+	}
+
+	l.tracef("TimeoutNow: beginning election in term %d", l.term+1)
+
+	// Start a new election and promote.
+	if err := l.writeTerm(l.term + 1); err != nil {
+		return fmt.Errorf("write term: %s", err)
+	}
+	l.term++
+	l.setState(Candidate)
+
+	return nil
+}
+
 // elector runs in a separate goroutine and checks for election timeouts.
 func (l *Log) elector(done chan chan struct{}) {
 	for {
@@ -1304,10 +1556,14 @@ func (l *Log) elector(done chan chan struct{}) {
 			}
 
 			// Ignore if not a follower or a candidate.
+			// Ignore if the log has been removed from the cluster.
 			// Ignore if the last contact was less than the election timeout.
 			if l.state != Follower && l.state != Candidate {
 				l.tracef("elector: log is not follower or candidate")
 				return nil
+			} else if l.removed() {
+				l.tracef("elector: log has been removed")
+				return nil
 			} else if l.lastContact.IsZero() {
 				l.tracef("elector: last contact is zero")
 				return nil
@@ -1343,21 +1599,26 @@ func (l *Log) elector(done chan chan struct{}) {
 }
 
 // WriteEntriesTo attaches a writer to the log from a given index.
-// The index specified must be a committed index.
-func (l *Log) WriteEntriesTo(w io.Writer, id, term, index uint64) error {
+// The index specified must be a committed index and lastLogTerm is the term
+// of the reader's entry at that index. If the entries after the index have
+// been truncated or the reader's entry does not match the log then a snapshot
+// of the FSM is written first.
+func (l *Log) WriteEntriesTo(w io.Writer, id, term, index, lastLogTerm uint64) error {
 	// Validate and initialize the writer.
-	writer, err := l.initWriter(w, id, term, index)
+	writer, snapshotIndex, err := l.initWriter(w, id, term, index, lastLogTerm)
 	if err != nil {
 		return err
 	}
 
 	// Write the snapshot and advance the writer through the log.
 	// If an error occurs then remove the writer.
-	if err := l.writeTo(writer, id, term, index); err != nil {
-		l.mu.Lock()
-		l.removeWriter(writer)
-		l.mu.Unlock()
-		return err
+	if snapshotIndex > 0 {
+		if err := l.writeTo(writer, id, term, index); err != nil {
+			l.mu.Lock()
+			l.removeWriter(writer)
+			l.mu.Unlock()
+			return err
+		}
 	}
 
 	// Wait for writer to finish.
@@ -1394,14 +1655,15 @@ func (l *Log) writeTo(writer *logWriter, id, term, index uint64) error {
 	return nil
 }
 
-// validates writer and adds it to the list of writers.
-func (l *Log) initWriter(w io.Writer, id, term, index uint64) (*logWriter, error) {
+// validates writer and adds it to the list of writers. Returns the writer's
+// snapshot index, which is zero if no snapshot is needed.
+func (l *Log) initWriter(w io.Writer, id, term, index, lastLogTerm uint64) (*logWriter, uint64, error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	// Check if log is closed.
 	if !l.opened() {
-		return nil, ErrClosed
+		return nil, 0, ErrClosed
 	}
 
 	// Step down if from a higher term.
@@ -1414,33 +1676,70 @@ func (l *Log) initWriter(w io.Writer, id, term, index uint64) (*logWriter, error
 	//   2. Term is earlier than current term.
 	//   3. Index is after the commit index.
 	if l.state != Leader {
-		return nil, ErrNotLeader
+		return nil, 0, ErrNotLeader
 	} else if index > l.index {
-		return nil, ErrUncommittedIndex
+		return nil, 0, ErrUncommittedIndex
 	}
 
 	// OPTIMIZE(benbjohnson): Create buffered output to prevent blocking.
 
-	// Write configuration.
+	// Wrap writer and append to log to tail.
+	writer := &logWriter{
+		Writer: w,
+		id:     id,
+		done:   make(chan struct{}),
+	}
+
+	// Replay the entries after the index if they are still in the log and the
+	// reader's log matches up to the index. The configuration is rebuilt by
+	// the follower as the entries are applied.
+	enc := NewLogEntryEncoder(w)
+	if entries, ok := l.entriesAfter(index, lastLogTerm); ok {
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return nil, 0, err
+			}
+		}
+		flushWriter(w)
+		l.writers = append(l.writers, writer)
+		return writer, 0, nil
+	}
+
+	// Otherwise write configuration and mark the writer as needing a snapshot.
 	var buf bytes.Buffer
 	err := NewConfigEncoder(&buf).Encode(l.config)
 	assert(err == nil, "marshal config error: %s", err)
-	enc := NewLogEntryEncoder(w)
 	if err := enc.Encode(&LogEntry{Type: logEntryConfig, Data: buf.Bytes()}); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	flushWriter(w)
 
-	// Wrap writer and append to log to tail.
-	writer := &logWriter{
-		Writer:        w,
-		id:            id,
-		snapshotIndex: l.appliedIndex,
-		done:          make(chan struct{}),
-	}
+	writer.snapshotIndex = l.appliedIndex
 	l.writers = append(l.writers, writer)
 
-	return writer, nil
+	return writer, writer.snapshotIndex, nil
+}
+
+// entriesAfter returns the entries after an index. Returns false if any of the
+// entries have been truncated from the log or if the entry at the index does
+// not have the given term. A reader whose entry differs may have entries that
+// were never committed so it must be sent a snapshot. Must be called with a lock.
+func (l *Log) entriesAfter(index, term uint64) ([]*LogEntry, bool) {
+	if len(l.entries) == 0 {
+		return nil, index == l.index && term == l.lastLogTerm
+	}
+
+	// The entry at the index must be retained to compare its term.
+	// An index of zero precedes the first entry so there is nothing to compare.
+	min := l.entries[0].Index
+	if index == 0 && min == 1 {
+		return l.entries, true
+	} else if index < min {
+		return nil, false
+	} else if l.entries[index-min].Term != term {
+		return nil, false
+	}
+	return l.entries[index+1-min:], true
 }
 
 // replays entries since the snapshot's index and begins tailing the log.
@@ -1522,9 +1821,14 @@ func (l *Log) ReadFrom(r io.ReadCloser) error {
 	dec := NewLogEntryDecoder(r)
 	for {
 		// Decode single entry.
+		// A corrupt or truncated entry is discarded and an error is returned so
+		// that the follower reconnects and re-fetches it from the leader.
 		var e LogEntry
 		if err := dec.Decode(&e); err == io.EOF {
 			return nil
+		} else if err == ErrInvalidChecksum || err == io.ErrUnexpectedEOF {
+			l.Logger.Printf("read from: discarding invalid entry: prev=%d, err=%s", l.index, err)
+			return err
 		} else if err != nil {
 			return err
 		}
@@ -1582,6 +1886,11 @@ func (l *Log) ReadFrom(r io.ReadCloser) error {
 			return nil
 		}
 		//l.tracef("ReadFrom: entry: index=%d / prev=%d / commit=%d", e.Index, l.index, l.commitIndex)
+		if e.Index != l.index+1 {
+			l.Logger.Printf("read from: discarding non-contiguous entry: index=%d, prev=%d", e.Index, l.index)
+			l.mu.Unlock()
+			return ErrNonContiguousIndex
+		}
 		l.append(&e)
 		l.mu.Unlock()
 	}
@@ -1662,11 +1971,15 @@ type LogEntry struct {
 }
 
 // encodedHeader returns the encoded header for the entry.
+// The header is checksummed separately from the data so that a corrupt data
+// size is detected before the data is read.
 func (e *LogEntry) encodedHeader() []byte {
 	var b [logEntryHeaderSize]byte
 	binary.BigEndian.PutUint64(b[0:8], (uint64(e.Type)<<56)|uint64(len(e.Data)))
 	binary.BigEndian.PutUint64(b[8:16], e.Index)
 	binary.BigEndian.PutUint64(b[16:24], e.Term)
+	binary.BigEndian.PutUint32(b[24:28], crc32.ChecksumIEEE(b[0:24]))
+	binary.BigEndian.PutUint32(b[28:32], crc32.ChecksumIEEE(e.Data))
 	return b[:]
 }
 
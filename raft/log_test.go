@@ -1,6 +1,7 @@
 package raft_test
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
@@ -84,6 +85,169 @@ func TestLog_Apply(t *testing.T) {
 	}
 }
 
+// Ensure that a follower is sent the entries after its index if they are still in the log.
+func TestLog_WriteEntriesTo_Entries(t *testing.T) {
+	l := NewInitializedLog(&url.URL{Host: "log0"})
+	defer l.Close()
+	l.MustApplyCommands("foo", "bar", "baz")
+
+	// Stream from after the first command.
+	tr := NewTransport()
+	tr.register(l.Log)
+	r, err := tr.ReadFrom(l.URL, 2, 1, 2, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The remaining commands should be replayed without a snapshot.
+	dec := raft.NewLogEntryDecoder(r)
+	for i, data := range []string{"bar", "baz"} {
+		var e raft.LogEntry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("unexpected error(%d): %s", i, err)
+		} else if e.Type != raft.LogEntryCommand || e.Index != uint64(i+3) || string(e.Data) != data {
+			t.Fatalf("unexpected entry(%d): type=%d, index=%d, data=%s", i, e.Type, e.Index, e.Data)
+		}
+	}
+}
+
+// Ensure that a follower behind the truncated entries is sent a snapshot.
+func TestLog_WriteEntriesTo_Snapshot(t *testing.T) {
+	l := NewLog(&url.URL{Host: "log0"})
+	l.SnapshotThreshold = 2
+	l.MustOpen()
+	l.MustInitialize()
+	defer l.Close()
+	l.MustApplyCommands("foo", "bar", "baz")
+
+	// Stream from after the first command, which has been truncated.
+	tr := NewTransport()
+	tr.register(l.Log)
+	r, err := tr.ReadFrom(l.URL, 2, 1, 2, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Read the configuration and the snapshot marker.
+	dec := raft.NewLogEntryDecoder(r)
+	for i := 0; i < 2; i++ {
+		var e raft.LogEntry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("unexpected error(%d): %s", i, err)
+		} else if e.Type == raft.LogEntryCommand {
+			t.Fatalf("unexpected command(%d): index=%d", i, e.Index)
+		}
+	}
+
+	// Restore the snapshot and verify that it contains every command.
+	fsm := &FSM{}
+	var index uint64
+	if err := fsm.Restore(r); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := binary.Read(r, binary.BigEndian, &index); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if index != 4 {
+		t.Fatalf("unexpected snapshot index: %d", index)
+	} else if n := len(fsm.Commands); n != 3 {
+		t.Fatalf("unexpected command count: %d", n)
+	}
+
+	// A follower within the retained entries is still sent entries.
+	r, err = tr.ReadFrom(l.URL, 2, 1, 3, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var e raft.LogEntry
+	if err := raft.NewLogEntryDecoder(r).Decode(&e); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if e.Type != raft.LogEntryCommand || e.Index != 4 || string(e.Data) != "baz" {
+		t.Fatalf("unexpected entry: type=%d, index=%d, data=%s", e.Type, e.Index, e.Data)
+	}
+}
+
+// Ensure that a follower whose entry at its index differs from the log is sent a snapshot.
+func TestLog_WriteEntriesTo_TermMismatch(t *testing.T) {
+	l := NewInitializedLog(&url.URL{Host: "log0"})
+	defer l.Close()
+	l.MustApplyCommands("foo", "bar", "baz")
+
+	// Stream from an entry that was written in a different term.
+	tr := NewTransport()
+	tr.register(l.Log)
+	r, err := tr.ReadFrom(l.URL, 2, 1, 3, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The configuration and the snapshot marker should be sent instead of entries.
+	dec := raft.NewLogEntryDecoder(r)
+	for i := 0; i < 2; i++ {
+		var e raft.LogEntry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("unexpected error(%d): %s", i, err)
+		} else if e.Type == raft.LogEntryCommand {
+			t.Fatalf("unexpected command(%d): index=%d", i, e.Index)
+		}
+	}
+
+	// The snapshot should contain every command.
+	fsm := &FSM{}
+	var index uint64
+	if err := fsm.Restore(r); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := binary.Read(r, binary.BigEndian, &index); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if index != 4 {
+		t.Fatalf("unexpected snapshot index: %d", index)
+	} else if n := len(fsm.Commands); n != 3 {
+		t.Fatalf("unexpected command count: %d", n)
+	}
+}
+
+// Ensure that a corrupt entry read from the leader is discarded without being appended.
+func TestLog_ReadFrom_ErrInvalidChecksum(t *testing.T) {
+	l := NewInitializedLog(&url.URL{Host: "log0"})
+	defer l.Close()
+
+	// Encode three entries and corrupt the data of the second entry.
+	var buf bytes.Buffer
+	enc := raft.NewLogEntryEncoder(&buf)
+	for i, data := range []string{"foo", "bar", "baz"} {
+		if err := enc.Encode(&raft.LogEntry{Type: raft.LogEntryCommand, Index: uint64(i + 2), Term: 1, Data: []byte(data)}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	b := buf.Bytes()
+	b[(len(b)/3)*2-1] ^= 0xFF
+
+	// Only the entry before the corrupt entry should be appended.
+	if err := l.ReadFrom(ioutil.NopCloser(&buf)); err != raft.ErrInvalidChecksum {
+		t.Fatalf("unexpected error: %s", err)
+	} else if index, _, _ := l.Heartbeat(0, 0, 0); index != 2 {
+		t.Fatalf("unexpected index: %d", index)
+	}
+}
+
+// Ensure that an entry that does not follow the log's last entry is discarded.
+func TestLog_ReadFrom_ErrNonContiguousIndex(t *testing.T) {
+	l := NewInitializedLog(&url.URL{Host: "log0"})
+	defer l.Close()
+
+	var buf bytes.Buffer
+	enc := raft.NewLogEntryEncoder(&buf)
+	for _, index := range []uint64{2, 4} {
+		if err := enc.Encode(&raft.LogEntry{Type: raft.LogEntryCommand, Index: index, Term: 1, Data: []byte("foo")}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if err := l.ReadFrom(ioutil.NopCloser(&buf)); err != raft.ErrNonContiguousIndex {
+		t.Fatalf("unexpected error: %s", err)
+	} else if index, _, _ := l.Heartbeat(0, 0, 0); index != 2 {
+		t.Fatalf("unexpected index: %d", index)
+	}
+}
+
 // Ensure that a node has no configuration after it's closed.
 func TestLog_Config_Closed(t *testing.T) {
 	l := NewInitializedLog(&url.URL{Host: "log0"})
@@ -232,6 +396,95 @@ func TestLog_Elect(t *testing.T) {
 	}
 }
 
+// Ensure that a follower can be removed from the cluster.
+func TestCluster_RemovePeer(t *testing.T) {
+	c := NewCluster()
+	defer c.Close()
+
+	// Remove the third node and apply the change on the leader.
+	go func() {
+		c.MustWaitUncommitted(4)
+		c.Logs[0].Clock.heartbeat()
+		c.Logs[0].Clock.apply()
+	}()
+	if err := c.Logs[0].RemovePeer(3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := len(c.Logs[0].Config().Nodes); n != 2 {
+		t.Fatalf("unexpected node count: %d", n)
+	}
+
+	// Heartbeat the commit index and verify the remaining follower applies it.
+	c.Logs[0].Clock.heartbeat()
+	c.Logs[1].Clock.apply()
+	if n := len(c.Logs[1].Config().Nodes); n != 2 {
+		t.Fatalf("unexpected node count on follower: %d", n)
+	}
+
+	// Removing an unknown node or the leader should fail.
+	if err := c.Logs[0].RemovePeer(3); err != raft.ErrNodeNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := c.Logs[0].RemovePeer(1); err != raft.ErrRemoveLeader {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := c.Logs[1].RemovePeer(2); err != raft.ErrNotLeader {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure that leadership can be transferred to a follower without an election timeout.
+func TestCluster_TransferLeadership(t *testing.T) {
+	c := NewCluster()
+	defer c.Close()
+
+	// Transfer leadership to the second node.
+	if err := c.Logs[0].TransferLeadership(2); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Ensure node 1 is elected in the next term and node 0 has stepped down.
+	if state := c.Logs[1].State(); state != raft.Leader {
+		t.Fatalf("expected node 1 to move to leader: %s", state)
+	} else if term := c.Logs[1].Term(); term != 2 {
+		t.Fatalf("expected term 2: got %d", term)
+	} else if state := c.Logs[0].State(); state != raft.Follower {
+		t.Fatalf("expected node 0 to step down: %s", state)
+	}
+
+	// The previous leader should reject commands.
+	if _, err := c.Logs[0].Apply([]byte("foo")); err != raft.ErrNotLeader {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Apply a command on the new leader and ensure it's replicated.
+	index, err := c.Logs[1].Apply([]byte("abc"))
+	if err != nil {
+		t.Fatalf("unexpected apply error: %s", err)
+	}
+	c.MustWaitUncommitted(index)
+	c.Logs[1].Clock.heartbeat()
+	c.Logs[1].Clock.heartbeat()
+	c.Logs[0].Clock.apply()
+	c.Logs[1].Clock.apply()
+	c.Logs[2].Clock.apply()
+	if err := c.Logs[0].Wait(index); err != nil {
+		t.Fatalf("unexpected wait error: %s", err)
+	}
+}
+
+// Ensure that leadership cannot be transferred to itself or an unknown node.
+func TestLog_TransferLeadership_Error(t *testing.T) {
+	c := NewCluster()
+	defer c.Close()
+
+	if err := c.Logs[0].TransferLeadership(1); err != raft.ErrInvalidNodeID {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := c.Logs[0].TransferLeadership(100); err != raft.ErrNodeNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := c.Logs[1].TransferLeadership(3); err != raft.ErrNotLeader {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
 // Ensure that state can be stringified.
 func TestState_String(t *testing.T) {
 	var tests = []struct {
@@ -480,6 +733,24 @@ func (l *Log) MustInitialize() {
 	}
 }
 
+// MustApplyCommands applies commands to a single node log and waits for them
+// to be applied to the FSM. Panic on error.
+func (l *Log) MustApplyCommands(commands ...string) {
+	var index uint64
+	for _, command := range commands {
+		i, err := l.Apply([]byte(command))
+		if err != nil {
+			panic("apply: " + err.Error())
+		}
+		index = i
+	}
+
+	go func() { l.Clock.apply() }()
+	if err := l.Wait(index); err != nil {
+		panic("wait: " + err.Error())
+	}
+}
+
 // Close closes the log and HTTP server.
 func (l *Log) Close() error {
 	defer os.RemoveAll(l.Log.Path())
@@ -0,0 +1,774 @@
+package raft
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+)
+
+// TCP frame types.
+const (
+	tcpJoin         = byte(0x01)
+	tcpLeave        = byte(0x02)
+	tcpHeartbeat    = byte(0x03)
+	tcpRequestVote  = byte(0x04)
+	tcpStream       = byte(0x05)
+	tcpStreamCancel = byte(0x06)
+	tcpTimeoutNow   = byte(0x07)
+
+	tcpResponse   = byte(0x81)
+	tcpStreamData = byte(0x82)
+	tcpStreamEnd  = byte(0x83)
+)
+
+const (
+	// tcpFrameHeaderSize is the size of the type, id & payload size.
+	tcpFrameHeaderSize = 1 + 8 + 4
+
+	// tcpMaxFrameSize is the largest payload accepted in a single frame.
+	tcpMaxFrameSize = 1 << 20
+
+	// tcpStreamChunkSize is the largest payload sent in a single stream frame.
+	tcpStreamChunkSize = 32 * 1024
+)
+
+// DefaultTCPTimeout is the default time to wait for a node to accept a
+// connection or to respond to a request.
+const DefaultTCPTimeout = 10 * time.Second
+
+var (
+	// errStreamCanceled is returned when writing to a stream the node has closed.
+	errStreamCanceled = errors.New("stream canceled")
+
+	// errRequestTimeout is returned when a node does not respond to a request in time.
+	errRequestTimeout = errors.New("request timeout")
+)
+
+// TCPTransport represents a transport for sending RPCs over a persistent TCP
+// connection to each node.
+//
+// Connections are established by upgrading an HTTP request to the node's raft
+// handler so nodes are addressed by the same URLs as the HTTPTransport. Each
+// request and log stream is tagged with an id so they can be multiplexed over
+// the same connection using a compact binary framing:
+//
+//	type (1 byte) | id (8 bytes) | payload size (4 bytes) | payload
+//
+// Each log stream is sent over its own connection so a follower that is slow
+// to read the log never delays heartbeats or votes sent to it.
+type TCPTransport struct {
+	// Timeout is the time to wait for a node to accept a connection or to
+	// respond to a request. Defaults to DefaultTCPTimeout.
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	conns   map[string]*tcpConn   // request connections by host
+	streams map[*tcpConn]struct{} // log stream connections
+}
+
+// Join requests membership into a node's cluster.
+func (t *TCPTransport) Join(u *url.URL, nodeURL *url.URL) (uint64, *Config, error) {
+	body, err := t.call(u, tcpJoin, []byte(nodeURL.String()))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Parse returned id.
+	a, body, err := decodeUint64s(body, 1)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Unmarshal config.
+	var config *Config
+	if err := json.Unmarshal(body, &config); err != nil {
+		return 0, nil, fmt.Errorf("config unmarshal: %s", err)
+	}
+
+	return a[0], config, nil
+}
+
+// Leave removes a node from a cluster's membership.
+func (t *TCPTransport) Leave(u *url.URL, id uint64) error {
+	_, err := t.call(u, tcpLeave, encodeUint64s(id))
+	return err
+}
+
+// Heartbeat checks the status of a follower.
+func (t *TCPTransport) Heartbeat(u *url.URL, term, commitIndex, leaderID uint64) (uint64, uint64, error) {
+	body, err := t.call(u, tcpHeartbeat, encodeUint64s(term, commitIndex, leaderID))
+
+	// The index & term are returned even if the heartbeat fails.
+	a, _, derr := decodeUint64s(body, 2)
+	if derr != nil {
+		if err == nil {
+			err = derr
+		}
+		return 0, 0, err
+	}
+	return a[0], a[1], err
+}
+
+// ReadFrom streams the log from a leader.
+// Errors returned by the leader are returned when reading from the stream.
+func (t *TCPTransport) ReadFrom(u *url.URL, id, term, index, lastLogTerm uint64) (io.ReadCloser, error) {
+	// Dial a separate connection so that the stream can block until it is
+	// read without delaying responses to other requests.
+	c, err := dialTCP(u, t.timeout())
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	if t.streams == nil {
+		t.streams = make(map[*tcpConn]struct{})
+	}
+	t.streams[c] = struct{}{}
+	t.mu.Unlock()
+
+	go c.readLoop(func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.streams, c)
+	})
+
+	// Register the stream before requesting it so no data is missed.
+	pr, pw := io.Pipe()
+	streamID, err := c.register(nil, pw)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.write(tcpStream, streamID, encodeUint64s(id, term, index, lastLogTerm)); err != nil {
+		c.close(err)
+		return nil, err
+	}
+
+	return &tcpStreamReader{PipeReader: pr, conn: c, id: streamID}, nil
+}
+
+// RequestVote requests a vote for a candidate in a given term.
+func (t *TCPTransport) RequestVote(u *url.URL, term, candidateID, lastLogIndex, lastLogTerm uint64) (uint64, error) {
+	body, err := t.call(u, tcpRequestVote, encodeUint64s(term, candidateID, lastLogIndex, lastLogTerm))
+
+	// The term is returned even if the vote is rejected.
+	a, _, derr := decodeUint64s(body, 1)
+	if derr != nil {
+		if err == nil {
+			err = derr
+		}
+		return 0, err
+	}
+	return a[0], err
+}
+
+// TimeoutNow requests a node to start an election immediately.
+func (t *TCPTransport) TimeoutNow(u *url.URL, term uint64) error {
+	_, err := t.call(u, tcpTimeoutNow, encodeUint64s(term))
+	return err
+}
+
+// call sends a request to a node and waits for the response body.
+func (t *TCPTransport) call(u *url.URL, typ byte, data []byte) ([]byte, error) {
+	c, err := t.conn(u)
+	if err != nil {
+		return nil, err
+	}
+	return c.call(typ, data)
+}
+
+// conn returns the connection to a node's host, dialing it if necessary.
+func (t *TCPTransport) conn(u *url.URL) (*tcpConn, error) {
+	t.mu.Lock()
+	c := t.conns[u.Host]
+	t.mu.Unlock()
+	if c != nil {
+		return c, nil
+	}
+
+	// Dial outside the lock so an unreachable node doesn't block other nodes.
+	c, err := dialTCP(u, t.timeout())
+	if err != nil {
+		return nil, err
+	}
+
+	// Use an existing connection if one was established while dialing.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if other := t.conns[u.Host]; other != nil {
+		c.close(nil)
+		return other, nil
+	}
+	if t.conns == nil {
+		t.conns = make(map[string]*tcpConn)
+	}
+	t.conns[u.Host] = c
+
+	// Remove the connection once it fails so the next request redials.
+	go c.readLoop(func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.conns[u.Host] == c {
+			delete(t.conns, u.Host)
+		}
+	})
+
+	return c, nil
+}
+
+// timeout returns the request timeout, or the default if it is not set.
+func (t *TCPTransport) timeout() time.Duration {
+	if t.Timeout == 0 {
+		return DefaultTCPTimeout
+	}
+	return t.Timeout
+}
+
+// Close closes all connections.
+func (t *TCPTransport) Close() error {
+	t.mu.Lock()
+	conns, streams := t.conns, t.streams
+	t.conns, t.streams = nil, nil
+	t.mu.Unlock()
+
+	for _, c := range conns {
+		c.close(nil)
+	}
+	for c := range streams {
+		c.close(nil)
+	}
+	return nil
+}
+
+// tcpConn represents a client connection to a node.
+type tcpConn struct {
+	conn    net.Conn
+	r       io.Reader
+	timeout time.Duration // time to wait for a response
+
+	wmu sync.Mutex // serializes frame writes
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan *tcpFrame // requests waiting on a response
+	streams map[uint64]*io.PipeWriter // open log streams
+	err     error                     // set once the connection fails
+}
+
+// dialTCP connects to a node and upgrades the connection to the raft protocol.
+func dialTCP(u *url.URL, timeout time.Duration) (*tcpConn, error) {
+	conn, err := net.DialTimeout("tcp", u.Host, timeout)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	// Request an upgrade from the node's raft handler.
+	if _, err := fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nConnection: Upgrade\r\nUpgrade: raft\r\n\r\n", path.Join("/", u.Path, "raft/tcp"), u.Host); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	// The node may begin sending frames immediately after the response.
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	} else if resp.StatusCode != http.StatusSwitchingProtocols {
+		_ = conn.Close()
+		if s := resp.Header.Get("X-Raft-Error"); s != "" {
+			return nil, errors.New(s)
+		}
+		return nil, fmt.Errorf("upgrade: %s", resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return &tcpConn{
+		conn:    conn,
+		r:       r,
+		timeout: timeout,
+		pending: make(map[uint64]chan *tcpFrame),
+		streams: make(map[uint64]*io.PipeWriter),
+	}, nil
+}
+
+// register assigns an id to a pending request or a stream.
+func (c *tcpConn) register(ch chan *tcpFrame, pw *io.PipeWriter) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	c.nextID++
+	if ch != nil {
+		c.pending[c.nextID] = ch
+	} else {
+		c.streams[c.nextID] = pw
+	}
+	return c.nextID, nil
+}
+
+// call sends a request and waits for its response.
+func (c *tcpConn) call(typ byte, data []byte) ([]byte, error) {
+	ch := make(chan *tcpFrame, 1)
+	id, err := c.register(ch, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.write(typ, id, data); err != nil {
+		c.close(err)
+		return nil, err
+	}
+
+	// The channel is closed without a response if the connection fails.
+	// A node that does not respond in time is treated as failed so the
+	// connection is closed and the next request redials it.
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case f, ok := <-ch:
+		if !ok {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return nil, c.err
+		}
+		return decodeTCPResponse(f.data)
+	case <-timer.C:
+		c.close(errRequestTimeout)
+		return nil, errRequestTimeout
+	}
+}
+
+// write sends a single frame to the node.
+func (c *tcpConn) write(typ byte, id uint64, data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return writeTCPFrame(c.conn, typ, id, data)
+}
+
+// readLoop dispatches frames from the node until the connection fails.
+func (c *tcpConn) readLoop(closed func()) {
+	defer closed()
+
+	for {
+		f, err := readTCPFrame(c.r)
+		if err != nil {
+			c.close(err)
+			return
+		}
+
+		switch f.typ {
+		case tcpResponse:
+			c.mu.Lock()
+			ch := c.pending[f.id]
+			delete(c.pending, f.id)
+			c.mu.Unlock()
+
+			if ch != nil {
+				ch <- f
+			}
+
+		case tcpStreamData:
+			c.mu.Lock()
+			pw := c.streams[f.id]
+			c.mu.Unlock()
+
+			// Blocks until the data is read, which only delays this stream
+			// since it has its own connection. Drop the data if the stream is closed.
+			if pw != nil {
+				_, _ = pw.Write(f.data)
+			}
+
+		case tcpStreamEnd:
+			if pw := c.removeStream(f.id); pw != nil {
+				if _, err := decodeTCPResponse(f.data); err != nil {
+					_ = pw.CloseWithError(err)
+				} else {
+					_ = pw.Close()
+				}
+			}
+		}
+	}
+}
+
+// removeStream removes a stream from the connection and returns its writer.
+func (c *tcpConn) removeStream(id uint64) *io.PipeWriter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pw := c.streams[id]
+	delete(c.streams, id)
+	return pw
+}
+
+// close closes the connection and fails all pending requests and streams.
+func (c *tcpConn) close(err error) {
+	if err == nil {
+		err = errors.New("connection closed")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	_ = c.conn.Close()
+
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	for id, pw := range c.streams {
+		_ = pw.CloseWithError(err)
+		delete(c.streams, id)
+	}
+}
+
+// tcpStreamReader represents the reading end of a log stream.
+type tcpStreamReader struct {
+	*io.PipeReader
+	conn *tcpConn
+	id   uint64
+}
+
+// Close notifies the node to stop writing to the stream and closes the
+// stream's connection.
+func (r *tcpStreamReader) Close() error {
+	if r.conn.removeStream(r.id) != nil {
+		_ = r.conn.write(tcpStreamCancel, r.id, nil)
+	}
+	r.conn.close(nil)
+	return r.PipeReader.Close()
+}
+
+// serveTCP upgrades the connection and serves raft requests over it until
+// the connection is closed.
+func (h *Handler) serveTCP(w http.ResponseWriter, r *http.Request) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		w.Header().Set("X-Raft-Error", "tcp upgrade not supported")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		w.Header().Set("X-Raft-Error", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Confirm the upgrade and begin serving frames.
+	_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: raft\r\n\r\n")
+	if err := buf.Flush(); err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	s := &tcpSession{
+		handler: h,
+		conn:    conn,
+		streams: make(map[uint64]*tcpStreamWriter),
+	}
+	s.serve(buf.Reader)
+}
+
+// tcpSession represents a connection accepted by the handler.
+type tcpSession struct {
+	handler *Handler
+	conn    net.Conn
+
+	wmu sync.Mutex // serializes frame writes
+
+	mu      sync.Mutex
+	streams map[uint64]*tcpStreamWriter
+}
+
+// serve reads requests until the connection is closed.
+// Each request is executed in a separate goroutine.
+func (s *tcpSession) serve(r io.Reader) {
+	defer s.close()
+
+	for {
+		f, err := readTCPFrame(r)
+		if err != nil {
+			return
+		}
+
+		switch f.typ {
+		case tcpStream:
+			w := &tcpStreamWriter{session: s, id: f.id}
+			s.mu.Lock()
+			s.streams[f.id] = w
+			s.mu.Unlock()
+			go s.stream(w, f)
+
+		case tcpStreamCancel:
+			if w := s.removeStream(f.id); w != nil {
+				w.cancel()
+			}
+
+		default:
+			go s.handle(f)
+		}
+	}
+}
+
+// handle executes a single request against the log and writes the response.
+func (s *tcpSession) handle(f *tcpFrame) {
+	var body []byte
+	var err error
+
+	switch f.typ {
+	case tcpJoin:
+		var u *url.URL
+		if u, err = url.Parse(string(f.data)); err != nil {
+			err = errors.New("invalid url")
+			break
+		}
+
+		var id uint64
+		var config *Config
+		if id, config, err = s.handler.Log.AddPeer(u); err != nil {
+			break
+		}
+		b, _ := json.Marshal(config)
+		body = append(encodeUint64s(id), b...)
+
+	case tcpLeave:
+		var a []uint64
+		if a, _, err = decodeUint64s(f.data, 1); err != nil {
+			break
+		}
+		err = s.handler.Log.RemovePeer(a[0])
+
+	case tcpHeartbeat:
+		var a []uint64
+		if a, _, err = decodeUint64s(f.data, 3); err != nil {
+			break
+		}
+		var currentIndex, currentTerm uint64
+		currentIndex, currentTerm, err = s.handler.Log.Heartbeat(a[0], a[1], a[2])
+		body = encodeUint64s(currentIndex, currentTerm)
+
+	case tcpRequestVote:
+		var a []uint64
+		if a, _, err = decodeUint64s(f.data, 4); err != nil {
+			break
+		}
+		var currentTerm uint64
+		currentTerm, err = s.handler.Log.RequestVote(a[0], a[1], a[2], a[3])
+		body = encodeUint64s(currentTerm)
+
+	case tcpTimeoutNow:
+		var a []uint64
+		if a, _, err = decodeUint64s(f.data, 1); err != nil {
+			break
+		}
+		err = s.handler.Log.TimeoutNow(a[0])
+
+	default:
+		err = fmt.Errorf("invalid frame type: %d", f.typ)
+	}
+
+	_ = s.write(tcpResponse, f.id, encodeTCPResponse(err, body))
+}
+
+// stream writes log entries to a stream until it is canceled or fails.
+func (s *tcpSession) stream(w *tcpStreamWriter, f *tcpFrame) {
+	a, _, err := decodeUint64s(f.data, 4)
+	if err == nil {
+		if err = s.handler.Log.WriteEntriesTo(w, a[0], a[1], a[2], a[3]); err == io.EOF {
+			err = nil
+		}
+	}
+
+	// Notify the client that the stream has ended, unless it closed it.
+	if s.removeStream(w.id) != nil {
+		w.Flush()
+		if err == nil {
+			w.mu.Lock()
+			err = w.err
+			w.mu.Unlock()
+		}
+		_ = s.write(tcpStreamEnd, w.id, encodeTCPResponse(err, nil))
+	}
+}
+
+// write sends a single frame to the client.
+func (s *tcpSession) write(typ byte, id uint64, data []byte) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	return writeTCPFrame(s.conn, typ, id, data)
+}
+
+// removeStream removes a stream from the session and returns it.
+func (s *tcpSession) removeStream(id uint64) *tcpStreamWriter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.streams[id]
+	delete(s.streams, id)
+	return w
+}
+
+// close closes the connection and cancels all open streams.
+func (s *tcpSession) close() {
+	_ = s.conn.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, w := range s.streams {
+		w.cancel()
+		delete(s.streams, id)
+	}
+}
+
+// tcpStreamWriter buffers log entries written to a stream and sends them as
+// data frames when flushed. Implements http.Flusher so that the log flushes
+// entries as they are written.
+type tcpStreamWriter struct {
+	session *tcpSession
+	id      uint64
+
+	mu  sync.Mutex
+	buf []byte
+	err error // set once the stream is canceled or a write fails
+}
+
+// Write buffers p and sends any full chunks to the client.
+func (w *tcpStreamWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.buf = append(w.buf, p...)
+	for len(w.buf) >= tcpStreamChunkSize {
+		if w.err = w.session.write(tcpStreamData, w.id, w.buf[:tcpStreamChunkSize]); w.err != nil {
+			return 0, w.err
+		}
+		w.buf = w.buf[tcpStreamChunkSize:]
+	}
+	return len(p), nil
+}
+
+// Flush sends all buffered data to the client.
+// An error is returned by the next write if the data cannot be sent.
+func (w *tcpStreamWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil && len(w.buf) > 0 {
+		w.err = w.session.write(tcpStreamData, w.id, w.buf)
+		w.buf = nil
+	}
+}
+
+// cancel causes all subsequent writes to fail.
+func (w *tcpStreamWriter) cancel() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.err = errStreamCanceled
+	w.buf = nil
+}
+
+// tcpFrame represents a single frame sent over a TCP connection.
+type tcpFrame struct {
+	typ  byte
+	id   uint64
+	data []byte
+}
+
+// writeTCPFrame writes a frame header and payload in a single write.
+func writeTCPFrame(w io.Writer, typ byte, id uint64, data []byte) error {
+	b := make([]byte, tcpFrameHeaderSize+len(data))
+	b[0] = typ
+	binary.BigEndian.PutUint64(b[1:9], id)
+	binary.BigEndian.PutUint32(b[9:13], uint32(len(data)))
+	copy(b[tcpFrameHeaderSize:], data)
+	_, err := w.Write(b)
+	return err
+}
+
+// readTCPFrame reads a single frame.
+func readTCPFrame(r io.Reader) (*tcpFrame, error) {
+	var hdr [tcpFrameHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(hdr[9:13])
+	if size > tcpMaxFrameSize {
+		return nil, fmt.Errorf("frame too large: %d", size)
+	}
+
+	f := &tcpFrame{typ: hdr[0], id: binary.BigEndian.Uint64(hdr[1:9]), data: make([]byte, size)}
+	if _, err := io.ReadFull(r, f.data); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// encodeTCPResponse encodes an error message followed by a response body.
+func encodeTCPResponse(err error, body []byte) []byte {
+	var msg string
+	if err != nil {
+		msg = err.Error()
+	}
+
+	b := make([]byte, 4, 4+len(msg)+len(body))
+	binary.BigEndian.PutUint32(b, uint32(len(msg)))
+	b = append(b, msg...)
+	return append(b, body...)
+}
+
+// decodeTCPResponse decodes a response into its body and error.
+func decodeTCPResponse(b []byte) ([]byte, error) {
+	if len(b) < 4 {
+		return nil, errors.New("invalid response")
+	}
+
+	n := binary.BigEndian.Uint32(b)
+	if uint32(len(b)-4) < n {
+		return nil, errors.New("invalid response")
+	}
+
+	body := b[4+n:]
+	if n > 0 {
+		return body, errors.New(string(b[4 : 4+n]))
+	}
+	return body, nil
+}
+
+// encodeUint64s encodes a list of integers in big endian order.
+func encodeUint64s(a ...uint64) []byte {
+	b := make([]byte, 8*len(a))
+	for i, v := range a {
+		binary.BigEndian.PutUint64(b[i*8:], v)
+	}
+	return b
+}
+
+// decodeUint64s decodes n integers and returns the remaining bytes.
+func decodeUint64s(b []byte, n int) ([]uint64, []byte, error) {
+	if len(b) < n*8 {
+		return nil, nil, errors.New("invalid frame")
+	}
+
+	a := make([]uint64, n)
+	for i := range a {
+		a[i] = binary.BigEndian.Uint64(b[i*8:])
+	}
+	return a, b[n*8:], nil
+}
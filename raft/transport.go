@@ -11,6 +11,16 @@ import (
 	"strconv"
 )
 
+// Transport represents a method of sending RPCs to other nodes in the cluster.
+type Transport interface {
+	Join(u *url.URL, nodeURL *url.URL) (uint64, *Config, error)
+	Leave(u *url.URL, id uint64) error
+	Heartbeat(u *url.URL, term, commitIndex, leaderID uint64) (lastIndex, currentTerm uint64, err error)
+	ReadFrom(u *url.URL, id, term, index, lastLogTerm uint64) (io.ReadCloser, error)
+	RequestVote(u *url.URL, term, candidateID, lastLogIndex, lastLogTerm uint64) (uint64, error)
+	TimeoutNow(u *url.URL, term uint64) error
+}
+
 // HTTPTransport represents a transport for sending RPCs over the HTTP protocol.
 type HTTPTransport struct{}
 
@@ -114,7 +124,7 @@ func (t *HTTPTransport) Heartbeat(uri *url.URL, term, commitIndex, leaderID uint
 }
 
 // ReadFrom streams the log from a leader.
-func (t *HTTPTransport) ReadFrom(uri *url.URL, id, term, index uint64) (io.ReadCloser, error) {
+func (t *HTTPTransport) ReadFrom(uri *url.URL, id, term, index, lastLogTerm uint64) (io.ReadCloser, error) {
 	// Construct URL.
 	u := *uri
 	u.Path = path.Join(u.Path, "raft/stream")
@@ -124,6 +134,8 @@ func (t *HTTPTransport) ReadFrom(uri *url.URL, id, term, index uint64) (io.ReadC
 	v.Set("id", strconv.FormatUint(id, 10))
 	v.Set("term", strconv.FormatUint(term, 10))
 	v.Set("index", strconv.FormatUint(index, 10))
+	v.Set("lastLogTerm", strconv.FormatUint(lastLogTerm, 10))
+	v.Set("format", strconv.Itoa(logEntryFormat))
 	u.RawQuery = v.Encode()
 
 	// Send HTTP request.
@@ -153,6 +165,7 @@ func (t *HTTPTransport) RequestVote(uri *url.URL, term, candidateID, lastLogInde
 	v.Set("candidateID", strconv.FormatUint(candidateID, 10))
 	v.Set("lastLogIndex", strconv.FormatUint(lastLogIndex, 10))
 	v.Set("lastLogTerm", strconv.FormatUint(lastLogTerm, 10))
+	v.Set("format", strconv.Itoa(logEntryFormat))
 	u.RawQuery = v.Encode()
 
 	// Send HTTP request.
@@ -176,3 +189,25 @@ func (t *HTTPTransport) RequestVote(uri *url.URL, term, candidateID, lastLogInde
 
 	return newTerm, nil
 }
+
+// TimeoutNow requests a node to start an election immediately.
+func (t *HTTPTransport) TimeoutNow(uri *url.URL, term uint64) error {
+	// Construct URL.
+	u := *uri
+	u.Path = path.Join(u.Path, "raft/timeout")
+	u.RawQuery = (&url.Values{"term": {strconv.FormatUint(term, 10)}}).Encode()
+
+	// Send HTTP request.
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	// Parse returned error.
+	if s := resp.Header.Get("X-Raft-Error"); s != "" {
+		return errors.New(s)
+	}
+
+	return nil
+}
@@ -237,13 +237,19 @@ func TestHTTPTransport_ReadFrom(t *testing.T) {
 		if index := r.FormValue("index"); index != `3` {
 			t.Fatalf("unexpected index: %q", index)
 		}
+		if lastLogTerm := r.FormValue("lastLogTerm"); lastLogTerm != `4` {
+			t.Fatalf("unexpected last log term: %q", lastLogTerm)
+		}
+		if format := r.FormValue("format"); format != `2` {
+			t.Fatalf("unexpected format: %q", format)
+		}
 		w.Write([]byte("test123"))
 	}))
 	defer s.Close()
 
 	// Execute stream against test server.
 	u, _ := url.Parse(s.URL)
-	r, err := (&raft.HTTPTransport{}).ReadFrom(u, 1, 2, 3)
+	r, err := (&raft.HTTPTransport{}).ReadFrom(u, 1, 2, 3, 4)
 	if err != nil {
 		t.Fatalf("unexpected error: %s", err)
 	}
@@ -263,7 +269,7 @@ func TestHTTPTransport_ReadFrom_Err(t *testing.T) {
 
 	// Execute stream against test server.
 	u, _ := url.Parse(s.URL)
-	r, err := (&raft.HTTPTransport{}).ReadFrom(u, 0, 0, 0)
+	r, err := (&raft.HTTPTransport{}).ReadFrom(u, 0, 0, 0, 0)
 	if err == nil {
 		t.Fatalf("expected error")
 	} else if err.Error() != `bad stream` {
@@ -276,7 +282,7 @@ func TestHTTPTransport_ReadFrom_Err(t *testing.T) {
 // Ensure an streaming over HTTP to a stopped server returns an error.
 func TestHTTPTransport_ReadFrom_ErrConnectionRefused(t *testing.T) {
 	u, _ := url.Parse("http://localhost:41932")
-	_, err := (&raft.HTTPTransport{}).ReadFrom(u, 0, 0, 0)
+	_, err := (&raft.HTTPTransport{}).ReadFrom(u, 0, 0, 0, 0)
 	if err == nil {
 		t.Fatal("expected error")
 	} else if !strings.Contains(err.Error(), `connection refused`) {
@@ -364,6 +370,382 @@ func TestHTTPTransport_RequestVote_ErrConnectionRefused(t *testing.T) {
 	}
 }
 
+// Ensure an election can be requested over HTTP.
+func TestHTTPTransport_TimeoutNow(t *testing.T) {
+	// Start mock HTTP server.
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path := r.URL.Path; path != `/raft/timeout` {
+			t.Fatalf("unexpected path: %q", path)
+		} else if term := r.FormValue("term"); term != `3` {
+			t.Fatalf("unexpected term: %q", term)
+		}
+	}))
+	defer s.Close()
+
+	// Execute request against test server.
+	u, _ := url.Parse(s.URL)
+	if err := (&raft.HTTPTransport{}).TimeoutNow(u, 3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure the errors returned from an election request are passed through.
+func TestHTTPTransport_TimeoutNow_Err(t *testing.T) {
+	// Start mock HTTP server.
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Raft-Error", "oh no")
+	}))
+	defer s.Close()
+
+	// Execute request against test server.
+	u, _ := url.Parse(s.URL)
+	if err := (&raft.HTTPTransport{}).TimeoutNow(u, 3); err == nil || err.Error() != `oh no` {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure a join over TCP can be read and responded to.
+func TestTCPTransport_Join(t *testing.T) {
+	h := NewHandler()
+	h.AddPeerFunc = func(u *url.URL) (uint64, *raft.Config, error) {
+		if u.String() != "http://local" {
+			t.Fatalf("unexpected url: %s", u)
+		}
+		return 2, &raft.Config{ClusterID: 100}, nil
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	// Execute join against test server.
+	tr := &raft.TCPTransport{}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	id, config, err := tr.Join(u, &url.URL{Scheme: "http", Host: "local"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if id != 2 {
+		t.Fatalf("unexpected id: %d", id)
+	} else if config == nil || config.ClusterID != 100 {
+		t.Fatalf("unexpected config: %#v", config)
+	}
+}
+
+// Ensure a join over TCP returns the log's error.
+func TestTCPTransport_Join_Err(t *testing.T) {
+	h := NewHandler()
+	h.AddPeerFunc = func(u *url.URL) (uint64, *raft.Config, error) {
+		return 0, nil, raft.ErrClosed
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	if _, _, err := tr.Join(u, &url.URL{Host: "local"}); err == nil || err.Error() != `log closed` {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure a leave over TCP can be read and responded to.
+func TestTCPTransport_Leave(t *testing.T) {
+	h := NewHandler()
+	h.RemovePeerFunc = func(id uint64) error {
+		if id != 1 {
+			t.Fatalf("unexpected id: %d", id)
+		}
+		return nil
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	if err := tr.Leave(u, 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure heartbeats over TCP share a connection and return the follower's index & term.
+func TestTCPTransport_Heartbeat(t *testing.T) {
+	h := NewHandler()
+	h.HeartbeatFunc = func(term, commitIndex, leaderID uint64) (uint64, uint64, error) {
+		if term != 1 {
+			t.Fatalf("unexpected term: %d", term)
+		} else if commitIndex != 2 {
+			t.Fatalf("unexpected commit index: %d", commitIndex)
+		} else if leaderID != 3 {
+			t.Fatalf("unexpected leader id: %d", leaderID)
+		}
+		return 4, 5, nil
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+
+	// Send concurrent heartbeats over the same connection.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			newIndex, newTerm, err := tr.Heartbeat(u, 1, 2, 3)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			} else if newIndex != 4 || newTerm != 5 {
+				t.Errorf("unexpected index/term: %d/%d", newIndex, newTerm)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Ensure a failed heartbeat over TCP still returns the follower's index & term.
+func TestTCPTransport_Heartbeat_Err(t *testing.T) {
+	h := NewHandler()
+	h.HeartbeatFunc = func(term, commitIndex, leaderID uint64) (uint64, uint64, error) {
+		return 4, 5, raft.ErrStaleTerm
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	newIndex, newTerm, err := tr.Heartbeat(u, 1, 2, 3)
+	if err == nil || err.Error() != `stale term` {
+		t.Fatalf("unexpected error: %s", err)
+	} else if newIndex != 4 || newTerm != 5 {
+		t.Fatalf("unexpected index/term: %d/%d", newIndex, newTerm)
+	}
+}
+
+// Ensure a TCP request to a node that does not respond returns an error.
+func TestTCPTransport_Heartbeat_Timeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	h := NewHandler()
+	h.HeartbeatFunc = func(term, commitIndex, leaderID uint64) (uint64, uint64, error) {
+		<-block
+		return 0, 0, nil
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{Timeout: 10 * time.Millisecond}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	if _, _, err := tr.Heartbeat(u, 1, 2, 3); err == nil || err.Error() != `request timeout` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a TCP heartbeat to a stopped server returns an error.
+func TestTCPTransport_Heartbeat_ErrConnectionRefused(t *testing.T) {
+	u, _ := url.Parse("http://localhost:41932")
+	_, _, err := (&raft.TCPTransport{}).Heartbeat(u, 0, 0, 0)
+	if err == nil {
+		t.Fatal("expected error")
+	} else if !strings.Contains(err.Error(), `connection refused`) {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure the log can be streamed over TCP.
+func TestTCPTransport_ReadFrom(t *testing.T) {
+	h := NewHandler()
+	h.WriteEntriesToFunc = func(w io.Writer, id, term, index, lastLogTerm uint64) error {
+		if id != 1 || term != 2 || index != 3 || lastLogTerm != 4 {
+			t.Fatalf("unexpected args: %d/%d/%d/%d", id, term, index, lastLogTerm)
+		}
+		w.Write([]byte("test"))
+		w.Write(bytes.Repeat([]byte("x"), 100000))
+		return nil
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	r, err := tr.ReadFrom(u, 1, 2, 3, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer r.Close()
+
+	if b, err := ioutil.ReadAll(r); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if len(b) != 100004 || string(b[:5]) != `testx` {
+		t.Fatalf("unexpected stream: %d bytes", len(b))
+	}
+}
+
+// Ensure a stream over TCP returns the log's error when read.
+func TestTCPTransport_ReadFrom_Err(t *testing.T) {
+	h := NewHandler()
+	h.WriteEntriesToFunc = func(w io.Writer, id, term, index, lastLogTerm uint64) error {
+		return raft.ErrNotLeader
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	r, err := tr.ReadFrom(u, 1, 2, 3, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer r.Close()
+
+	if _, err := ioutil.ReadAll(r); err == nil || err.Error() != `not leader` {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure closing a TCP stream stops the log from writing to it.
+func TestTCPTransport_ReadFrom_Close(t *testing.T) {
+	done := make(chan error)
+	h := NewHandler()
+	h.WriteEntriesToFunc = func(w io.Writer, id, term, index, lastLogTerm uint64) error {
+		for {
+			if _, err := w.Write([]byte("x")); err != nil {
+				done <- err
+				return err
+			}
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Millisecond)
+		}
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	r, err := tr.ReadFrom(u, 1, 2, 3, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Read some data and then close the stream.
+	if _, err := io.ReadFull(r, make([]byte, 2)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	r.Close()
+
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != `stream canceled` {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream not canceled")
+	}
+}
+
+// Ensure a stream over TCP that is not being read does not delay other requests.
+func TestTCPTransport_ReadFrom_Slow(t *testing.T) {
+	h := NewHandler()
+	h.WriteEntriesToFunc = func(w io.Writer, id, term, index, lastLogTerm uint64) error {
+		for {
+			if _, err := w.Write(bytes.Repeat([]byte("x"), 100000)); err != nil {
+				return err
+			}
+			w.(http.Flusher).Flush()
+		}
+	}
+	h.HeartbeatFunc = func(term, commitIndex, leaderID uint64) (uint64, uint64, error) {
+		return 4, 5, nil
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{Timeout: time.Second}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	r, err := tr.ReadFrom(u, 1, 2, 3, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer r.Close()
+
+	// Wait for the stream to fill up and then send a heartbeat.
+	time.Sleep(100 * time.Millisecond)
+	if newIndex, newTerm, err := tr.Heartbeat(u, 1, 2, 3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if newIndex != 4 || newTerm != 5 {
+		t.Fatalf("unexpected index/term: %d/%d", newIndex, newTerm)
+	}
+}
+
+// Ensure a vote request over TCP can be read and responded to.
+func TestTCPTransport_RequestVote(t *testing.T) {
+	h := NewHandler()
+	h.RequestVoteFunc = func(term, candidateID, lastLogIndex, lastLogTerm uint64) (uint64, error) {
+		if term != 1 || candidateID != 2 || lastLogIndex != 3 || lastLogTerm != 4 {
+			t.Fatalf("unexpected args: %d/%d/%d/%d", term, candidateID, lastLogIndex, lastLogTerm)
+		}
+		return 5, raft.ErrAlreadyVoted
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	term, err := tr.RequestVote(u, 1, 2, 3, 4)
+	if err == nil || err.Error() != `already voted` {
+		t.Fatalf("unexpected error: %s", err)
+	} else if term != 5 {
+		t.Fatalf("unexpected term: %d", term)
+	}
+}
+
+// Ensure an election can be requested over TCP.
+func TestTCPTransport_TimeoutNow(t *testing.T) {
+	h := NewHandler()
+	h.TimeoutNowFunc = func(term uint64) error {
+		if term != 3 {
+			t.Fatalf("unexpected term: %d", term)
+		}
+		return raft.ErrStaleTerm
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	if err := tr.TimeoutNow(u, 3); err == nil || err.Error() != `stale term` {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure a TCP transport redials a node after its connection is closed.
+func TestTCPTransport_Reconnect(t *testing.T) {
+	h := NewHandler()
+	h.RemovePeerFunc = func(id uint64) error { return nil }
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	tr := &raft.TCPTransport{}
+	defer tr.Close()
+	u, _ := url.Parse(s.URL)
+	if err := tr.Leave(u, 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tr.Close()
+	if err := tr.Leave(u, 1); err != nil {
+		t.Fatalf("unexpected error after reconnect: %s", err)
+	}
+}
+
 // Transport represents a test transport that directly calls another log.
 // Logs are looked up by hostname only.
 type Transport struct {
@@ -416,7 +798,7 @@ func (t *Transport) Heartbeat(u *url.URL, term, commitIndex, leaderID uint64) (l
 }
 
 // ReadFrom streams entries from the target log.
-func (t *Transport) ReadFrom(u *url.URL, id, term, index uint64) (io.ReadCloser, error) {
+func (t *Transport) ReadFrom(u *url.URL, id, term, index, lastLogTerm uint64) (io.ReadCloser, error) {
 	l, err := t.log(u)
 	if err != nil {
 		return nil, err
@@ -425,7 +807,7 @@ func (t *Transport) ReadFrom(u *url.URL, id, term, index uint64) (io.ReadCloser,
 	// Create a streaming buffer that will hang until Close() is called.
 	buf := newStreamingBuffer()
 	go func() {
-		if err := l.WriteEntriesTo(buf, id, term, index); err != nil {
+		if err := l.WriteEntriesTo(buf, id, term, index, lastLogTerm); err != nil {
 			warnf("Transport.ReadFrom: error: %s", err)
 		}
 		_ = buf.Close()
@@ -433,6 +815,15 @@ func (t *Transport) ReadFrom(u *url.URL, id, term, index uint64) (io.ReadCloser,
 	return buf, nil
 }
 
+// TimeoutNow calls TimeoutNow() on the target log.
+func (t *Transport) TimeoutNow(u *url.URL, term uint64) error {
+	l, err := t.log(u)
+	if err != nil {
+		return err
+	}
+	return l.TimeoutNow(term)
+}
+
 // RequestVote calls RequestVote() on the target log.
 func (t *Transport) RequestVote(u *url.URL, term, candidateID, lastLogIndex, lastLogTerm uint64) (uint64, error) {
 	l, err := t.log(u)
@@ -0,0 +1,345 @@
+package influxdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// RebalanceShards moves shard replicas so that every shard has as many owners
+// as its replication factor and every data node owns a similar number of shards.
+// Replicas owned by deleted data nodes are reassigned to the remaining nodes.
+//
+// Each move happens in three steps. The new owners are added alongside the
+// existing owners so they start receiving writes. The new owners then copy a
+// snapshot of the shard from an existing owner. Finally, the owners are cut
+// over and the previous owners delete their copy of the shard.
+func (s *Server) RebalanceShards() error {
+	s.mu.RLock()
+	moves := s.planShardRebalance()
+	s.mu.RUnlock()
+
+	for _, mv := range moves {
+		if err := s.moveShard(mv); err != nil {
+			return fmt.Errorf("move shard: id=%d, err=%s", mv.shardID, err)
+		}
+	}
+	return nil
+}
+
+// planShardRebalance returns the moves required to balance shard replicas
+// across the data nodes. Must be called with a lock.
+func (s *Server) planShardRebalance() []*shardMove {
+	// Sort nodes so every plan breaks ties the same way.
+	nodes := make([]*DataNode, 0, len(s.dataNodes))
+	for _, n := range s.dataNodes {
+		nodes = append(nodes, n)
+	}
+	sort.Sort(dataNodes(nodes))
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	// Determine the existing owners of every shard that still have a data node.
+	var moves shardMoves
+	counts := make(map[uint64]int)
+	for _, db := range s.databases {
+		for _, rp := range db.policies {
+			// Require at least one replica but no more replicas than nodes.
+			replicaN := int(rp.ReplicaN)
+			if replicaN == 0 {
+				replicaN = 1
+			} else if replicaN > len(nodes) {
+				replicaN = len(nodes)
+			}
+
+			for _, g := range rp.shardGroups {
+				for _, sh := range g.Shards {
+					mv := &shardMove{shardID: sh.ID, replicaN: replicaN, prev: sh.DataNodeIDs}
+					for _, id := range sh.DataNodeIDs {
+						if s.dataNodes[id] != nil {
+							mv.sources = append(mv.sources, id)
+							counts[id]++
+						}
+					}
+					mv.owners = append([]uint64{}, mv.sources...)
+					moves = append(moves, mv)
+				}
+			}
+		}
+	}
+	sort.Sort(moves)
+
+	// Drop replicas above the replication factor from the most loaded nodes.
+	for _, mv := range moves {
+		for len(mv.owners) > mv.replicaN {
+			i := 0
+			for j, id := range mv.owners {
+				if counts[id] > counts[mv.owners[i]] || (counts[id] == counts[mv.owners[i]] && id > mv.owners[i]) {
+					i = j
+				}
+			}
+			counts[mv.owners[i]]--
+			mv.owners = append(mv.owners[:i], mv.owners[i+1:]...)
+		}
+	}
+
+	// Assign missing replicas to the least loaded nodes.
+	for _, mv := range moves {
+		for len(mv.owners) < mv.replicaN {
+			var owner *DataNode
+			for _, n := range nodes {
+				if !mv.hasOwner(n.ID) && (owner == nil || counts[n.ID] < counts[owner.ID]) {
+					owner = n
+				}
+			}
+			mv.owners = append(mv.owners, owner.ID)
+			counts[owner.ID]++
+		}
+	}
+
+	// Move replicas from the most loaded node to the least loaded node until
+	// no node owns more than one shard above any other node. The most loaded
+	// node always owns a shard that the least loaded node does not.
+	for {
+		min, max := nodes[0], nodes[0]
+		for _, n := range nodes {
+			if counts[n.ID] < counts[min.ID] {
+				min = n
+			}
+			if counts[n.ID] > counts[max.ID] {
+				max = n
+			}
+		}
+		if counts[max.ID]-counts[min.ID] <= 1 {
+			break
+		}
+
+		for _, mv := range moves {
+			if mv.hasOwner(max.ID) && !mv.hasOwner(min.ID) {
+				for i, id := range mv.owners {
+					if id == max.ID {
+						mv.owners[i] = min.ID
+					}
+				}
+				counts[max.ID]--
+				counts[min.ID]++
+				break
+			}
+		}
+	}
+
+	// Only return shards whose owners have changed.
+	var a []*shardMove
+	for _, mv := range moves {
+		if len(mv.added()) > 0 || len(mv.owners) != len(mv.prev) {
+			a = append(a, mv)
+		}
+	}
+	return a
+}
+
+// shardMove represents a change to the owners of a shard.
+type shardMove struct {
+	shardID  uint64
+	replicaN int
+	prev     []uint64 // owners before the move
+	sources  []uint64 // previous owners that still exist
+	owners   []uint64 // owners after the move
+}
+
+// hasOwner returns true if the data node is an owner after the move.
+func (mv *shardMove) hasOwner(id uint64) bool {
+	for _, owner := range mv.owners {
+		if owner == id {
+			return true
+		}
+	}
+	return false
+}
+
+// added returns the owners that did not own the shard before the move.
+func (mv *shardMove) added() (a []uint64) {
+	prev := &Shard{DataNodeIDs: mv.prev}
+	for _, id := range mv.owners {
+		if !prev.HasDataNodeID(id) {
+			a = append(a, id)
+		}
+	}
+	return
+}
+
+// shardMoves represents a list of moves sortable by shard id.
+type shardMoves []*shardMove
+
+func (a shardMoves) Len() int           { return len(a) }
+func (a shardMoves) Less(i, j int) bool { return a[i].shardID < a[j].shardID }
+func (a shardMoves) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// moveShard adds the new owners of a shard, copies the shard to them and then
+// removes the previous owners.
+func (s *Server) moveShard(mv *shardMove) error {
+	if added := mv.added(); len(added) > 0 {
+		// The new owners would start empty if no existing owner can be copied.
+		if len(mv.sources) == 0 {
+			return ErrNoShardSource
+		}
+
+		// Keep the previous owners so that no writes are missed during the copy.
+		c := &updateShardOwnersCommand{ID: mv.shardID, DataNodeIDs: append(append([]uint64{}, mv.prev...), added...)}
+		index, err := s.broadcast(updateShardOwnersMessageType, c)
+		if err != nil {
+			return err
+		}
+
+		// Copy the shard to each new owner.
+		for _, id := range added {
+			if err := s.replicateShardOn(id, mv.shardID, mv.sources, index); err != nil {
+				// Restore the previous owners so the incomplete copies are removed.
+				_, _ = s.broadcast(updateShardOwnersMessageType, &updateShardOwnersCommand{ID: mv.shardID, DataNodeIDs: mv.prev})
+				return err
+			}
+		}
+	}
+
+	// Cut over to the new owners.
+	_, err := s.broadcast(updateShardOwnersMessageType, &updateShardOwnersCommand{ID: mv.shardID, DataNodeIDs: mv.owners})
+	return err
+}
+
+// replicateShardOn requests a data node to copy a shard from one of the source data nodes.
+func (s *Server) replicateShardOn(nodeID, shardID uint64, sourceIDs []uint64, index uint64) error {
+	if nodeID == s.ID() {
+		return s.ReplicateShard(shardID, sourceIDs, index)
+	}
+
+	n := s.DataNode(nodeID)
+	if n == nil {
+		return ErrDataNodeNotFound
+	}
+
+	// Build the request to the data node.
+	u := copyURL(n.URL)
+	u.Path = fmt.Sprintf("/shards/%d/replicate", shardID)
+	values := url.Values{"index": {strconv.FormatUint(index, 10)}}
+	for _, id := range sourceIDs {
+		values.Add("source", strconv.FormatUint(id, 10))
+	}
+	u.RawQuery = values.Encode()
+
+	resp, err := http.Post(u.String(), "application/octet-stream", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("replicate shard: node=%d, status=%d, %s", nodeID, resp.StatusCode, bytes.TrimSpace(b))
+	}
+	return nil
+}
+
+// ReplicateShard copies a shard from the first available source data node and
+// merges it into the local copy of the shard. Points already written locally
+// are kept. The index is the broker index of the command that assigned the
+// shard to this server.
+func (s *Server) ReplicateShard(id uint64, sourceIDs []uint64, index uint64) error {
+	// Wait until the local copy of the shard has been opened.
+	if err := s.Sync(index); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	sh := s.shards[id]
+	var urls []*url.URL
+	for _, sourceID := range sourceIDs {
+		if n := s.dataNodes[sourceID]; n != nil && n.ID != s.id {
+			urls = append(urls, n.URL)
+		}
+	}
+	s.mu.RUnlock()
+
+	if sh == nil || sh.store == nil {
+		return ErrShardNotFound
+	}
+
+	err := fmt.Errorf("no data node available for shard: %d", id)
+	for _, u := range urls {
+		if err = s.replicateShardFrom(sh, u, index); err == nil {
+			break
+		}
+		log.Printf("unable to replicate shard: id=%d, url=%s, err=%s", id, u, err)
+	}
+	return err
+}
+
+// replicateShardFrom streams a snapshot of a shard from a data node to a
+// temporary file and merges it into the local shard.
+func (s *Server) replicateShardFrom(sh *Shard, u *url.URL, index uint64) error {
+	u = copyURL(u)
+	u.Path = fmt.Sprintf("/shards/%d", sh.ID)
+	u.RawQuery = url.Values{"index": {strconv.FormatUint(index, 10)}}.Encode()
+
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("status=%d, %s", resp.StatusCode, bytes.TrimSpace(b))
+	}
+
+	// Write the snapshot next to the shard.
+	f, err := ioutil.TempFile(filepath.Dir(sh.store.Path()), filepath.Base(sh.store.Path())+".")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		return err
+	} else if err := f.Close(); err != nil {
+		return err
+	}
+
+	// Merge the snapshot and add its series to the lookup.
+	ids, err := sh.merge(f.Name())
+	for _, seriesID := range ids {
+		s.addShardBySeriesID(sh, seriesID)
+	}
+	return err
+}
+
+// CopyShard writes a snapshot of a local shard to a writer.
+func (s *Server) CopyShard(w io.Writer, id uint64) error {
+	sh := s.Shard(id)
+	if sh == nil {
+		return ErrShardNotFound
+	}
+
+	tx, err := sh.beginRead()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = sh.endRead(tx) }()
+
+	// Set content length if this is a HTTP connection.
+	if w, ok := w.(http.ResponseWriter); ok {
+		w.Header().Set("Content-Length", strconv.Itoa(int(tx.Size())))
+	}
+
+	// Write entire shard to the writer.
+	return tx.Copy(w)
+}
@@ -0,0 +1,216 @@
+package influxdb
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+const (
+	// DefaultRemoteMapperDialTimeout is how long a data node waits to connect
+	// to the owner of a shard when requesting a remote mapper.
+	DefaultRemoteMapperDialTimeout = 5 * time.Second
+
+	// DefaultRemoteMapperResponseTimeout is how long a data node waits for the
+	// owner of a shard to open the shard and begin responding to a remote mapper.
+	DefaultRemoteMapperResponseTimeout = 30 * time.Second
+)
+
+// remoteMapperClient is the HTTP client used to request remote mappers. Only
+// connecting and the response header are timed out since the output of a
+// mapper is streamed for as long as the map function runs.
+var remoteMapperClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		Dial:                  (&net.Dialer{Timeout: DefaultRemoteMapperDialTimeout}).Dial,
+		ResponseHeaderTimeout: DefaultRemoteMapperResponseTimeout,
+	},
+}
+
+// RemoteMapper represents a request for the data node that owns a shard to
+// map the shard's data on behalf of the node coordinating a query.
+type RemoteMapper struct {
+	ShardID     uint64                   // shard to read from
+	Measurement string                   // fully qualified measurement name
+	Fields      []string                 // selected field or tag names
+	Call        *influxql.Call           // aggregate call, nil for raw queries
+	Tags        string                   // encoded dimensional tag values
+	SeriesIDs   []uint32                 // series to read from the shard
+	Filters     map[uint32]influxql.Expr // field conditions by series id
+	TMin, TMax  int64                    // time range, in nanoseconds
+	Interval    int64                    // group by interval, in nanoseconds
+	SkipEmpty   bool                     // do not map intervals without data
+	Descending  bool                     // read points in reverse time order
+	Limit       int                      // maximum points to read, if non-zero
+}
+
+// mapperOutput represents a single value streamed back by a remote mapper.
+// The stream ends with an output marked as done so that truncated responses
+// can be detected, or with an error if the shard could not be read.
+type mapperOutput struct {
+	Key   influxql.Key
+	Value interface{}
+	Done  bool
+	Err   string
+}
+
+// RunMapper maps the data of a local shard for a remote mapper request and
+// streams the output to w. An error is only returned before any output is
+// written, unless writing to w fails.
+func (s *Server) RunMapper(w io.Writer, m *RemoteMapper) error {
+	mapFn, err := influxql.InitializeMapFunc(m.Call)
+	if err != nil {
+		return err
+	}
+
+	itr, err := s.remoteMapperIterator(m)
+	if err != nil {
+		return err
+	}
+	if err := itr.open(); err != nil {
+		return err
+	}
+	defer func() { _ = itr.close() }()
+
+	// Send the response header before mapping so the caller does not time out
+	// while waiting for the first output.
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	mapper := influxql.NewMapper(mapFn, itr, time.Duration(m.Interval))
+	mapper.SkipEmpty = m.SkipEmpty
+
+	// Stream the output. Keep reading after a write error so the mapper can finish.
+	enc := gob.NewEncoder(w)
+	for out := range mapper.Map().C() {
+		for k, v := range out {
+			if err == nil {
+				err = enc.Encode(&mapperOutput{Key: k, Value: v})
+			}
+		}
+		if f, ok := w.(http.Flusher); ok && err == nil {
+			f.Flush()
+		}
+	}
+	if err != nil {
+		return err
+	} else if err := mapper.Err(); err != nil {
+		return enc.Encode(&mapperOutput{Err: err.Error()})
+	}
+	return enc.Encode(&mapperOutput{Done: true})
+}
+
+// remoteMapperIterator returns an iterator over the series of a local shard
+// requested by a remote mapper.
+func (s *Server) remoteMapperIterator(m *RemoteMapper) (*shardIterator, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sh := s.shards[m.ShardID]
+	if sh == nil || sh.store == nil {
+		return nil, ErrShardNotFound
+	}
+
+	database, _, measurement, err := splitIdent(m.Measurement)
+	if err != nil {
+		return nil, err
+	}
+	mm, err := s.measurement(database, measurement)
+	if err != nil {
+		return nil, err
+	} else if mm == nil {
+		return nil, ErrMeasurementNotFound
+	}
+
+	f, columns, err := mm.selectColumns(m.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ignore series that no longer exist.
+	set := make(map[uint32]influxql.Expr, len(m.SeriesIDs))
+	for _, id := range m.SeriesIDs {
+		if mm.seriesByID[id] != nil {
+			set[id] = m.Filters[id]
+		}
+	}
+
+	return newShardIterator(mm, f, columns, sh, m.Tags, set, m.TMin, m.TMax, m.Descending, m.Limit), nil
+}
+
+// remoteIterator represents an iterator over a shard owned by other data nodes.
+// Its data is never read locally. Instead, one of the owners executes the map
+// function and streams back the output.
+type remoteIterator struct {
+	tags   string
+	urls   []*url.URL // owner URLs, in order of preference
+	mapper RemoteMapper
+}
+
+// Tags returns the encoded dimensional tag values.
+func (i *remoteIterator) Tags() string { return i.tags }
+
+// Next always returns no data since remote data is mapped by its owner.
+func (i *remoteIterator) Next() (key int64, data []byte, value interface{}) { return 0, nil, nil }
+
+// MapRemote requests an owner of the shard to map its data and sends the output
+// to the emitter. Other owners are tried if an owner cannot be reached.
+func (i *remoteIterator) MapRemote(call *influxql.Call, interval int64, skipEmpty bool, e *influxql.Emitter) error {
+	m := i.mapper
+	m.Call, m.Interval, m.SkipEmpty = call, interval, skipEmpty
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&m); err != nil {
+		return err
+	}
+
+	err := fmt.Errorf("no data node available for shard: %d", m.ShardID)
+	for _, u := range i.urls {
+		var emitted bool
+		if emitted, err = i.mapRemote(u, buf.Bytes(), e); err == nil || emitted {
+			break
+		}
+	}
+	return err
+}
+
+// mapRemote sends a mapper request to a single data node. Returns true if any
+// output was emitted before an error occurred.
+func (i *remoteIterator) mapRemote(u *url.URL, body []byte, e *influxql.Emitter) (bool, error) {
+	u = copyURL(u)
+	u.Path = "/run_mapper"
+
+	resp, err := remoteMapperClient.Post(u.String(), "application/octet-stream", bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return false, fmt.Errorf("remote mapper: shard=%d, status=%d, %s", i.mapper.ShardID, resp.StatusCode, bytes.TrimSpace(b))
+	}
+
+	// Read output until the end of the stream is marked.
+	dec := gob.NewDecoder(resp.Body)
+	for emitted := false; ; emitted = true {
+		var out mapperOutput
+		if err := dec.Decode(&out); err != nil {
+			return emitted, fmt.Errorf("remote mapper: shard=%d, %s", i.mapper.ShardID, err)
+		} else if out.Err != "" {
+			return emitted, fmt.Errorf("remote mapper: shard=%d, %s", i.mapper.ShardID, out.Err)
+		} else if out.Done {
+			return emitted, nil
+		}
+		e.Emit(out.Key, out.Value)
+	}
+}
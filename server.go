@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
@@ -18,6 +19,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/boltdb/bolt"
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/messaging"
 	"golang.org/x/crypto/bcrypt"
@@ -67,18 +69,23 @@ const (
 	// Shard messages
 	createShardGroupIfNotExistsMessageType = messaging.MessageType(0x40)
 	deleteShardGroupMessageType            = messaging.MessageType(0x41)
+	updateShardOwnersMessageType           = messaging.MessageType(0x42)
 
 	// Series messages
 	createSeriesIfNotExistsMessageType = messaging.MessageType(0x50)
+	dropSeriesMessageType              = messaging.MessageType(0x51)
+	deleteSeriesRangeMessageType       = messaging.MessageType(0x52)
 
 	// Measurement messages
 	createFieldsIfNotExistsMessageType = messaging.MessageType(0x60)
+	dropMeasurementMessageType         = messaging.MessageType(0x61)
 
 	// Continuous Query messages
 	createContinuousQueryMessageType = messaging.MessageType(0x70)
 
 	// Write series data messages (per-topic)
-	writeRawSeriesMessageType = messaging.MessageType(0x80)
+	writeRawSeriesMessageType      = messaging.MessageType(0x80)
+	writeRawSeriesBatchMessageType = messaging.MessageType(0x81)
 
 	// Privilege messages
 	setPrivilegeMessageType = messaging.MessageType(0x90)
@@ -91,10 +98,12 @@ type Server struct {
 	path   string
 	done   chan struct{} // goroutine close notification
 	rpDone chan struct{} // retention policies goroutine close notification
+	hbDone chan struct{} // heartbeat goroutine close notification
 
-	client MessagingClient  // broker client
-	index  uint64           // highest broadcast index seen
-	errors map[uint64]error // message errors
+	client       MessagingClient   // broker client
+	index        uint64            // highest broadcast index seen
+	topicIndexes map[uint64]uint64 // highest index applied by topic id
+	errors       map[uint64]error  // message errors
 
 	meta *metastore // metadata store
 
@@ -126,8 +135,9 @@ type Server struct {
 // NewServer returns a new instance of Server.
 func NewServer() *Server {
 	s := Server{
-		meta:      &metastore{},
-		errors:    make(map[uint64]error),
+		meta:         &metastore{},
+		topicIndexes: make(map[uint64]uint64),
+		errors:       make(map[uint64]error),
 		dataNodes: make(map[uint64]*DataNode),
 		databases: make(map[string]*database),
 		users:     make(map[string]*User),
@@ -243,6 +253,10 @@ func (s *Server) Close() error {
 	if s.rpDone != nil {
 		close(s.rpDone)
 	}
+	if s.hbDone != nil {
+		close(s.hbDone)
+		s.hbDone = nil
+	}
 
 	// Remove path.
 	s.path = ""
@@ -289,14 +303,29 @@ func (s *Server) load() error {
 			}
 		}
 
-		// Open all shards.
+		// Open all shards assigned to this server and add them to the lookup.
+		// Shards owned by other data nodes are mapped remotely.
+		s.shards = make(map[uint64]*Shard)
 		for _, db := range s.databases {
 			for _, rp := range db.policies {
 				for _, g := range rp.shardGroups {
 					for _, sh := range g.Shards {
+						sh.database = db.name
+						s.shards[sh.ID] = sh
+
+						if !sh.HasDataNodeID(s.id) {
+							continue
+						}
 						if err := sh.open(s.shardPath(sh.ID)); err != nil {
 							return fmt.Errorf("cannot open shard store: id=%d, err=%s", sh.ID, err)
 						}
+
+						// Convert shards written in an older format.
+						if err := sh.migrate(func(seriesID uint32) *FieldCodec {
+							return s.fieldCodecBySeriesID(sh.database, seriesID)
+						}); err != nil {
+							return fmt.Errorf("cannot migrate shard store: id=%d, err=%s", sh.ID, err)
+						}
 					}
 				}
 			}
@@ -353,6 +382,64 @@ func (s *Server) EnforceRetentionPolicies() {
 	}
 }
 
+// StartHeartbeats launches a goroutine that periodically reports the index
+// applied for each topic back to the broker.
+func (s *Server) StartHeartbeats(interval time.Duration) error {
+	if interval == 0 {
+		return fmt.Errorf("heartbeat interval must be non-zero")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hbDone != nil {
+		close(s.hbDone)
+	}
+	hbDone := make(chan struct{}, 0)
+	s.hbDone = hbDone
+	go func() {
+		for {
+			select {
+			case <-hbDone:
+				return
+			case <-time.After(interval):
+				if err := s.Heartbeat(); err != nil {
+					log.Printf("heartbeat: %s", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Heartbeat sends the highest index applied for each topic to the broker.
+// The broker uses the indexes to track replication lag and to discard
+// messages that every replica has applied.
+func (s *Server) Heartbeat() error {
+	s.mu.RLock()
+	id, client := s.id, s.client
+	indexes := make(map[uint64]uint64, len(s.topicIndexes))
+	for topicID, index := range s.topicIndexes {
+		indexes[topicID] = index
+	}
+	s.mu.RUnlock()
+
+	if client == nil {
+		return ErrServerClosed
+	} else if id == 0 || len(indexes) == 0 {
+		return nil
+	}
+	return client.Heartbeat(id, indexes)
+}
+
+// ReplicaStatus returns the replication status of every replica on the broker.
+func (s *Server) ReplicaStatus() ([]*messaging.ReplicaStatus, error) {
+	client := s.Client()
+	if client == nil {
+		return nil, ErrServerClosed
+	}
+	return client.ReplicaStatus()
+}
+
 // Client retrieves the current messaging client.
 func (s *Server) Client() MessagingClient {
 	s.mu.RLock()
@@ -881,6 +968,7 @@ func (s *Server) applyCreateShardGroupIfNotExists(m *messaging.Message) (err err
 	g.Shards = make([]*Shard, shardN)
 	for i := range g.Shards {
 		g.Shards[i] = newShard()
+		g.Shards[i].database = db.name
 	}
 
 	// Persist to metastore if a shard was created.
@@ -893,14 +981,20 @@ func (s *Server) applyCreateShardGroupIfNotExists(m *messaging.Message) (err err
 			sh.ID = tx.nextShardID()
 		}
 
-		// Assign data nodes to shards via round robin.
-		// Start from a repeatably "random" place in the node list.
-		nodeIndex := int(m.Index % uint64(len(nodes)))
+		// Assign each shard to the distinct data nodes owning the fewest shards
+		// so replicas are balanced across the cluster. Ties are broken by the
+		// lowest node id so every server assigns the same owners.
+		counts := s.shardCountsByDataNode()
 		for _, sh := range g.Shards {
-			for i := 0; i < replicaN; i++ {
-				node := nodes[nodeIndex%len(nodes)]
-				sh.DataNodeIDs = append(sh.DataNodeIDs, node.ID)
-				nodeIndex++
+			for len(sh.DataNodeIDs) < replicaN {
+				var owner *DataNode
+				for _, n := range nodes {
+					if !sh.HasDataNodeID(n.ID) && (owner == nil || counts[n.ID] < counts[owner.ID]) {
+						owner = n
+					}
+				}
+				sh.DataNodeIDs = append(sh.DataNodeIDs, owner.ID)
+				counts[owner.ID]++
 			}
 		}
 
@@ -949,6 +1043,23 @@ func (s *Server) applyCreateShardGroupIfNotExists(m *messaging.Message) (err err
 	return
 }
 
+// shardCountsByDataNode returns the number of shards owned by each data node.
+func (s *Server) shardCountsByDataNode() map[uint64]int {
+	counts := make(map[uint64]int)
+	for _, db := range s.databases {
+		for _, rp := range db.policies {
+			for _, g := range rp.shardGroups {
+				for _, sh := range g.Shards {
+					for _, id := range sh.DataNodeIDs {
+						counts[id]++
+					}
+				}
+			}
+		}
+	}
+	return counts
+}
+
 type createShardGroupIfNotExistsCommand struct {
 	Database  string    `json:"database"`
 	Policy    string    `json:"policy"`
@@ -1018,6 +1129,77 @@ type deleteShardGroupCommand struct {
 	ID       uint64 `json:"id"`
 }
 
+// applyUpdateShardOwners changes the data nodes that own a shard. A server that
+// becomes an owner opens the shard and subscribes to it. A server that is no
+// longer an owner unsubscribes and deletes its copy of the shard.
+func (s *Server) applyUpdateShardOwners(m *messaging.Message) (err error) {
+	var c updateShardOwnersCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// A removed local copy is deleted after the lock is released so that
+	// queries still reading from the shard can finish without blocking others.
+	var sh *Shard
+	var removed *bolt.DB
+	defer func() {
+		if removed != nil {
+			if err := sh.drop(removed); err != nil {
+				log.Printf("error deleting shard %d: %s", sh.ID, err)
+			}
+		}
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Retrieve shard and its database.
+	sh = s.shards[c.ID]
+	if sh == nil {
+		return ErrShardNotFound
+	}
+	db := s.databases[sh.database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	}
+
+	// Update the owners in the metastore.
+	wasOwner := sh.HasDataNodeID(s.id)
+	prev := sh.DataNodeIDs
+	sh.DataNodeIDs = c.DataNodeIDs
+	if err = s.meta.mustUpdate(func(tx *metatx) error {
+		return tx.saveDatabase(db)
+	}); err != nil {
+		sh.DataNodeIDs = prev
+		return
+	}
+
+	// Open or remove the local copy of the shard.
+	// TODO: Move subscription outside of command processing.
+	isOwner := sh.HasDataNodeID(s.id)
+	if isOwner && !wasOwner {
+		// Open shard store. Panic if an error occurs and we can retry.
+		if err := sh.open(s.shardPath(sh.ID)); err != nil {
+			panic("unable to open shard: " + err.Error())
+		}
+
+		if err := s.client.Subscribe(s.id, sh.ID); err != nil {
+			log.Printf("unable to subscribe: replica=%d, topic=%d, err=%s", s.id, sh.ID, err)
+		}
+	} else if wasOwner && !isOwner {
+		if err := s.client.Unsubscribe(s.id, sh.ID); err != nil {
+			log.Printf("unable to unsubscribe: replica=%d, topic=%d, err=%s", s.id, sh.ID, err)
+		}
+
+		removed = sh.detach()
+	}
+
+	return
+}
+
+type updateShardOwnersCommand struct {
+	ID          uint64   `json:"id"`
+	DataNodeIDs []uint64 `json:"nodeIDs"`
+}
+
 // User returns a user by username
 // Returns nil if the user does not exist.
 func (s *Server) User(name string) *User {
@@ -1585,6 +1767,184 @@ type createSeriesIfNotExistsCommand struct {
 	Tags     map[string]string `json:"tags"`
 }
 
+// DropSeries deletes a set of series from a database.
+func (s *Server) DropSeries(database string, seriesIDs []uint32) error {
+	c := &dropSeriesCommand{Database: database, SeriesIDs: seriesIDs}
+	_, err := s.broadcast(dropSeriesMessageType, c)
+	return err
+}
+
+// applyDropSeries removes series from the metastore, the index and all local shards.
+func (s *Server) applyDropSeries(m *messaging.Message) error {
+	var c dropSeriesCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate command.
+	db := s.databases[c.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	}
+
+	// Remove from metastore.
+	if err := s.meta.mustUpdate(func(tx *metatx) error {
+		for _, id := range c.SeriesIDs {
+			series := db.series[id]
+			if series == nil {
+				continue
+			}
+			if err := tx.dropSeries(db.name, series.measurement.Name, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// Delete series data from shards on this server.
+	for _, rp := range db.policies {
+		for _, g := range rp.shardGroups {
+			for _, sh := range g.Shards {
+				if sh.store == nil {
+					continue
+				}
+				if err := sh.deleteSeries(c.SeriesIDs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Remove from the in memory index.
+	for _, id := range c.SeriesIDs {
+		db.DropSeries(id)
+		delete(s.shardsBySeriesID, id)
+	}
+
+	return nil
+}
+
+type dropSeriesCommand struct {
+	Database  string   `json:"database"`
+	SeriesIDs []uint32 `json:"seriesIds"`
+}
+
+// DropMeasurement removes a measurement, its series and all of their data from a database.
+func (s *Server) DropMeasurement(database, name string) error {
+	c := &dropMeasurementCommand{Database: database, Name: name}
+	_, err := s.broadcast(dropMeasurementMessageType, c)
+	return err
+}
+
+// applyDropMeasurement removes a measurement from the metastore, the index and all local shards.
+func (s *Server) applyDropMeasurement(m *messaging.Message) error {
+	var c dropMeasurementCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate command.
+	db := s.databases[c.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	}
+	mm := db.measurements[c.Name]
+	if mm == nil {
+		return ErrMeasurementNotFound
+	}
+
+	// Remove from metastore.
+	if err := s.meta.mustUpdate(func(tx *metatx) error {
+		return tx.dropMeasurement(db.name, c.Name)
+	}); err != nil {
+		return err
+	}
+
+	// Delete series data from shards on this server.
+	ids := make([]uint32, 0, len(mm.seriesByID))
+	for id := range mm.seriesByID {
+		ids = append(ids, id)
+	}
+	for _, rp := range db.policies {
+		for _, g := range rp.shardGroups {
+			for _, sh := range g.Shards {
+				if sh.store == nil {
+					continue
+				}
+				if err := sh.deleteSeries(ids); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Remove from the in memory index.
+	db.DropMeasurement(c.Name)
+	for _, id := range ids {
+		delete(s.shardsBySeriesID, id)
+	}
+
+	return nil
+}
+
+type dropMeasurementCommand struct {
+	Database string `json:"database"`
+	Name     string `json:"name"`
+}
+
+// DeleteSeriesRange deletes all data between min and max, inclusive, for a set of series.
+func (s *Server) DeleteSeriesRange(database string, seriesIDs []uint32, min, max time.Time) error {
+	c := &deleteSeriesRangeCommand{Database: database, SeriesIDs: seriesIDs, Min: min.UnixNano(), Max: max.UnixNano()}
+	_, err := s.broadcast(deleteSeriesRangeMessageType, c)
+	return err
+}
+
+// applyDeleteSeriesRange removes a time range of series data from all local shards.
+func (s *Server) applyDeleteSeriesRange(m *messaging.Message) error {
+	var c deleteSeriesRangeCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate command.
+	db := s.databases[c.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	}
+
+	// Delete series data from shards on this server that overlap the range.
+	min, max := time.Unix(0, c.Min), time.Unix(0, c.Max)
+	for _, rp := range db.policies {
+		for _, g := range rp.shardGroups {
+			if g.StartTime.After(max) || !g.EndTime.After(min) {
+				continue
+			}
+			for _, sh := range g.Shards {
+				if sh.store == nil {
+					continue
+				}
+				if err := sh.deleteSeriesRange(c.SeriesIDs, c.Min, c.Max); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+type deleteSeriesRangeCommand struct {
+	Database  string   `json:"database"`
+	SeriesIDs []uint32 `json:"seriesIds"`
+	Min       int64    `json:"min"`
+	Max       int64    `json:"max"`
+}
+
 // Point defines the values that will be written to the database
 type Point struct {
 	Name      string
@@ -1593,9 +1953,22 @@ type Point struct {
 	Values    map[string]interface{}
 }
 
-// WriteSeries writes series data to the database.
+// WriteSeries writes series data to the database. Points are validated before
+// anything is written. If any point is invalid then no points are written and
+// a *WriteError listing the rejected points is returned.
 // Returns the messaging index the data was written to.
 func (s *Server) WriteSeries(database, retentionPolicy string, points []Point) (uint64, error) {
+	return s.writeSeries(database, retentionPolicy, points, false)
+}
+
+// WriteSeriesPartial writes the valid points in a batch of series data.
+// A *WriteError listing the rejected points is returned if any point is invalid.
+// Returns the messaging index the data was written to.
+func (s *Server) WriteSeriesPartial(database, retentionPolicy string, points []Point) (uint64, error) {
+	return s.writeSeries(database, retentionPolicy, points, true)
+}
+
+func (s *Server) writeSeries(database, retentionPolicy string, points []Point, partial bool) (uint64, error) {
 	// If the retention policy is not set, use the default for this database.
 	if retentionPolicy == "" {
 		rp, err := s.DefaultRetentionPolicy(database)
@@ -1605,71 +1978,225 @@ func (s *Server) WriteSeries(database, retentionPolicy string, points []Point) (
 			return 0, ErrDefaultRetentionPolicyNotFound
 		}
 		retentionPolicy = rp.Name
+	} else if rp, err := s.RetentionPolicy(database, retentionPolicy); err != nil {
+		return 0, err
+	} else if rp == nil {
+		return 0, ErrRetentionPolicyNotFound
+	}
+
+	// Validate the batch against the schema before creating any series or fields.
+	rejected := s.validatePoints(database, points)
+	if len(rejected) > 0 && (!partial || len(rejected) == len(points)) {
+		return 0, &WriteError{N: len(points), Points: rejected}
+	}
+
+	// Encode the remaining points. Points can still be rejected if a
+	// concurrent write created a conflicting field after validation.
+	batches, encodeRejected, err := s.encodePoints(database, retentionPolicy, points, rejected)
+	if err != nil {
+		return 0, err
+	}
+	if len(encodeRejected) > 0 {
+		rejected = append(rejected, encodeRejected...)
+		sort.Sort(pointErrors(rejected))
+		if !partial || len(rejected) == len(points) {
+			return 0, &WriteError{N: len(points), Points: rejected}
+		}
+	}
+
+	// Publish a single "raw write series batch" message on each shard's topic.
+	var index uint64
+	for shardID, data := range batches {
+		i, err := s.client.Publish(&messaging.Message{
+			Type:    writeRawSeriesBatchMessageType,
+			TopicID: shardID,
+			Data:    data,
+		})
+		if err != nil {
+			return index, err
+		}
+		if i > index {
+			index = i
+		}
+	}
+
+	if len(rejected) > 0 {
+		return index, &WriteError{N: len(points), Points: rejected}
+	}
+	return index, nil
+}
+
+// validatePoints checks every point against the measurement schemas and the
+// fields that earlier points in the batch will create.
+// Returns an error for each point that cannot be written.
+func (s *Server) validatePoints(database string, points []Point) []*PointError {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	db := s.databases[database]
+	pending := make(map[string]map[string]influxql.DataType)
+	var a []*PointError
+	for i := range points {
+		if err := validatePoint(db, &points[i], pending); err != nil {
+			a = append(a, &PointError{Index: i, Err: err})
+		}
+	}
+	return a
+}
+
+// validatePoint returns an error if a point is missing data or if its values
+// cannot be stored in the measurement's fields. Pending holds the types of new
+// fields by measurement and is updated with the point's new fields if it is valid.
+func validatePoint(db *database, p *Point, pending map[string]map[string]influxql.DataType) error {
+	if p.Name == "" {
+		return ErrMeasurementNameRequired
+	} else if len(p.Values) == 0 {
+		return ErrValuesRequired
+	}
+
+	// Find the existing measurement, if any.
+	var m *Measurement
+	if db != nil {
+		m = db.measurements[p.Name]
+	}
+	fieldN := len(pending[p.Name])
+	if m != nil {
+		fieldN += len(m.Fields)
+	}
+
+	var newFields map[string]influxql.DataType
+	for k, v := range p.Values {
+		typ := fieldDataType(v)
+		if typ == influxql.Unknown {
+			return &FieldError{Name: k, Value: v, Err: ErrFieldTypeUnsupported}
+		}
+
+		// Check the type against an existing or pending field.
+		var f *Field
+		if m != nil {
+			f = m.FieldByName(k)
+		}
+		if f != nil {
+			if !fieldTypeAccepts(f.Type, typ) {
+				return &FieldError{Name: k, Value: v, Type: f.Type, Err: ErrFieldTypeConflict}
+			}
+			continue
+		} else if pt, ok := pending[p.Name][k]; ok {
+			if !fieldTypeAccepts(pt, typ) {
+				return &FieldError{Name: k, Value: v, Type: pt, Err: ErrFieldTypeConflict}
+			}
+			continue
+		}
+
+		// Only 255 fields can exist on a measurement.
+		if fieldN+len(newFields)+1 > math.MaxUint8 {
+			return &FieldError{Name: k, Value: v, Err: ErrFieldOverflow}
+		}
+		if newFields == nil {
+			newFields = make(map[string]influxql.DataType)
+		}
+		newFields[k] = typ
+	}
+
+	// Later points in the batch must match the types of the new fields.
+	if len(newFields) > 0 {
+		if pending[p.Name] == nil {
+			pending[p.Name] = make(map[string]influxql.DataType)
+		}
+		for k, typ := range newFields {
+			pending[p.Name][k] = typ
+		}
+	}
+	return nil
+}
+
+// encodePoints encodes the points that have not been rejected and groups
+// them into batches by shard id. Points with invalid fields are returned as
+// rejected. Any other error fails the whole write.
+func (s *Server) encodePoints(database, retentionPolicy string, points []Point, rejected []*PointError) (map[uint64][]byte, []*PointError, error) {
+	skip := make(map[int]bool, len(rejected))
+	for _, e := range rejected {
+		skip[e.Index] = true
 	}
 
 	// Collect responses for each channel.
 	type resp struct {
-		index uint64
+		index int
+		sh    *Shard
+		data  []byte
 		err   error
 	}
 	ch := make(chan resp, len(points))
 
-	// Write each point in parallel.
+	// Encode each point in parallel.
 	var wg sync.WaitGroup
 	for i := range points {
+		if skip[i] {
+			continue
+		}
 		wg.Add(1)
-		go func(p *Point) {
-			index, err := s.writePoint(database, retentionPolicy, p)
-			ch <- resp{index, err}
+		go func(i int, p *Point) {
+			sh, data, err := s.encodePoint(database, retentionPolicy, p)
+			ch <- resp{i, sh, data, err}
 			wg.Done()
-		}(&points[i])
+		}(i, &points[i])
 	}
 	wg.Wait()
 	close(ch)
 
-	// Calculate max index and check for errors.
-	var index uint64
-	var err error
+	// Group encoded points by shard and check for errors.
+	var a []*PointError
+	batches := make(map[uint64][]byte)
 	for resp := range ch {
-		if resp.index > index {
-			index = resp.index
-		}
-		if err == nil && resp.err != nil {
-			err = resp.err
+		if err, ok := resp.err.(*FieldError); ok {
+			a = append(a, &PointError{Index: resp.index, Err: err})
+			continue
+		} else if resp.err != nil {
+			return nil, nil, resp.err
 		}
+		batches[resp.sh.ID] = appendPointBatch(batches[resp.sh.ID], resp.data)
 	}
-	return index, err
+	return batches, a, nil
 }
 
-func (s *Server) writePoint(database, retentionPolicy string, point *Point) (uint64, error) {
+// pointErrors represents a list of point errors sortable by index.
+type pointErrors []*PointError
+
+func (a pointErrors) Len() int           { return len(a) }
+func (a pointErrors) Less(i, j int) bool { return a[i].Index < a[j].Index }
+func (a pointErrors) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// encodePoint creates the series, fields & shard group for a point, if necessary,
+// and returns the shard the point belongs to along with the encoded point data.
+func (s *Server) encodePoint(database, retentionPolicy string, point *Point) (*Shard, []byte, error) {
 	measurement, tags, timestamp, values := point.Name, point.Tags, point.Timestamp, point.Values
 
 	// Sanity-check the data point.
 	if measurement == "" {
-		return 0, ErrMeasurementNameRequired
+		return nil, nil, ErrMeasurementNameRequired
 	}
 	if len(values) == 0 {
-		return 0, ErrValuesRequired
+		return nil, nil, ErrValuesRequired
 	}
 
 	// Find the id for the series and tagset
 	seriesID, err := s.createSeriesIfNotExists(database, measurement, tags)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
 	}
 
 	// Retrieve measurement.
 	m, err := s.measurement(database, measurement)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
 	} else if m == nil {
-		return 0, ErrMeasurementNotFound
+		return nil, nil, ErrMeasurementNotFound
 	}
 
 	// Retrieve shard group.
 	g, err := s.createShardGroupIfNotExists(database, retentionPolicy, timestamp)
 	if err != nil {
-		return 0, fmt.Errorf("create shard(%s/%s): %s", retentionPolicy, timestamp.Format(time.RFC3339Nano), err)
+		return nil, nil, fmt.Errorf("create shard(%s/%s): %s", retentionPolicy, timestamp.Format(time.RFC3339Nano), err)
 	}
 
 	// Find appropriate shard within the shard group.
@@ -1678,7 +2205,7 @@ func (s *Server) writePoint(database, retentionPolicy string, point *Point) (uin
 	// Ensure fields are created as necessary.
 	err = s.createFieldsIfNotExists(database, measurement, values)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
 	}
 
 	// Get a field codec.
@@ -1692,19 +2219,14 @@ func (s *Server) writePoint(database, retentionPolicy string, point *Point) (uin
 	// Convert string-key/values to encoded fields.
 	encodedFields, err := codec.EncodeFields(values)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
 	}
 
 	// Encode point header.
 	data := marshalPointHeader(seriesID, timestamp.UnixNano())
 	data = append(data, encodedFields...)
 
-	// Publish "raw write series" message on shard's topic to broker.
-	return s.client.Publish(&messaging.Message{
-		Type:    writeRawSeriesMessageType,
-		TopicID: sh.ID,
-		Data:    data,
-	})
+	return sh, data, nil
 }
 
 // applyWriteRawSeries writes raw series data to the database.
@@ -1715,6 +2237,9 @@ func (s *Server) applyWriteRawSeries(m *messaging.Message) error {
 	sh := s.Shard(m.TopicID)
 	if sh == nil {
 		return ErrShardNotFound
+	} else if sh.store == nil {
+		// Ignore data for shards whose local copy has been removed.
+		return nil
 	}
 
 	// Extract the series id and timestamp from the header.
@@ -1722,6 +2247,17 @@ func (s *Server) applyWriteRawSeries(m *messaging.Message) error {
 	seriesID, timestamp := unmarshalPointHeader(m.Data[:pointHeaderSize])
 	data := m.Data[pointHeaderSize:]
 
+	// Decode the values so they can be stored by field. Series are created
+	// before they are written to, so a missing series has since been dropped
+	// and its data is ignored.
+	s.mu.RLock()
+	codec := s.fieldCodecBySeriesID(sh.database, seriesID)
+	s.mu.RUnlock()
+	if codec == nil {
+		return nil
+	}
+	values := codec.DecodeFields(data)
+
 	// Add to lookup.
 	s.addShardBySeriesID(sh, seriesID)
 
@@ -1729,7 +2265,72 @@ func (s *Server) applyWriteRawSeries(m *messaging.Message) error {
 	overwrite := true
 
 	// Write to shard.
-	return sh.writeSeries(seriesID, timestamp, data, overwrite)
+	return sh.writeSeries(seriesID, timestamp, values, overwrite)
+}
+
+// applyWriteRawSeriesBatch writes a batch of raw series data to a shard.
+// All points in the batch are written in a single shard transaction.
+func (s *Server) applyWriteRawSeriesBatch(m *messaging.Message) error {
+	// Retrieve the shard.
+	sh := s.Shard(m.TopicID)
+	if sh == nil {
+		return ErrShardNotFound
+	} else if sh.store == nil {
+		// Ignore data for shards whose local copy has been removed.
+		return nil
+	}
+
+	// Split the batch into individual encoded points.
+	a, err := unmarshalPointBatch(m.Data)
+	if err != nil {
+		return err
+	}
+
+	// Decode the values and group points by series.
+	// Points for series that have since been dropped are ignored.
+	points := make(map[uint32][]blockPoint)
+	codecs := make(map[uint32]*FieldCodec)
+	s.mu.RLock()
+	for _, data := range a {
+		seriesID, timestamp := unmarshalPointHeader(data[:pointHeaderSize])
+
+		codec, ok := codecs[seriesID]
+		if !ok {
+			codec = s.fieldCodecBySeriesID(sh.database, seriesID)
+			codecs[seriesID] = codec
+		}
+		if codec == nil {
+			continue
+		}
+
+		points[seriesID] = append(points[seriesID], blockPoint{timestamp: timestamp, values: codec.DecodeFields(data[pointHeaderSize:])})
+	}
+	s.mu.RUnlock()
+
+	// Add to lookup.
+	for seriesID := range points {
+		s.addShardBySeriesID(sh, seriesID)
+	}
+
+	// TODO: Enable some way to specify if the data should be overwritten
+	overwrite := true
+
+	// Write to shard.
+	return sh.writeSeriesBatch(points, overwrite)
+}
+
+// fieldCodecBySeriesID returns a codec for the measurement that owns a series.
+// Returns nil if the series does not exist. Must be called with a lock.
+func (s *Server) fieldCodecBySeriesID(database string, seriesID uint32) *FieldCodec {
+	db := s.databases[database]
+	if db == nil {
+		return nil
+	}
+	series := db.series[seriesID]
+	if series == nil {
+		return nil
+	}
+	return NewFieldCodec(series.measurement)
 }
 
 func (s *Server) addShardBySeriesID(sh *Shard, seriesID uint32) {
@@ -1791,8 +2392,8 @@ func (s *Server) createFieldsIfNotExists(database string, measurement string, va
 			if f == nil {
 				newFields[k] = influxql.InspectDataType(v)
 			} else {
-				if f.Type != influxql.InspectDataType(v) {
-					return nil, fmt.Errorf(fmt.Sprintf("field \"%s\" is type %T, mapped as type %s", k, v, f.Type))
+				if !fieldTypeAccepts(f.Type, influxql.InspectDataType(v)) {
+					return nil, &FieldError{Name: k, Value: v, Type: f.Type, Err: ErrFieldTypeConflict}
 				}
 			}
 		}
@@ -1925,7 +2526,11 @@ func (s *Server) ExecuteQuery(q *influxql.Query, database string, user *User) Re
 		case *influxql.ShowUsersStatement:
 			res = s.executeShowUsersStatement(stmt, user)
 		case *influxql.DropSeriesStatement:
-			continue
+			res = s.executeDropSeriesStatement(stmt, database, user)
+		case *influxql.DeleteStatement:
+			res = s.executeDeleteStatement(stmt, database, user)
+		case *influxql.DropMeasurementStatement:
+			res = s.executeDropMeasurementStatement(stmt, database, user)
 		case *influxql.ShowSeriesStatement:
 			res = s.executeShowSeriesStatement(stmt, database, user)
 		case *influxql.ShowMeasurementsStatement:
@@ -1954,6 +2559,10 @@ func (s *Server) ExecuteQuery(q *influxql.Query, database string, user *User) Re
 			continue
 		case *influxql.ShowContinuousQueriesStatement:
 			res = s.executeShowContinuousQueriesStatement(stmt, database, user)
+		case *influxql.RebalanceShardsStatement:
+			res = s.executeRebalanceShardsStatement(stmt, user)
+		case *influxql.ShowReplicasStatement:
+			res = s.executeShowReplicasStatement(stmt, user)
 		default:
 			panic(fmt.Sprintf("unsupported statement type: %T", stmt))
 		}
@@ -1992,6 +2601,9 @@ func (s *Server) executeSelectStatement(stmt *influxql.SelectStatement, database
 	// Read all rows from channel.
 	res := &Result{Rows: make([]*influxql.Row, 0)}
 	for row := range ch {
+		if row.Err != nil {
+			return &Result{Err: row.Err}
+		}
 		res.Rows = append(res.Rows, row)
 	}
 
@@ -2027,10 +2639,26 @@ func (s *Server) planSelectStatement(stmt *influxql.SelectStatement) (*influxql.
 			if s.databases[db].measurements[m] == nil {
 				return nil, fmt.Errorf("measurement %s does not exist.", measurement.Name)
 			}
+			mm := s.databases[db].measurements[m]
 			var fields influxql.Fields
-			for _, f := range s.databases[db].measurements[m].Fields {
+			for _, f := range mm.Fields {
 				fields = append(fields, &influxql.Field{Expr: &influxql.VarRef{Val: f.Name}})
 			}
+
+			// Tag keys are also selected unless they are grouped by.
+			_, dimensions, err := stmt.Dimensions.Normalize()
+			if err != nil {
+				return nil, err
+			}
+			grouped := make(map[string]bool)
+			for _, d := range dimensions {
+				grouped[d] = true
+			}
+			for _, k := range mm.tagKeys() {
+				if !grouped[k] {
+					fields = append(fields, &influxql.Field{Expr: &influxql.VarRef{Val: k}})
+				}
+			}
 			stmt.Fields = fields
 		}
 	}
@@ -2057,6 +2685,33 @@ func (s *Server) executeShowDatabasesStatement(q *influxql.ShowDatabasesStatemen
 	return &Result{Rows: []*influxql.Row{row}}
 }
 
+func (s *Server) executeRebalanceShardsStatement(q *influxql.RebalanceShardsStatement, user *User) *Result {
+	return &Result{Err: s.RebalanceShards()}
+}
+
+func (s *Server) executeShowReplicasStatement(q *influxql.ShowReplicasStatement, user *User) *Result {
+	a, err := s.ReplicaStatus()
+	if err != nil {
+		return &Result{Err: err}
+	}
+
+	row := &influxql.Row{Name: "replicas", Columns: []string{"id", "url", "lastContact", "lag", "topics"}}
+	for _, r := range a {
+		var lastContact interface{}
+		if !r.LastContact.IsZero() {
+			lastContact = r.LastContact.UTC().Format(time.RFC3339Nano)
+		}
+
+		topicIDs := make([]uint64, 0, len(r.Topics))
+		for _, t := range r.Topics {
+			topicIDs = append(topicIDs, t.ID)
+		}
+
+		row.Values = append(row.Values, []interface{}{r.ID, r.URL, lastContact, r.Lag, topicIDs})
+	}
+	return &Result{Rows: []*influxql.Row{row}}
+}
+
 func (s *Server) executeCreateUserStatement(q *influxql.CreateUserStatement, user *User) *Result {
 	isAdmin := false
 	if q.Privilege != nil {
@@ -2141,6 +2796,230 @@ func (s *Server) executeShowSeriesStatement(stmt *influxql.ShowSeriesStatement,
 	return result
 }
 
+func (s *Server) executeDropSeriesStatement(stmt *influxql.DropSeriesStatement, database string, user *User) *Result {
+	// Local function keeps locking foolproof.
+	f := func(stmt *influxql.DropSeriesStatement, database string) (seriesIDs, error) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		// Find the database.
+		db := s.databases[database]
+		if db == nil {
+			return nil, ErrDatabaseNotFound
+		}
+
+		// Drop a single series by id, if specified.
+		if stmt.SeriesID != 0 {
+			if db.series[stmt.SeriesID] == nil {
+				return nil, ErrSeriesNotFound
+			}
+			return seriesIDs{stmt.SeriesID}, nil
+		}
+
+		// Get the list of measurements we're interested in.
+		measurements, err := measurementsFromSourceOrDB(stmt.Source, db)
+		if err != nil {
+			return nil, err
+		}
+
+		// Collect the series matching the tag filters from each measurement.
+		var ids seriesIDs
+		for _, m := range measurements {
+			if stmt.Condition == nil {
+				ids = ids.union(m.seriesIDs)
+				continue
+			}
+
+			// Reject conditions that do not only filter on tags.
+			if err := validateDropSeriesCondition(m, stmt.Condition); err != nil {
+				return nil, err
+			}
+
+			filters := map[uint32]influxql.Expr{}
+			a, _, expr := m.walkWhereForSeriesIds(stmt.Condition, filters)
+			if expr != nil || len(filters) > 0 {
+				return nil, ErrFieldConditionNotSupported
+			}
+			ids = ids.union(a)
+		}
+		return ids, nil
+	}
+
+	ids, err := f(stmt, database)
+	if err != nil {
+		return &Result{Err: err}
+	} else if len(ids) == 0 {
+		return &Result{}
+	}
+
+	return &Result{Err: s.DropSeries(database, ids)}
+}
+
+func (s *Server) executeDropMeasurementStatement(stmt *influxql.DropMeasurementStatement, database string, user *User) *Result {
+	return &Result{Err: s.DropMeasurement(database, stmt.Name)}
+}
+
+func (s *Server) executeDeleteStatement(stmt *influxql.DeleteStatement, database string, user *User) *Result {
+	// Replace instances of "now()" with the current time.
+	condition := influxql.Reduce(stmt.Condition, &influxql.NowValuer{Now: time.Now().UTC()})
+
+	// Local function keeps locking foolproof.
+	f := func(source influxql.Source, condition influxql.Expr, database string) (seriesIDs, error) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		// Find the database.
+		db := s.databases[database]
+		if db == nil {
+			return nil, ErrDatabaseNotFound
+		}
+
+		// Get the list of measurements we're interested in.
+		measurements, err := measurementsFromSourceOrDB(source, db)
+		if err != nil {
+			return nil, err
+		}
+
+		// Collect the series matching the tag filters from each measurement.
+		// Conditions that only restrict time match every series.
+		var ids seriesIDs
+		for _, m := range measurements {
+			if condition == nil {
+				ids = ids.union(m.seriesIDs)
+				continue
+			}
+
+			// Reject conditions that cannot be resolved to whole series.
+			if err := validateDeleteCondition(m, condition); err != nil {
+				return nil, err
+			}
+
+			filters := map[uint32]influxql.Expr{}
+			a, ok, expr := m.walkWhereForSeriesIds(condition, filters)
+			if expr != nil || len(filters) > 0 {
+				return nil, ErrFieldConditionNotSupported
+			} else if !ok {
+				a = m.seriesIDs
+			}
+			ids = ids.union(a)
+		}
+		return ids, nil
+	}
+
+	ids, err := f(stmt.Source, condition, database)
+	if err != nil {
+		return &Result{Err: err}
+	} else if len(ids) == 0 {
+		return &Result{}
+	}
+
+	// Determine the time range to delete. Unbounded ends cover all data.
+	min, max := deleteTimeRange(condition)
+	if min.IsZero() {
+		min = time.Unix(0, 0)
+	}
+	if max.IsZero() {
+		max = time.Unix(0, math.MaxInt64)
+	}
+
+	return &Result{Err: s.DeleteSeriesRange(database, ids, min, max)}
+}
+
+// validateDeleteCondition returns an error if a DELETE condition cannot be
+// resolved to a set of series and a single time range. Only tags can be
+// compared, using = or !=, and time can only be combined with AND.
+func validateDeleteCondition(m *Measurement, expr influxql.Expr) error {
+	switch expr := expr.(type) {
+	case *influxql.ParenExpr:
+		return validateDeleteCondition(m, expr.Expr)
+	case *influxql.BinaryExpr:
+		switch expr.Op {
+		case influxql.AND, influxql.OR:
+			if expr.Op == influxql.OR && (hasTimeRef(expr.LHS) || hasTimeRef(expr.RHS)) {
+				return ErrTimeConditionNotSupported
+			}
+			if err := validateDeleteCondition(m, expr.LHS); err != nil {
+				return err
+			}
+			return validateDeleteCondition(m, expr.RHS)
+		}
+
+		// Comparisons must be against time or a tag.
+		ref, ok := expr.LHS.(*influxql.VarRef)
+		if !ok {
+			ref, _ = expr.RHS.(*influxql.VarRef)
+		}
+		if ref != nil && strings.ToLower(ref.Val) == "time" {
+			return nil
+		} else if ref == nil || m.FieldByName(ref.Val) != nil || (expr.Op != influxql.EQ && expr.Op != influxql.NEQ) {
+			return ErrFieldConditionNotSupported
+		}
+	}
+	return nil
+}
+
+// validateDropSeriesCondition returns an error if a DROP SERIES condition
+// references anything other than tags. Tags can only be compared using = or !=.
+func validateDropSeriesCondition(m *Measurement, expr influxql.Expr) error {
+	if hasTimeRef(expr) {
+		return ErrSeriesTimeConditionNotSupported
+	}
+	return validateDeleteCondition(m, expr)
+}
+
+// hasTimeRef returns true if expr references time.
+func hasTimeRef(expr influxql.Expr) bool {
+	var ok bool
+	influxql.WalkFunc(expr, func(n influxql.Node) {
+		if ref, isRef := n.(*influxql.VarRef); isRef && strings.ToLower(ref.Val) == "time" {
+			ok = true
+		}
+	})
+	return ok
+}
+
+// deleteTimeRange returns the time range of a DELETE condition. Exclusive
+// bounds are converted to inclusive bounds with nanosecond precision so points
+// just inside a bound are deleted too.
+func deleteTimeRange(expr influxql.Expr) (min, max time.Time) {
+	expr = influxql.RewriteFunc(influxql.CloneExpr(expr), func(n influxql.Node) influxql.Node {
+		e, ok := n.(*influxql.BinaryExpr)
+		if !ok || (e.Op != influxql.LT && e.Op != influxql.GT) {
+			return n
+		}
+
+		// Normalize the comparison to "time <op> literal".
+		ref, lit, op := e.LHS, e.RHS, e.Op
+		if _, ok := ref.(*influxql.VarRef); !ok {
+			ref, lit = lit, ref
+			if op == influxql.LT {
+				op = influxql.GT
+			} else {
+				op = influxql.LT
+			}
+		}
+		if ref, ok := ref.(*influxql.VarRef); !ok || strings.ToLower(ref.Val) != "time" {
+			return n
+		}
+
+		var t time.Time
+		switch lit := lit.(type) {
+		case *influxql.TimeLiteral:
+			t = lit.Val
+		case *influxql.DurationLiteral:
+			t = time.Unix(0, int64(lit.Val)).UTC()
+		default:
+			return n
+		}
+
+		if op == influxql.LT {
+			return &influxql.BinaryExpr{Op: influxql.LTE, LHS: ref, RHS: &influxql.TimeLiteral{Val: t.Add(-time.Nanosecond)}}
+		}
+		return &influxql.BinaryExpr{Op: influxql.GTE, LHS: ref, RHS: &influxql.TimeLiteral{Val: t.Add(time.Nanosecond)}}
+	}).(influxql.Expr)
+	return influxql.TimeRange(expr)
+}
+
 func (s *Server) executeShowMeasurementsStatement(stmt *influxql.ShowMeasurementsStatement, database string, user *User) *Result {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -2693,6 +3572,8 @@ func (s *Server) processor(client MessagingClient, done chan struct{}) {
 		switch m.Type {
 		case writeRawSeriesMessageType:
 			err = s.applyWriteRawSeries(m)
+		case writeRawSeriesBatchMessageType:
+			err = s.applyWriteRawSeriesBatch(m)
 		case createDataNodeMessageType:
 			err = s.applyCreateDataNode(m)
 		case deleteDataNodeMessageType:
@@ -2717,12 +3598,20 @@ func (s *Server) processor(client MessagingClient, done chan struct{}) {
 			err = s.applyCreateShardGroupIfNotExists(m)
 		case deleteShardGroupMessageType:
 			err = s.applyDeleteShardGroup(m)
+		case updateShardOwnersMessageType:
+			err = s.applyUpdateShardOwners(m)
 		case setDefaultRetentionPolicyMessageType:
 			err = s.applySetDefaultRetentionPolicy(m)
 		case createFieldsIfNotExistsMessageType:
 			err = s.applyCreateFieldsIfNotExist(m)
 		case createSeriesIfNotExistsMessageType:
 			err = s.applyCreateSeriesIfNotExists(m)
+		case dropSeriesMessageType:
+			err = s.applyDropSeries(m)
+		case deleteSeriesRangeMessageType:
+			err = s.applyDeleteSeriesRange(m)
+		case dropMeasurementMessageType:
+			err = s.applyDropMeasurement(m)
 		case setPrivilegeMessageType:
 			err = s.applySetPrivilege(m)
 		case createContinuousQueryMessageType:
@@ -2732,6 +3621,9 @@ func (s *Server) processor(client MessagingClient, done chan struct{}) {
 		// Sync high water mark and errors.
 		s.mu.Lock()
 		s.index = m.Index
+		if m.Index > s.topicIndexes[m.TopicID] {
+			s.topicIndexes[m.TopicID] = m.Index
+		}
 		if err != nil {
 			s.errors[m.Index] = err
 		}
@@ -2784,14 +3676,18 @@ func (r *Result) UnmarshalJSON(b []byte) error {
 type Results struct {
 	Results []*Result
 	Err     error
+
+	// Points rejected from a write.
+	Rejected []*PointError
 }
 
 // MarshalJSON encodes a Results struct into JSON.
 func (r Results) MarshalJSON() ([]byte, error) {
 	// Define a struct that outputs "error" as a string.
 	var o struct {
-		Results []*Result `json:"results,omitempty"`
-		Err     string    `json:"error,omitempty"`
+		Results  []*Result     `json:"results,omitempty"`
+		Err      string        `json:"error,omitempty"`
+		Rejected []*PointError `json:"rejected,omitempty"`
 	}
 
 	// Copy fields to output struct.
@@ -2799,6 +3695,7 @@ func (r Results) MarshalJSON() ([]byte, error) {
 	if r.Err != nil {
 		o.Err = r.Err.Error()
 	}
+	o.Rejected = r.Rejected
 
 	return json.Marshal(&o)
 }
@@ -2806,8 +3703,9 @@ func (r Results) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON decodes the data into the Results struct
 func (r *Results) UnmarshalJSON(b []byte) error {
 	var o struct {
-		Results []*Result `json:"results,omitempty"`
-		Err     string    `json:"error,omitempty"`
+		Results  []*Result     `json:"results,omitempty"`
+		Err      string        `json:"error,omitempty"`
+		Rejected []*PointError `json:"rejected,omitempty"`
 	}
 
 	err := json.Unmarshal(b, &o)
@@ -2818,6 +3716,7 @@ func (r *Results) UnmarshalJSON(b []byte) error {
 	if o.Err != "" {
 		r.Err = errors.New(o.Err)
 	}
+	r.Rejected = o.Rejected
 	return nil
 }
 
@@ -2852,6 +3751,12 @@ type MessagingClient interface {
 	// Removes a subscription from the replica for a topic.
 	Unsubscribe(replicaID, topicID uint64) error
 
+	// Reports the highest index applied by a replica for each topic.
+	Heartbeat(replicaID uint64, indexes map[uint64]uint64) error
+
+	// Returns the replication status of every replica.
+	ReplicaStatus() ([]*messaging.ReplicaStatus, error)
+
 	// The streaming channel for all subscribed messages.
 	C() <-chan *messaging.Message
 }
@@ -3187,6 +4092,10 @@ func (s *Server) runContinuousQueryAndWriteResult(cq *ContinuousQuery) error {
 
 	// Read all rows from channel and write them in
 	for row := range ch {
+		if row.Err != nil {
+			return row.Err
+		}
+
 		points, err := s.convertRowToPoints(cq.intoMeasurement, row)
 		if err != nil {
 			log.Println(err)
@@ -6,9 +6,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -734,6 +738,71 @@ func TestServer_StartRetentionPolicyEnforcement_ErrZeroInterval(t *testing.T) {
 	}
 }
 
+// Ensure the server reports the highest index applied for each topic to the broker.
+func TestServer_Heartbeat(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDatabase("foo")
+
+	var replicaID uint64
+	var indexes map[uint64]uint64
+	c.HeartbeatFunc = func(id uint64, a map[uint64]uint64) error {
+		replicaID, indexes = id, a
+		return nil
+	}
+
+	if err := s.Heartbeat(); err != nil {
+		t.Fatal(err)
+	} else if replicaID != s.ID() {
+		t.Fatalf("unexpected replica id: %d", replicaID)
+	} else if !reflect.DeepEqual(indexes, map[uint64]uint64{0: s.Index()}) {
+		t.Fatalf("unexpected indexes: %v", indexes)
+	}
+
+	// A command that fails to apply has still been processed and is reported.
+	index := s.Index()
+	if err := s.CreateDatabase("foo"); err != influxdb.ErrDatabaseExists {
+		t.Fatalf("unexpected error: %v", err)
+	} else if s.Index() == index {
+		t.Fatal("expected index to move forward")
+	} else if err := s.Heartbeat(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(indexes, map[uint64]uint64{0: s.Index()}) {
+		t.Fatalf("unexpected indexes: %v", indexes)
+	}
+}
+
+// Ensure the server prohibits a zero heartbeat interval.
+func TestServer_StartHeartbeats_ErrZeroInterval(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	if err := s.StartHeartbeats(time.Duration(0)); err == nil {
+		t.Fatal("failed to prohibit zero heartbeat interval")
+	}
+}
+
+// Ensure the server can list the replication status of replicas on the broker.
+func TestServer_ExecuteQuery_ShowReplicas(t *testing.T) {
+	c := NewMessagingClient()
+	c.ReplicaStatusFunc = func() ([]*messaging.ReplicaStatus, error) {
+		return []*messaging.ReplicaStatus{
+			{ID: 1, URL: "http://localhost:8086", LastContact: mustParseTime("2000-01-01T00:00:00Z"), Lag: 2,
+				Topics: []*messaging.TopicStatus{{ID: 0, Index: 10, Lag: 0}, {ID: 3, Index: 4, Lag: 2}}},
+			{ID: 2, URL: "http://localhost:9086"},
+		}, nil
+	}
+	s := OpenServer(c)
+	defer s.Close()
+
+	results := s.ExecuteQuery(MustParseQuery(`SHOW REPLICAS`), "", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"replicas","columns":["id","url","lastContact","lag","topics"],"values":[[1,"http://localhost:8086","2000-01-01T00:00:00Z",2,[0,3]],[2,"http://localhost:9086",null,0,[]]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+}
+
 func TestServer_EnforceRetentionPolices(t *testing.T) {
 	c := NewMessagingClient()
 	s := OpenServer(c)
@@ -830,6 +899,562 @@ func TestServer_WriteSeries(t *testing.T) {
 	}
 }
 
+// Ensure the server batches points into a single message per shard.
+func TestServer_WriteSeries_Batch(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "mypolicy", Duration: 1 * time.Hour})
+
+	// Count the number of raw series messages published.
+	var n int
+	c.PublishFunc = func(m *messaging.Message) (uint64, error) {
+		if m.TopicID != messaging.BroadcastTopicID {
+			n++
+		}
+		return c.send(m)
+	}
+
+	// Write many points across multiple series.
+	var points []influxdb.Point
+	for i := 0; i < 100; i++ {
+		points = append(points, influxdb.Point{
+			Name:      "cpu",
+			Tags:      map[string]string{"host": fmt.Sprintf("server%d", i%4)},
+			Timestamp: mustParseTime("2000-01-01T00:00:00Z").Add(time.Duration(i) * time.Second),
+			Values:    map[string]interface{}{"value": float64(i)},
+		})
+	}
+	index, err := s.WriteSeries("foo", "mypolicy", points)
+	if err != nil {
+		t.Fatal(err)
+	} else if err = s.Sync(index); err != nil {
+		t.Fatalf("sync error: %s", err)
+	}
+
+	// Verify only one message was published for the shard.
+	if n != 1 {
+		t.Fatalf("unexpected message count: %d", n)
+	}
+
+	// Verify each point was written.
+	for i, p := range points {
+		if v, err := s.ReadSeries("foo", "mypolicy", "cpu", p.Tags, p.Timestamp); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(v, map[string]interface{}{"value": float64(i)}) {
+			t.Fatalf("%d. values mismatch: %#v", i, v)
+		}
+	}
+}
+
+// Ensure points for a series dropped before a batch is applied are ignored
+// without losing the other points in the batch.
+func TestServer_WriteSeries_Batch_DroppedSeries(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "mypolicy", Duration: 1 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "mypolicy")
+
+	// Create both series.
+	tags := []map[string]string{{"host": "serverA"}, {"host": "serverB"}}
+	for _, tagset := range tags {
+		s.MustWriteSeries("foo", "mypolicy", []influxdb.Point{{Name: "cpu", Tags: tagset, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(0)}}})
+	}
+
+	// Hold the next write until the first series has been dropped.
+	var held *messaging.Message
+	c.PublishFunc = func(m *messaging.Message) (uint64, error) {
+		held = m
+		return m.Index, nil
+	}
+	timestamp := mustParseTime("2000-01-01T00:00:10Z")
+	index, err := s.WriteSeries("foo", "mypolicy", []influxdb.Point{
+		{Name: "cpu", Tags: tags[0], Timestamp: timestamp, Values: map[string]interface{}{"value": float64(1)}},
+		{Name: "cpu", Tags: tags[1], Timestamp: timestamp, Values: map[string]interface{}{"value": float64(2)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.PublishFunc = c.send
+
+	results := s.ExecuteQuery(MustParseQuery(`DROP SERIES FROM cpu WHERE host = 'serverA'`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Apply the held write and wait for it with a later command.
+	// The remaining series should still be written.
+	c.send(held)
+	if err := s.CreateDatabase("bar"); err != nil {
+		t.Fatal(err)
+	} else if err := s.Sync(index); err != nil {
+		t.Fatalf("sync error: %s", err)
+	} else if v, err := s.ReadSeries("foo", "mypolicy", "cpu", tags[1], timestamp); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(v, map[string]interface{}{"value": float64(2)}) {
+		t.Fatalf("values mismatch: %#v", v)
+	}
+}
+
+// Ensure the server rejects a batch with invalid points before anything is
+// written unless the valid points are written with WriteSeriesPartial.
+func TestServer_WriteSeries_Rejected(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "mypolicy", Duration: 1 * time.Hour})
+	s.MustWriteSeries("foo", "mypolicy", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(1)}}})
+
+	// Count the number of messages published.
+	var n int
+	c.PublishFunc = func(m *messaging.Message) (uint64, error) {
+		n++
+		return c.send(m)
+	}
+
+	points := []influxdb.Point{
+		{Name: "mem", Timestamp: mustParseTime("2000-01-01T00:00:01Z"), Values: map[string]interface{}{"value": float64(2)}},
+		{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:02Z"), Values: map[string]interface{}{"value": "foo"}},
+		{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:03Z")},
+	}
+	rejected := `2 of 3 points rejected, point 1: field "value" is type string, mapped as type number`
+
+	// Verify nothing is published when any point is invalid.
+	if _, err := s.WriteSeries("foo", "mypolicy", points); errstr(err) != rejected {
+		t.Fatalf("unexpected error: %s", err)
+	} else if werr := err.(*influxdb.WriteError); len(werr.Points) != 2 || werr.Points[0].Index != 1 || werr.Points[1].Index != 2 || werr.Points[1].Err != influxdb.ErrValuesRequired {
+		t.Fatalf("unexpected rejected points: %#v", werr.Points)
+	} else if n != 0 {
+		t.Fatalf("unexpected message count: %d", n)
+	}
+
+	// Verify the valid point is written with a partial write.
+	index, err := s.WriteSeriesPartial("foo", "mypolicy", points)
+	if errstr(err) != rejected {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err = s.Sync(index); err != nil {
+		t.Fatalf("sync error: %s", err)
+	}
+	if v, err := s.ReadSeries("foo", "mypolicy", "mem", nil, mustParseTime("2000-01-01T00:00:01Z")); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(v, map[string]interface{}{"value": float64(2)}) {
+		t.Fatalf("values mismatch: %#v", v)
+	}
+	if v, _ := s.ReadSeries("foo", "mypolicy", "cpu", nil, mustParseTime("2000-01-01T00:00:02Z")); v != nil {
+		t.Fatalf("unexpected values: %#v", v)
+	}
+}
+
+// Ensure the server validates a batch against the fields that earlier points
+// in the batch will create and never panics on unsupported values.
+func TestServer_WriteSeries_FieldValidation(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "mypolicy", Duration: 1 * time.Hour})
+
+	// Build a point with more fields than a measurement can hold.
+	overflow := make(map[string]interface{})
+	for i := 0; i < 300; i++ {
+		overflow[fmt.Sprintf("f%03d", i)] = float64(i)
+	}
+
+	points := []influxdb.Point{
+		{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(1)}},
+		{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:01Z"), Values: map[string]interface{}{"value": "foo"}},
+		{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:02Z"), Values: map[string]interface{}{"other": nil}},
+		{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:03Z"), Values: map[string]interface{}{"other": map[string]interface{}{}}},
+		{Name: "mem", Timestamp: mustParseTime("2000-01-01T00:00:04Z"), Values: overflow},
+	}
+	index, err := s.WriteSeriesPartial("foo", "mypolicy", points)
+	werr, ok := err.(*influxdb.WriteError)
+	if !ok {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err = s.Sync(index); err != nil {
+		t.Fatalf("sync error: %s", err)
+	}
+
+	// Verify each invalid point is rejected with a field error.
+	for i, tt := range []struct {
+		index int
+		err   error
+	}{
+		{index: 1, err: influxdb.ErrFieldTypeConflict},
+		{index: 2, err: influxdb.ErrFieldTypeUnsupported},
+		{index: 3, err: influxdb.ErrFieldTypeUnsupported},
+		{index: 4, err: influxdb.ErrFieldOverflow},
+	} {
+		if i >= len(werr.Points) {
+			t.Fatalf("%d. missing rejected point", i)
+		} else if e := werr.Points[i]; e.Index != tt.index {
+			t.Fatalf("%d. unexpected index: %d", i, e.Index)
+		} else if ferr, ok := e.Err.(*influxdb.FieldError); !ok || ferr.Err != tt.err {
+			t.Fatalf("%d. unexpected error: %s", i, e.Err)
+		}
+	}
+	if len(werr.Points) != 4 {
+		t.Fatalf("unexpected rejected point count: %d", len(werr.Points))
+	}
+
+	// Verify the valid point was written.
+	if v, err := s.ReadSeries("foo", "mypolicy", "cpu", nil, mustParseTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(v, map[string]interface{}{"value": float64(1)}) {
+		t.Fatalf("values mismatch: %#v", v)
+	}
+}
+
+// Ensure the server does not create series when writing to a missing retention policy.
+func TestServer_WriteSeries_ErrRetentionPolicyNotFound(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+
+	if _, err := s.WriteSeries("foo", "no_such_policy", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(1)}}}); err != influxdb.ErrRetentionPolicyNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	} else if a := s.MeasurementNames("foo"); len(a) != 0 {
+		t.Fatalf("unexpected measurements: %v", a)
+	}
+}
+
+// Ensure the server stores integers without losing precision and widens
+// integers written to number fields.
+func TestServer_WriteSeries_Integer(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"bytes": int64(9007199254740993), "value": float64(1.5)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"bytes": int64(1), "value": int64(2)}}})
+
+	if v, err := s.ReadSeries("db", "raw", "cpu", nil, mustParseTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(v, map[string]interface{}{"bytes": int64(9007199254740993), "value": float64(1.5)}) {
+		t.Fatalf("unexpected values: %#v", v)
+	}
+	if v, err := s.ReadSeries("db", "raw", "cpu", nil, mustParseTime("2000-01-01T00:00:10Z")); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(v, map[string]interface{}{"bytes": int64(1), "value": float64(2)}) {
+		t.Fatalf("unexpected values: %#v", v)
+	}
+
+	// Floats cannot be written to an integer field.
+	if _, err := s.WriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Values: map[string]interface{}{"bytes": float64(1)}}}); err == nil || err.Error() != `1 of 1 points rejected, point 0: field "bytes" is type float64, mapped as type integer` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the server can drop series by id and by tag filter.
+func TestServer_DropSeries(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+
+	// Write series to the database. Series are created in order so they get sequential ids.
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(20)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverC"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(30)}}})
+
+	// Conditions on anything other than tags are rejected.
+	for _, tt := range []struct {
+		q   string
+		err error
+	}{
+		{q: `DROP SERIES FROM cpu WHERE value > 90`, err: influxdb.ErrFieldConditionNotSupported},
+		{q: `DROP SERIES FROM cpu WHERE host = 'serverA' OR value = 10`, err: influxdb.ErrFieldConditionNotSupported},
+		{q: `DROP SERIES FROM cpu WHERE time < '2000-01-01T00:00:05Z'`, err: influxdb.ErrSeriesTimeConditionNotSupported},
+	} {
+		results := s.ExecuteQuery(MustParseQuery(tt.q), "db", nil)
+		if res := results.Results[0]; res.Err != tt.err {
+			t.Fatalf("%s: unexpected error: %v", tt.q, res.Err)
+		}
+	}
+
+	// Drop a series by tag filter.
+	results := s.ExecuteQuery(MustParseQuery(`DROP SERIES FROM cpu WHERE host = 'serverA'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Drop a series by id.
+	results = s.ExecuteQuery(MustParseQuery(`DROP SERIES 2`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Dropping a non-existent series id should return an error.
+	results = s.ExecuteQuery(MustParseQuery(`DROP SERIES 2`), "db", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrSeriesNotFound {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Verify the dropped series are removed from the index and data, even after restart.
+	for i := 0; i < 2; i++ {
+		results = s.ExecuteQuery(MustParseQuery(`SHOW SERIES`), "db", nil)
+		if res := results.Results[0]; res.Err != nil {
+			t.Fatalf("unexpected error: %s", res.Err)
+		} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["host"],"values":[["serverC"]]}]}` {
+			t.Fatalf("unexpected row(0): %s", s)
+		}
+
+		if _, err := s.ReadSeries("db", "raw", "cpu", map[string]string{"host": "serverA"}, mustParseTime("2000-01-01T00:00:00Z")); err != influxdb.ErrSeriesNotFound {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s.Restart()
+	}
+}
+
+// Ensure the server can drop a measurement along with its series and data.
+func TestServer_DropMeasurement(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+
+	// Write series to two measurements.
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "mem", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(20)}}})
+
+	// Drop the measurement.
+	results := s.ExecuteQuery(MustParseQuery(`DROP MEASUREMENT cpu`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Dropping a non-existent measurement should return an error.
+	results = s.ExecuteQuery(MustParseQuery(`DROP MEASUREMENT cpu`), "db", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrMeasurementNotFound {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Verify the measurement is removed from the index and data, even after restart.
+	for i := 0; i < 2; i++ {
+		results = s.ExecuteQuery(MustParseQuery(`SHOW MEASUREMENTS`), "db", nil)
+		if res := results.Results[0]; res.Err != nil {
+			t.Fatalf("unexpected error: %s", res.Err)
+		} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"measurements","columns":["name"],"values":[["mem"]]}]}` {
+			t.Fatalf("unexpected row(0): %s", s)
+		}
+
+		if _, err := s.ReadSeries("db", "raw", "cpu", map[string]string{"host": "serverA"}, mustParseTime("2000-01-01T00:00:00Z")); err != influxdb.ErrMeasurementNotFound {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s.Restart()
+	}
+
+	// Verify the measurement can be recreated without its old data.
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(30)}}})
+	if values, err := s.ReadSeries("db", "raw", "cpu", map[string]string{"host": "serverA"}, mustParseTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if values != nil {
+		t.Fatalf("unexpected values: %#v", values)
+	}
+}
+
+// Ensure the server can delete a time range of points matching a tag filter.
+func TestServer_DeleteSeries(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+
+	// Write points for two series at two different times.
+	for _, host := range []string{"serverA", "serverB"} {
+		s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": host}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10)}}})
+		s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": host}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(20)}}})
+	}
+
+	// Write a point a nanosecond before the delete's upper bound.
+	s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:04.999999999Z"), Values: map[string]interface{}{"value": float64(15)}}})
+
+	// Conditions that cannot be resolved to whole series are rejected.
+	for _, tt := range []struct {
+		q   string
+		err error
+	}{
+		{q: `DELETE FROM cpu WHERE value = 10`, err: influxdb.ErrFieldConditionNotSupported},
+		{q: `DELETE FROM cpu WHERE value > 90 OR host = 'serverB'`, err: influxdb.ErrFieldConditionNotSupported},
+		{q: `DELETE FROM cpu WHERE time < '2000-01-01T00:00:05Z' OR host = 'serverB'`, err: influxdb.ErrTimeConditionNotSupported},
+	} {
+		results := s.ExecuteQuery(MustParseQuery(tt.q), "db", nil)
+		if res := results.Results[0]; res.Err != tt.err {
+			t.Fatalf("%s: unexpected error: %v", tt.q, res.Err)
+		}
+	}
+
+	// Delete the older points for serverA only.
+	results := s.ExecuteQuery(MustParseQuery(`DELETE FROM cpu WHERE host = 'serverA' AND time < '2000-01-01T00:00:05Z'`), "db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Verify only the matching point was removed.
+	for _, tt := range []struct {
+		host      string
+		timestamp string
+		exists    bool
+	}{
+		{"serverA", "2000-01-01T00:00:00Z", false},
+		{"serverA", "2000-01-01T00:00:04.999999999Z", false},
+		{"serverA", "2000-01-01T00:00:10Z", true},
+		{"serverB", "2000-01-01T00:00:00Z", true},
+		{"serverB", "2000-01-01T00:00:10Z", true},
+	} {
+		values, err := s.ReadSeries("db", "raw", "cpu", map[string]string{"host": tt.host}, mustParseTime(tt.timestamp))
+		if err != nil {
+			t.Fatalf("%s/%s: unexpected error: %s", tt.host, tt.timestamp, err)
+		} else if (values != nil) != tt.exists {
+			t.Fatalf("%s/%s: unexpected values: %#v", tt.host, tt.timestamp, values)
+		}
+	}
+}
+
+// Ensure the server can order raw and aggregate queries by time and apply limits and offsets.
+func TestServer_ExecuteQuery_OrderByLimitOffset(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+
+	// Write points for two series.
+	for i, host := range []string{"serverA", "serverB"} {
+		for j := 0; j < 4; j++ {
+			timestamp := mustParseTime("2000-01-01T00:00:00Z").Add(time.Duration(j) * 10 * time.Second)
+			s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": host}, Timestamp: timestamp, Values: map[string]interface{}{"value": float64(100*i + j)}}})
+		}
+	}
+
+	// Write enough points to span several blocks.
+	var points []influxdb.Point
+	for i := 0; i < 2500; i++ {
+		points = append(points, influxdb.Point{Name: "mem", Timestamp: mustParseTime("2000-01-01T00:00:00Z").Add(time.Duration(i) * time.Second), Values: map[string]interface{}{"value": float64(i)}})
+	}
+	s.MustWriteSeries("db", "raw", points)
+
+	for i, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SELECT value FROM mem ORDER BY time DESC LIMIT 3 OFFSET 1499`,
+			exp: `{"rows":[{"name":"mem","columns":["time","value"],"values":[["2000-01-01T00:16:40Z",1000],["2000-01-01T00:16:39Z",999],["2000-01-01T00:16:38Z",998]]}]}`,
+		},
+		{
+			q:   `SELECT value FROM cpu WHERE host = 'serverA' LIMIT 2`,
+			exp: `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",0],["2000-01-01T00:00:10Z",1]]}]}`,
+		},
+		{
+			q:   `SELECT value FROM cpu WHERE host = 'serverA' ORDER BY time DESC LIMIT 1`,
+			exp: `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:30Z",3]]}]}`,
+		},
+		{
+			q:   `SELECT value FROM cpu WHERE host = 'serverA' ORDER BY time DESC LIMIT 2 OFFSET 1`,
+			exp: `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:20Z",2],["2000-01-01T00:00:10Z",1]]}]}`,
+		},
+		{
+			q:   `SELECT value FROM cpu WHERE host = 'serverA' OFFSET 3`,
+			exp: `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:30Z",3]]}]}`,
+		},
+		{
+			q:   `SELECT value FROM cpu WHERE host = 'serverA' OFFSET 4`,
+			exp: `{}`,
+		},
+		{
+			q:   `SELECT value FROM cpu GROUP BY host ORDER BY DESC LIMIT 1`,
+			exp: `{"rows":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["2000-01-01T00:00:30Z",3]]},{"name":"cpu","tags":{"host":"serverB"},"columns":["time","value"],"values":[["2000-01-01T00:00:30Z",103]]}]}`,
+		},
+		{
+			q:   `SELECT sum(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:40Z' GROUP BY time(20s) ORDER BY time DESC LIMIT 1`,
+			exp: `{"rows":[{"name":"cpu","columns":["time","sum"],"values":[["2000-01-01T00:00:20Z",210]]}]}`,
+		},
+	} {
+		results := s.ExecuteQuery(MustParseQuery(tt.q), "db", nil)
+		if res := results.Results[0]; res.Err != nil {
+			t.Fatalf("%d. unexpected error: %s", i, res.Err)
+		} else if s := mustMarshalJSON(res); s != tt.exp {
+			t.Fatalf("%d. unexpected result: %s", i, s)
+		}
+	}
+
+	// Sorting by a field is not supported.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT value FROM cpu ORDER BY value`), "db", nil)
+	if res := results.Results[0]; res.Err == nil || res.Err.Error() != "only ORDER BY time supported at this time" {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+}
+
+// Ensure the server can compute the derivative of a counter.
+func TestServer_ExecuteQuery_Derivative(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+
+	// Write a counter that resets once.
+	for i, v := range []float64{10, 20, 40, 5} {
+		timestamp := mustParseTime("2000-01-01T00:00:00Z").Add(time.Duration(i) * 10 * time.Second)
+		s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Timestamp: timestamp, Values: map[string]interface{}{"value": v}}})
+	}
+
+	for i, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SELECT derivative(value, 10s) FROM cpu`,
+			exp: `{"rows":[{"name":"cpu","columns":["time","derivative"],"values":[["2000-01-01T00:00:10Z",10],["2000-01-01T00:00:20Z",20],["2000-01-01T00:00:30Z",-35]]}]}`,
+		},
+		{
+			q:   `SELECT non_negative_derivative(value, 10s) FROM cpu ORDER BY time DESC LIMIT 1`,
+			exp: `{"rows":[{"name":"cpu","columns":["time","non_negative_derivative"],"values":[["2000-01-01T00:00:20Z",20]]}]}`,
+		},
+	} {
+		results := s.ExecuteQuery(MustParseQuery(tt.q), "db", nil)
+		if res := results.Results[0]; res.Err != nil {
+			t.Fatalf("%d. unexpected error: %s", i, res.Err)
+		} else if s := mustMarshalJSON(res); s != tt.exp {
+			t.Fatalf("%d. unexpected result: %s", i, s)
+		}
+	}
+}
+
+// Ensure the server can page through the series of a grouped query.
+func TestServer_ExecuteQuery_SLimitSOffset(t *testing.T) {
+	s := OpenDefaultServer(NewMessagingClient())
+	defer s.Close()
+
+	// Write one point for each of several hosts.
+	for i, host := range []string{"serverA", "serverB", "serverC"} {
+		s.MustWriteSeries("db", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": host}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(i)}}})
+	}
+
+	for i, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SELECT value FROM cpu GROUP BY host SLIMIT 1`,
+			exp: `{"rows":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",0]]}]}`,
+		},
+		{
+			q:   `SELECT value FROM cpu GROUP BY host SLIMIT 1 SOFFSET 1`,
+			exp: `{"rows":[{"name":"cpu","tags":{"host":"serverB"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1]]}]}`,
+		},
+		{
+			q:   `SELECT sum(value) FROM cpu GROUP BY host SOFFSET 2`,
+			exp: `{"rows":[{"name":"cpu","tags":{"host":"serverC"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",2]]}]}`,
+		},
+		{
+			q:   `SELECT value FROM cpu GROUP BY host SOFFSET 3`,
+			exp: `{}`,
+		},
+	} {
+		results := s.ExecuteQuery(MustParseQuery(tt.q), "db", nil)
+		if res := results.Results[0]; res.Err != nil {
+			t.Fatalf("%d. unexpected error: %s", i, res.Err)
+		} else if s := mustMarshalJSON(res); s != tt.exp {
+			t.Fatalf("%d. unexpected result: %s", i, s)
+		}
+	}
+}
+
 // Ensure the server can execute a query and return the data correctly.
 func TestServer_ExecuteQuery(t *testing.T) {
 	s := OpenServer(NewMessagingClient())
@@ -900,9 +1525,87 @@ func TestServer_ExecuteWildcardQuery(t *testing.T) {
 	results := s.ExecuteQuery(MustParseQuery(`SELECT * FROM cpu`), "foo", nil)
 	if res := results.Results[0]; res.Err != nil {
 		t.Fatalf("unexpected error during SELECT *: %s", res.Err)
-	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","value","val-x"],"values":[["2000-01-01T00:00:00Z",10,null],["2000-01-01T00:00:10Z",null,20],["2000-01-01T00:00:20Z",30,40]]}]}` {
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","value","val-x","region"],"values":[["2000-01-01T00:00:00Z",10,null,"us-east"],["2000-01-01T00:00:10Z",null,20,"us-east"],["2000-01-01T00:00:20Z",30,40,"us-east"]]}]}` {
 		t.Fatalf("unexpected results during SELECT *: %s", s)
 	}
+
+	// Grouped tag keys are not returned as columns.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT * FROM cpu GROUP BY region`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error during SELECT * GROUP BY: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","tags":{"region":"us-east"},"columns":["time","value","val-x"],"values":[["2000-01-01T00:00:00Z",10,null],["2000-01-01T00:00:10Z",null,20],["2000-01-01T00:00:20Z",30,40]]}]}` {
+		t.Fatalf("unexpected results during SELECT * GROUP BY: %s", s)
+	}
+}
+
+// Ensure the server can filter points by their field values.
+func TestServer_ExecuteQuery_FieldCondition(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(95), "status": "error", "up": true}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(50), "status": "error", "up": false}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Values: map[string]interface{}{"value": float64(99), "status": "ok", "up": true}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Timestamp: mustParseTime("2000-01-01T00:00:30Z"), Values: map[string]interface{}{"value": float64(10), "status": "ok", "up": true}}})
+
+	for i, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{q: `SELECT value FROM cpu WHERE value > 90`, exp: `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",95],["2000-01-01T00:00:20Z",99]]}]}`},
+		{q: `SELECT value FROM cpu WHERE value > 90 AND status = 'error'`, exp: `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",95]]}]}`},
+		{q: `SELECT value FROM cpu WHERE up = false OR host = 'serverB'`, exp: `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:10Z",50],["2000-01-01T00:00:20Z",99],["2000-01-01T00:00:30Z",10]]}]}`},
+		{q: `SELECT value FROM cpu WHERE (value < 20 AND host = 'serverB') OR status = 'error'`, exp: `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",95],["2000-01-01T00:00:10Z",50],["2000-01-01T00:00:30Z",10]]}]}`},
+		{q: `SELECT value FROM cpu WHERE host <> 'serverA' AND value > 50`, exp: `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:20Z",99]]}]}`},
+		{q: `SELECT value FROM cpu WHERE time > '2000-01-01T00:00:05Z' AND value > 90`, exp: `{"rows":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:20Z",99]]}]}`},
+		{q: `SELECT count(value) FROM cpu WHERE value > 90`, exp: `{"rows":[{"name":"cpu","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",2]]}]}`},
+		{q: `SELECT count(value) FROM cpu WHERE status = 'ok' GROUP BY host`, exp: `{"rows":[{"name":"cpu","tags":{"host":"serverB"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",2]]}]}`},
+	} {
+		results := s.ExecuteQuery(MustParseQuery(tt.q), "foo", nil)
+		if res := results.Results[0]; res.Err != nil {
+			t.Errorf("%d. %s: unexpected error: %s", i, tt.q, res.Err)
+		} else if s := mustMarshalJSON(res); s != tt.exp {
+			t.Errorf("%d. %s: unexpected results: %s", i, tt.q, s)
+		}
+	}
+}
+
+// Ensure the server can select multiple fields from a raw query.
+func TestServer_ExecuteQuery_MultipleFields(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Values: map[string]interface{}{"value": float64(10), "idle": float64(90)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Values: map[string]interface{}{"value": float64(20), "idle": float64(80), "user": float64(5)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Values: map[string]interface{}{"user": float64(7)}}})
+
+	// Select fields in a different order than they were written.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT idle, value FROM cpu`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","idle","value"],"values":[["2000-01-01T00:00:00Z",90,10],["2000-01-01T00:00:10Z",80,20]]}]}` {
+		t.Fatalf("unexpected results: %s", s)
+	}
+
+	// Select a field along with a tag and limit the points returned.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT value, host FROM cpu ORDER BY time DESC LIMIT 1`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"rows":[{"name":"cpu","columns":["time","value","host"],"values":[["2000-01-01T00:00:10Z",20,"serverA"]]}]}` {
+		t.Fatalf("unexpected results: %s", s)
+	}
+
+	// Selecting an unknown field returns an error.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT value, foo FROM cpu`), "foo", nil)
+	if res := results.Results[0]; res.Err == nil || res.Err.Error() != "field not found: foo" {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
 }
 
 func TestServer_CreateShardGroupIfNotExist(t *testing.T) {
@@ -928,6 +1631,187 @@ func TestServer_CreateShardGroupIfNotExist(t *testing.T) {
 	}
 }
 
+// Ensure shards are replicated across distinct data nodes in a balanced way.
+func TestServer_CreateShardGroupIfNotExist_ReplicaN(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDataNode(&url.URL{Host: "127.0.0.1:8081"})
+	s.CreateDataNode(&url.URL{Host: "127.0.0.1:8082"})
+	s.CreateDatabase("foo")
+
+	if err := s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "bar", Duration: time.Hour, ReplicaN: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Track the shards this server subscribes to.
+	subscriptions := make(map[uint64]uint64)
+	c.SubscribeFunc = func(replicaID, topicID uint64) error {
+		subscriptions[topicID] = replicaID
+		return nil
+	}
+
+	// Create a shard group for three separate hours.
+	for i := 0; i < 3; i++ {
+		if err := s.CreateShardGroupIfNotExists("foo", "bar", mustParseTime("2000-01-01T00:30:00Z").Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, err := s.ShardGroups("foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(a) != 3 {
+		t.Fatalf("expected 3 shard groups but found %d", len(a))
+	}
+
+	// Every shard should have two distinct owners and each node should own two shards.
+	counts := make(map[uint64]int)
+	for _, g := range a {
+		if len(g.Shards) != 1 {
+			t.Fatalf("expected 1 shard but found %d", len(g.Shards))
+		}
+		sh := g.Shards[0]
+		if len(sh.DataNodeIDs) != 2 || sh.DataNodeIDs[0] == sh.DataNodeIDs[1] {
+			t.Fatalf("unexpected owners: %v", sh.DataNodeIDs)
+		}
+		for _, id := range sh.DataNodeIDs {
+			counts[id]++
+		}
+
+		// The server should only subscribe to the shards it owns.
+		if id, ok := subscriptions[sh.ID]; ok != sh.HasDataNodeID(s.ID()) || (ok && id != s.ID()) {
+			t.Fatalf("unexpected subscription: shard=%d, owners=%v", sh.ID, sh.DataNodeIDs)
+		}
+	}
+	if !reflect.DeepEqual(counts, map[uint64]int{1: 2, 2: 2, 3: 2}) {
+		t.Fatalf("unexpected shard counts: %v", counts)
+	}
+}
+
+// Ensure the server does not reassign shards whose owners have all been
+// deleted since no data node can copy them.
+func TestServer_RebalanceShards_DeletedDataNode(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDataNode(&url.URL{Host: "127.0.0.1:8081"})
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "bar", Duration: time.Hour, ReplicaN: 1})
+
+	// Create a group with one shard on each node.
+	if err := s.CreateShardGroupIfNotExists("foo", "bar", mustParseTime("2000-01-01T00:30:00Z")); err != nil {
+		t.Fatal(err)
+	}
+	a, err := s.ShardGroups("foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(a) != 1 || len(a[0].Shards) != 2 {
+		t.Fatalf("unexpected shard groups: %s", mustMarshalJSON(a))
+	}
+	orphan := a[0].Shards[1]
+	if !reflect.DeepEqual(orphan.DataNodeIDs, []uint64{2}) {
+		t.Fatalf("unexpected owners: %v", orphan.DataNodeIDs)
+	}
+
+	// Track the shards this server subscribes to.
+	var subscriptions []uint64
+	c.SubscribeFunc = func(replicaID, topicID uint64) error {
+		subscriptions = append(subscriptions, topicID)
+		return nil
+	}
+
+	// Remove the second node and rebalance.
+	if err := s.DeleteDataNode(2); err != nil {
+		t.Fatal(err)
+	}
+	results := s.ExecuteQuery(MustParseQuery(`REBALANCE SHARDS`), "foo", nil)
+	if res := results.Results[0]; res.Err == nil || !strings.Contains(res.Err.Error(), influxdb.ErrNoShardSource.Error()) {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// The orphaned shard should keep its owner and not be opened locally.
+	if !reflect.DeepEqual(orphan.DataNodeIDs, []uint64{2}) {
+		t.Fatalf("unexpected owners: %v", orphan.DataNodeIDs)
+	} else if len(subscriptions) != 0 {
+		t.Fatalf("unexpected subscriptions: %v", subscriptions)
+	}
+}
+
+// Ensure the server moves shards to a new data node and removes its own copy
+// once the new node has copied the shard.
+func TestServer_RebalanceShards_NewDataNode(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "bar", Duration: time.Hour, ReplicaN: 1})
+
+	// Create two groups while the server is the only data node.
+	for i := 0; i < 2; i++ {
+		if err := s.CreateShardGroupIfNotExists("foo", "bar", mustParseTime("2000-01-01T00:30:00Z").Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Add a data node that accepts requests to copy shards.
+	var requests []*url.URL
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	if err := s.CreateDataNode(u); err != nil {
+		t.Fatal(err)
+	}
+
+	// Track the shards this server unsubscribes from.
+	var unsubscriptions []uint64
+	c.UnsubscribeFunc = func(replicaID, topicID uint64) error {
+		unsubscriptions = append(unsubscriptions, topicID)
+		return nil
+	}
+
+	if err := s.RebalanceShards(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first shard should move to the new node.
+	a, err := s.ShardGroups("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved, kept := a[0].Shards[0], a[1].Shards[0]
+	if !reflect.DeepEqual(moved.DataNodeIDs, []uint64{2}) {
+		t.Fatalf("unexpected owners: %v", moved.DataNodeIDs)
+	} else if !reflect.DeepEqual(kept.DataNodeIDs, []uint64{1}) {
+		t.Fatalf("unexpected owners: %v", kept.DataNodeIDs)
+	}
+
+	// The new node should be asked to copy the shard from the server.
+	if len(requests) != 1 {
+		t.Fatalf("unexpected request count: %d", len(requests))
+	} else if requests[0].Path != fmt.Sprintf("/shards/%d/replicate", moved.ID) || requests[0].Query().Get("source") != "1" {
+		t.Fatalf("unexpected request: %s", requests[0])
+	}
+
+	// The server should remove its copy of the moved shard.
+	if !reflect.DeepEqual(unsubscriptions, []uint64{moved.ID}) {
+		t.Fatalf("unexpected unsubscriptions: %v", unsubscriptions)
+	} else if _, err := os.Stat(filepath.Join(s.Path(), "shards", strconv.FormatUint(moved.ID, 10))); !os.IsNotExist(err) {
+		t.Fatalf("expected shard to be removed: %v", err)
+	}
+
+	// Rebalancing again should not move any shards.
+	requests = nil
+	if err := s.RebalanceShards(); err != nil {
+		t.Fatal(err)
+	} else if len(requests) != 0 {
+		t.Fatalf("unexpected request count: %d", len(requests))
+	}
+}
+
 func TestServer_DeleteShardGroup(t *testing.T) {
 	s := OpenServer(NewMessagingClient())
 	defer s.Close()
@@ -1383,6 +2267,8 @@ type MessagingClient struct {
 	DeleteReplicaFunc func(replicaID uint64) error
 	SubscribeFunc     func(replicaID, topicID uint64) error
 	UnsubscribeFunc   func(replicaID, topicID uint64) error
+	HeartbeatFunc     func(replicaID uint64, indexes map[uint64]uint64) error
+	ReplicaStatusFunc func() ([]*messaging.ReplicaStatus, error)
 }
 
 // NewMessagingClient returns a new instance of MessagingClient.
@@ -1393,6 +2279,8 @@ func NewMessagingClient() *MessagingClient {
 	c.DeleteReplicaFunc = func(replicaID uint64) error { return nil }
 	c.SubscribeFunc = func(replicaID, topicID uint64) error { return nil }
 	c.UnsubscribeFunc = func(replicaID, topicID uint64) error { return nil }
+	c.HeartbeatFunc = func(replicaID uint64, indexes map[uint64]uint64) error { return nil }
+	c.ReplicaStatusFunc = func() ([]*messaging.ReplicaStatus, error) { return nil, nil }
 	return c
 }
 
@@ -1431,6 +2319,16 @@ func (c *MessagingClient) Unsubscribe(replicaID, topicID uint64) error {
 	return c.UnsubscribeFunc(replicaID, topicID)
 }
 
+// Heartbeat reports the applied index for each topic to the broker.
+func (c *MessagingClient) Heartbeat(replicaID uint64, indexes map[uint64]uint64) error {
+	return c.HeartbeatFunc(replicaID, indexes)
+}
+
+// ReplicaStatus returns the replication status of every replica on the broker.
+func (c *MessagingClient) ReplicaStatus() ([]*messaging.ReplicaStatus, error) {
+	return c.ReplicaStatusFunc()
+}
+
 // C returns a channel for streaming message.
 func (c *MessagingClient) C() <-chan *messaging.Message { return c.c }
 
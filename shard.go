@@ -4,6 +4,8 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/boltdb/bolt"
@@ -36,7 +38,11 @@ type Shard struct {
 	ID          uint64   `json:"id,omitempty"`
 	DataNodeIDs []uint64 `json:"nodeIDs,omitempty"` // owners
 
-	store *bolt.DB
+	database string // owning database name
+	store    *bolt.DB
+
+	mu      sync.Mutex     // protects store while beginning reads
+	readers sync.WaitGroup // open read transactions
 }
 
 // newShardGroup returns a new initialized ShardGroup instance.
@@ -45,6 +51,16 @@ func newShardGroup() *ShardGroup { return &ShardGroup{} }
 // Duration returns the duration between the shard group's start and end time.
 func (g *ShardGroup) Duration() time.Duration { return g.EndTime.Sub(g.StartTime) }
 
+// Shard storage format versions.
+const (
+	// shardFormatPoints stores a key per point in each series bucket. Values
+	// are encoded by the FieldCodec of the series' measurement.
+	shardFormatPoints = 1
+
+	// shardFormatBlocks stores the points of each series in compressed blocks.
+	shardFormatBlocks = 2
+)
+
 // newShard returns a new initialized Shard instance.
 func newShard() *Shard { return &Shard{} }
 
@@ -62,9 +78,16 @@ func (s *Shard) open(path string) error {
 	}
 	s.store = store
 
-	// Initialize store.
+	// Initialize store. New shards are marked with the current format.
+	// Shards with data but no format were written before blocks were used.
 	if err := s.store.Update(func(tx *bolt.Tx) error {
 		_, _ = tx.CreateBucketIfNotExists([]byte("values"))
+		b, err := tx.CreateBucketIfNotExists([]byte("format"))
+		if err != nil {
+			return err
+		} else if b.Get([]byte("version")) == nil && !hasSeriesBuckets(tx) {
+			return b.Put([]byte("version"), u64tob(shardFormatBlocks))
+		}
 		return nil
 	}); err != nil {
 		_ = s.close()
@@ -74,6 +97,74 @@ func (s *Shard) open(path string) error {
 	return nil
 }
 
+// migrate converts a shard written in an older format to the current format.
+// The codec function returns the codec for a series or nil if the series no
+// longer exists, in which case the series data is removed.
+func (s *Shard) migrate(codec func(seriesID uint32) *FieldCodec) error {
+	return s.store.Update(func(tx *bolt.Tx) error {
+		if formatVersion(tx) == shardFormatBlocks {
+			return nil
+		}
+
+		// Collect the series ids since buckets cannot be replaced while iterating.
+		var ids []uint32
+		_ = tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if len(name) == 4 {
+				ids = append(ids, btou32(name))
+			}
+			return nil
+		})
+
+		for _, id := range ids {
+			// Decode the point stored under each timestamp key.
+			var points []blockPoint
+			if c := codec(id); c != nil {
+				if err := tx.Bucket(u32tob(id)).ForEach(func(k, v []byte) error {
+					points = append(points, blockPoint{timestamp: int64(btou64(k)), values: c.DecodeFields(v)})
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+
+			// Replace the series bucket with one containing blocks.
+			if err := tx.DeleteBucket(u32tob(id)); err != nil {
+				return err
+			} else if len(points) == 0 {
+				continue
+			}
+			b, err := tx.CreateBucket(u32tob(id))
+			if err != nil {
+				return err
+			} else if err := writeBlocks(b, points, true); err != nil {
+				return err
+			}
+		}
+
+		return tx.Bucket([]byte("format")).Put([]byte("version"), u64tob(shardFormatBlocks))
+	})
+}
+
+// formatVersion returns the storage format of a shard store.
+func formatVersion(tx *bolt.Tx) int {
+	if b := tx.Bucket([]byte("format")); b != nil {
+		if v := b.Get([]byte("version")); v != nil {
+			return int(btou64(v))
+		}
+	}
+	return shardFormatPoints
+}
+
+// hasSeriesBuckets returns true if a shard store contains any series buckets.
+// Series buckets are keyed by the series id.
+func hasSeriesBuckets(tx *bolt.Tx) (found bool) {
+	_ = tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		found = found || len(name) == 4
+		return nil
+	})
+	return
+}
+
 // close shuts down the shard's store.
 func (s *Shard) close() error {
 	if s.store == nil {
@@ -82,6 +173,51 @@ func (s *Shard) close() error {
 	return s.store.Close()
 }
 
+// beginRead starts a read transaction on the shard's store. The transaction
+// must be ended with endRead so that the store is not closed while it is read.
+func (s *Shard) beginRead() (*bolt.Tx, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.store == nil {
+		return nil, ErrShardNotFound
+	}
+
+	tx, err := s.store.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	s.readers.Add(1)
+	return tx, nil
+}
+
+// endRead rolls back a read transaction started by beginRead.
+func (s *Shard) endRead(tx *bolt.Tx) error {
+	defer s.readers.Done()
+	return tx.Rollback()
+}
+
+// detach removes the store from the shard so that no new reads can begin.
+// Returns the previous store, which must be passed to drop.
+func (s *Shard) detach() *bolt.DB {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store := s.store
+	s.store = nil
+	return store
+}
+
+// drop waits for open reads of a detached store to end and then closes the
+// store and deletes its file.
+func (s *Shard) drop(store *bolt.DB) error {
+	s.readers.Wait()
+
+	path := store.Path()
+	if err := store.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
 // HasDataNodeID return true if the data node owns the shard.
 func (s *Shard) HasDataNodeID(id uint64) bool {
 	for _, dataNodeID := range s.DataNodeIDs {
@@ -92,6 +228,48 @@ func (s *Shard) HasDataNodeID(id uint64) bool {
 	return false
 }
 
+// merge writes the series data from another shard store at path into the
+// shard. Points that already exist in the shard are kept. Returns the ids of
+// the merged series.
+func (s *Shard) merge(path string) (ids []uint32, err error) {
+	other, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = other.Close() }()
+
+	err = other.View(func(tx *bolt.Tx) error {
+		if v := formatVersion(tx); v != shardFormatBlocks {
+			return fmt.Errorf("unsupported shard format: %d", v)
+		}
+
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			// Series buckets are keyed by the series id.
+			if len(name) != 4 {
+				return nil
+			}
+
+			// Decode every block in the series.
+			var points []blockPoint
+			if err := b.ForEach(func(k, v []byte) error {
+				a, err := unmarshalBlock(v)
+				if err != nil {
+					return err
+				}
+				points = append(points, a...)
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			seriesID := btou32(name)
+			ids = append(ids, seriesID)
+			return s.writeSeriesBatch(map[uint32][]blockPoint{seriesID: points}, false)
+		})
+	})
+	return
+}
+
 // readSeries reads encoded series data from a shard.
 func (s *Shard) readSeries(seriesID uint32, timestamp int64) (values []byte, err error) {
 	err = s.store.View(func(tx *bolt.Tx) error {
@@ -101,33 +279,75 @@ func (s *Shard) readSeries(seriesID uint32, timestamp int64) (values []byte, err
 			return nil
 		}
 
-		// Retrieve encoded series data.
-		values = b.Get(u64tob(uint64(timestamp)))
+		// Find the block containing the timestamp.
+		k, v := seekBlock(b.Cursor(), timestamp)
+		if k == nil {
+			return nil
+		}
+
+		// Decode the block and retrieve the point.
+		points, err := unmarshalBlock(v)
+		if err != nil {
+			return err
+		}
+		if i := blockPoints(points).search(timestamp); i < len(points) && points[i].timestamp == timestamp {
+			values = marshalFieldValues(points[i].values)
+		}
 		return nil
 	})
 	return
 }
 
 // writeSeries writes series data to a shard.
-func (s *Shard) writeSeries(seriesID uint32, timestamp int64, values []byte, overwrite bool) error {
+func (s *Shard) writeSeries(seriesID uint32, timestamp int64, values map[uint8]interface{}, overwrite bool) error {
+	return s.writeSeriesBatch(map[uint32][]blockPoint{seriesID: {{timestamp: timestamp, values: values}}}, overwrite)
+}
+
+// writeSeriesBatch writes points for multiple series to a shard in a single transaction.
+func (s *Shard) writeSeriesBatch(points map[uint32][]blockPoint, overwrite bool) error {
 	return s.store.Update(func(tx *bolt.Tx) error {
-		// Create a bucket for the series.
-		b, err := tx.CreateBucketIfNotExists(u32tob(seriesID))
-		if err != nil {
-			return err
-		}
+		for seriesID, a := range points {
+			// Create a bucket for the series.
+			b, err := tx.CreateBucketIfNotExists(u32tob(seriesID))
+			if err != nil {
+				return err
+			}
 
-		// Insert the values by timestamp.
-		if err := b.Put(u64tob(uint64(timestamp)), values); err != nil {
-			return err
+			// Merge the values into the series blocks.
+			if err := writeBlocks(b, a, overwrite); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+}
 
+// deleteSeries removes all data for a set of series from a shard.
+func (s *Shard) deleteSeries(seriesIDs []uint32) error {
+	return s.store.Update(func(tx *bolt.Tx) error {
+		for _, id := range seriesIDs {
+			if err := tx.DeleteBucket(u32tob(id)); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
 		return nil
 	})
 }
 
-func (s *Shard) deleteSeries(name string) error {
-	panic("not yet implemented") // TODO
+// deleteSeriesRange removes data between min and max, inclusive, for a set of series from a shard.
+func (s *Shard) deleteSeriesRange(seriesIDs []uint32, min, max int64) error {
+	return s.store.Update(func(tx *bolt.Tx) error {
+		for _, id := range seriesIDs {
+			b := tx.Bucket(u32tob(id))
+			if b == nil {
+				continue
+			}
+			if err := deleteBlockRange(b, min, max); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // Shards represents a list of shards.
@@ -151,6 +371,30 @@ func unmarshalPointHeader(b []byte) (seriesID uint32, timestamp int64) {
 	return
 }
 
+// appendPointBatch appends a length-prefixed encoded point to a batch.
+func appendPointBatch(b []byte, data []byte) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(len(data)))
+	b = append(b, buf[:]...)
+	return append(b, data...)
+}
+
+// unmarshalPointBatch splits a batch into its individual encoded points.
+func unmarshalPointBatch(b []byte) ([][]byte, error) {
+	var a [][]byte
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, ErrInvalidPointBatch
+		}
+		n := int(binary.BigEndian.Uint32(b[0:4]))
+		if n < pointHeaderSize || len(b)-4 < n {
+			return nil, ErrInvalidPointBatch
+		}
+		a, b = append(a, b[4:4+n]), b[4+n:]
+	}
+	return a, nil
+}
+
 type uint8Slice []uint8
 
 func (p uint8Slice) Len() int           { return len(p) }
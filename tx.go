@@ -2,6 +2,7 @@ package influxdb
 
 import (
 	"fmt"
+	"net/url"
 	"sync"
 	"time"
 
@@ -121,16 +122,27 @@ func (tx *tx) CreateIterators(stmt *influxql.SelectStatement) ([]influxql.Iterat
 		return nil, ErrMeasurementNotFound
 	}
 
-	// Find field.
-	fieldName := stmt.Fields[0].Expr.(*influxql.VarRef).Val
-	f := m.FieldByName(fieldName)
-	if f == nil {
-		return nil, fmt.Errorf("field not found: %s", fieldName)
+	// Find the selected fields.
+	names := make([]string, len(stmt.Fields))
+	for i, field := range stmt.Fields {
+		ref, ok := field.Expr.(*influxql.VarRef)
+		if !ok {
+			return nil, fmt.Errorf("expected field reference: %s", field.Expr)
+		}
+		names[i] = ref.Val
+	}
+	f, columns, err := m.selectColumns(names)
+	if err != nil {
+		return nil, err
 	}
 	tagSets := m.tagSets(stmt, dimensions)
 
-	// Get a field decoder.
-	d := NewFieldCodec(m)
+	// Determine the read direction and the most points needed from each iterator.
+	descending := !stmt.TimeAscending()
+	var limit int
+	if stmt.Limit > 0 {
+		limit = stmt.Limit + stmt.Offset
+	}
 
 	// Create an iterator for every shard.
 	var itrs []influxql.Iterator
@@ -138,24 +150,16 @@ func (tx *tx) CreateIterators(stmt *influxql.SelectStatement) ([]influxql.Iterat
 		for _, group := range shardGroups {
 			// TODO: only create iterators for the shards we actually have to hit in a group
 			for _, sh := range group.Shards {
-
-				// create a series cursor for each unique series id
-				cursors := make([]*seriesCursor, 0, len(set))
-				for id, cond := range set {
-					cursors = append(cursors, &seriesCursor{id: id, condition: cond, decoder: d})
+				// Shards owned by other data nodes are mapped by their owners.
+				if sh.store == nil {
+					if itr := tx.newRemoteIterator(stmt, names, group, sh, tag, set, tmin.UnixNano(), tmax.UnixNano(), descending, limit); itr != nil {
+						itrs = append(itrs, itr)
+					}
+					continue
 				}
 
 				// create the shard iterator that will map over all series for the shard
-				itr := &shardIterator{
-					measurement: m,
-					fieldName:   f.Name,
-					fieldID:     f.ID,
-					tags:        tag,
-					db:          sh.store,
-					cursors:     cursors,
-					tmin:        tmin.UnixNano(),
-					tmax:        tmax.UnixNano(),
-				}
+				itr := newShardIterator(m, f, columns, sh, tag, set, tmin.UnixNano(), tmax.UnixNano(), descending, limit)
 
 				// Add to tx so the bolt transaction can be opened/closed.
 				tx.itrs = append(tx.itrs, itr)
@@ -168,6 +172,112 @@ func (tx *tx) CreateIterators(stmt *influxql.SelectStatement) ([]influxql.Iterat
 	return itrs, nil
 }
 
+// newRemoteIterator returns an iterator for the series of a tagset stored in
+// a shard owned by other data nodes. Returns nil if none of the series belong
+// to the shard.
+func (tx *tx) newRemoteIterator(stmt *influxql.SelectStatement, names []string, g *ShardGroup, sh *Shard, tags string, set map[uint32]influxql.Expr, tmin, tmax int64, descending bool, limit int) *remoteIterator {
+	// Only request the series that are stored in the shard.
+	var ids []uint32
+	filters := make(map[uint32]influxql.Expr)
+	for id, cond := range set {
+		if g.ShardBySeriesID(id) != sh {
+			continue
+		}
+		ids = append(ids, id)
+		if cond != nil {
+			filters[id] = cond
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// Find the URLs of the owners.
+	var urls []*url.URL
+	for _, id := range sh.DataNodeIDs {
+		if n := tx.server.dataNodes[id]; n != nil && id != tx.server.id {
+			urls = append(urls, n.URL)
+		}
+	}
+
+	return &remoteIterator{
+		tags: tags,
+		urls: urls,
+		mapper: RemoteMapper{
+			ShardID:     sh.ID,
+			Measurement: stmt.Source.(*influxql.Measurement).Name,
+			Fields:      names,
+			Tags:        tags,
+			SeriesIDs:   ids,
+			Filters:     filters,
+			TMin:        tmin,
+			TMax:        tmax,
+			Descending:  descending,
+			Limit:       limit,
+		},
+	}
+}
+
+// selectColumns finds the fields selected by name. Selecting more than one
+// name reads every column in a single pass and names may also refer to tags.
+// Otherwise the single selected field is returned.
+func (m *Measurement) selectColumns(names []string) (*Field, []rawColumn, error) {
+	if len(names) == 1 {
+		f := m.FieldByName(names[0])
+		if f == nil {
+			return nil, nil, fmt.Errorf("field not found: %s", names[0])
+		}
+		return f, nil, nil
+	}
+
+	var columns []rawColumn
+	for _, name := range names {
+		if f := m.FieldByName(name); f != nil {
+			columns = append(columns, rawColumn{name: f.Name, fieldID: f.ID})
+		} else if _, ok := m.seriesByTagKeyValue[name]; ok {
+			columns = append(columns, rawColumn{name: name, tag: true})
+		} else {
+			return nil, nil, fmt.Errorf("field not found: %s", name)
+		}
+	}
+	return nil, columns, nil
+}
+
+// newShardIterator returns an iterator over a set of series in a local shard.
+func newShardIterator(m *Measurement, f *Field, columns []rawColumn, sh *Shard, tags string, set map[uint32]influxql.Expr, tmin, tmax int64, descending bool, limit int) *shardIterator {
+	// Copy field names so conditions can be evaluated against every field of a point.
+	fieldNames := make(map[uint8]string, len(m.Fields))
+	for _, f := range m.Fields {
+		fieldNames[f.ID] = f.Name
+	}
+
+	// create a series cursor for each unique series id
+	cursors := make([]*seriesCursor, 0, len(set))
+	for id, cond := range set {
+		c := &seriesCursor{id: id, condition: cond, fieldNames: fieldNames, descending: descending}
+		if columns != nil {
+			c.tags = m.seriesByID[id].Tags
+		}
+		cursors = append(cursors, c)
+	}
+
+	itr := &shardIterator{
+		measurement: m,
+		columns:     columns,
+		tags:        tags,
+		shard:       sh,
+		cursors:     cursors,
+		tmin:        tmin,
+		tmax:        tmax,
+		descending:  descending,
+		limit:       limit,
+	}
+	if f != nil {
+		itr.fieldName, itr.fieldID = f.Name, f.ID
+	}
+	return itr
+}
+
 // splitIdent splits an identifier into it's database, policy, and measurement parts.
 func splitIdent(s string) (db, rp, m string, err error) {
 	a, err := influxql.SplitIdent(s)
@@ -183,18 +293,22 @@ func splitIdent(s string) (db, rp, m string, err error) {
 type shardIterator struct {
 	fieldName   string
 	fieldID     uint8
+	columns     []rawColumn // fields and tags read by multi-field queries
 	measurement *Measurement
 	tags        string // encoded dimensional tag values
 	cursors     []*seriesCursor
 	keyValues   []keyValue
-	db          *bolt.DB // data stores by shard id
-	txn         *bolt.Tx // read transactions by shard id
+	shard       *Shard   // local shard to read from
+	txn         *bolt.Tx // read transaction on the shard
 	tmin, tmax  int64
+	descending  bool // read points in reverse time order
+	limit       int  // maximum number of points to return, if non-zero
+	n           int  // number of points returned
 }
 
 func (i *shardIterator) open() error {
 	// Open the data store
-	txn, err := i.db.Begin(false)
+	txn, err := i.shard.beginRead()
 	if err != nil {
 		return err
 	}
@@ -212,24 +326,54 @@ func (i *shardIterator) open() error {
 
 	i.keyValues = make([]keyValue, len(i.cursors))
 	for j, cur := range i.cursors {
-		i.keyValues[j].key, i.keyValues[j].data, i.keyValues[j].value = cur.Next(i.fieldName, i.fieldID, i.tmin, i.tmax)
+		i.keyValues[j].key, i.keyValues[j].data, i.keyValues[j].value = i.next(cur)
 	}
 
 	return nil
 }
 
+// next reads the next value from a cursor. Iterators over multiple columns
+// return a slice of values for each point.
+func (i *shardIterator) next(c *seriesCursor) (key int64, data []byte, value interface{}) {
+	if i.columns != nil {
+		return c.NextRow(i.columns, i.tmin, i.tmax)
+	}
+	return c.Next(i.fieldID, i.tmin, i.tmax)
+}
+
 func (i *shardIterator) close() error {
-	_ = i.txn.Rollback()
+	if i.txn != nil {
+		_ = i.shard.endRead(i.txn)
+		i.txn = nil
+	}
 	return nil
 }
 
 func (i *shardIterator) Tags() string { return i.tags }
 
+// Err returns the first error that occurred while reading the shard, if any.
+func (i *shardIterator) Err() error {
+	for _, c := range i.cursors {
+		if c.err != nil {
+			return c.err
+		}
+	}
+	return nil
+}
+
 func (i *shardIterator) Next() (key int64, data []byte, value interface{}) {
-	min := -1
+	// Stop once the limit has been reached.
+	if i.limit > 0 && i.n >= i.limit {
+		return 0, nil, nil
+	}
 
+	// Find the cursor with the next key in the read direction.
+	min := -1
 	for ind, kv := range i.keyValues {
-		if kv.key != 0 && kv.key < i.tmax {
+		if kv.key == 0 || kv.key >= i.tmax {
+			continue
+		}
+		if min == -1 || (!i.descending && kv.key < i.keyValues[min].key) || (i.descending && kv.key > i.keyValues[min].key) {
 			min = ind
 		}
 	}
@@ -238,13 +382,14 @@ func (i *shardIterator) Next() (key int64, data []byte, value interface{}) {
 	if min == -1 {
 		return 0, nil, nil
 	}
+	i.n++
 
 	kv := i.keyValues[min]
 	key = kv.key
 	data = kv.data
 	value = kv.value
 
-	i.keyValues[min].key, i.keyValues[min].data, i.keyValues[min].value = i.cursors[min].Next(i.fieldName, i.fieldID, i.tmin, i.tmax)
+	i.keyValues[min].key, i.keyValues[min].data, i.keyValues[min].value = i.next(i.cursors[min])
 	return key, data, value
 }
 
@@ -254,63 +399,164 @@ type keyValue struct {
 	value interface{}
 }
 
-type fieldDecoder interface {
-	DecodeByID(fieldID uint8, b []byte) (interface{}, error)
+// rawColumn represents a field or tag selected by a multi-field query.
+type rawColumn struct {
+	name    string
+	fieldID uint8
+	tag     bool
 }
 
 type seriesCursor struct {
 	id          uint32
-	condition   influxql.Expr
+	condition   influxql.Expr     // field condition evaluated for each point
+	fieldNames  map[uint8]string  // field names by id
+	tags        map[string]string // series tags, used for selected tag columns
 	cur         *bolt.Cursor
 	initialized bool
-	decoder     fieldDecoder
+	descending  bool         // read blocks and points in reverse time order
+	points      []blockPoint // decoded points from the current block
+	index       int          // number of points read from the current block
+	err         error        // error from decoding a block
 }
 
-func (c *seriesCursor) Next(fieldName string, fieldID uint8, tmin, tmax int64) (key int64, data []byte, value interface{}) {
-	// TODO: clean this up when we make it so series ids are only queried against the shards they exist in.
-	//       Right now we query for all series ids on a query against each shard, even if that shard may not have the
-	//       data, so cur could be nil.
-	if c.cur == nil {
-		return 0, nil, nil
-	}
-
+// Next returns the next value for a single field. Encoded point data is not
+// returned since values are decoded from blocks.
+func (c *seriesCursor) Next(fieldID uint8, tmin, tmax int64) (key int64, data []byte, value interface{}) {
 	for {
-		var k, v []byte
-		if !c.initialized {
-			k, v = c.cur.Seek(u64tob(uint64(tmin)))
-			c.initialized = true
-		} else {
-			k, v = c.cur.Next()
+		p, ok := c.nextPoint(tmin, tmax)
+		if !ok {
+			return 0, nil, nil
 		}
 
-		// Exit if there is no more data.
-		if k == nil {
-			return 0, nil, nil
+		// Skip to the next if we don't have a field value for this field for this point
+		value := p.values[fieldID]
+		if value == nil {
+			continue
 		}
 
-		// Marshal key & value.
-		key := int64(btou64(k))
-		value, err := c.decoder.DecodeByID(fieldID, v)
-		if err != nil {
+		// Move to next key/value if the condition is not met.
+		if !c.match(p) {
 			continue
 		}
 
-		if key > tmax {
+		return p.timestamp, nil, value
+	}
+}
+
+// NextRow returns the next values for a set of columns. Points without a
+// value for any of the selected fields are skipped. Encoded point data is not
+// returned.
+func (c *seriesCursor) NextRow(columns []rawColumn, tmin, tmax int64) (key int64, data []byte, value interface{}) {
+	for {
+		p, ok := c.nextPoint(tmin, tmax)
+		if !ok {
 			return 0, nil, nil
 		}
 
-		// Skip to the next if we don't have a field value for this field for this point
-		if value == nil {
+		// Read each column from the point or the series tags.
+		values := make([]interface{}, len(columns))
+		var found bool
+		for j, col := range columns {
+			if col.tag {
+				if v, ok := c.tags[col.name]; ok {
+					values[j] = v
+				}
+			} else if v := p.values[col.fieldID]; v != nil {
+				values[j] = v
+				found = true
+			}
+		}
+		if !found {
+			continue
+		}
+
+		// Move to next key/value if the condition is not met.
+		if !c.match(p) {
 			continue
 		}
 
-		// Evaluate condition. Move to next key/value if non-true.
-		if c.condition != nil {
-			if ok, _ := influxql.Eval(c.condition, map[string]interface{}{fieldName: value}).(bool); !ok {
+		return p.timestamp, nil, values
+	}
+}
+
+// match returns true if the point's field values satisfy the cursor's condition.
+func (c *seriesCursor) match(p blockPoint) bool {
+	if c.condition == nil {
+		return true
+	}
+
+	values := make(map[string]interface{}, len(p.values))
+	for id, v := range p.values {
+		values[c.fieldNames[id]] = v
+	}
+
+	ok, _ := influxql.Eval(c.condition, values).(bool)
+	return ok
+}
+
+// nextPoint returns the next point within the time range in the read direction.
+// Reading stops and the error is saved on the cursor if a block cannot be decoded.
+func (c *seriesCursor) nextPoint(tmin, tmax int64) (blockPoint, bool) {
+	// TODO: clean this up when we make it so series ids are only queried against the shards they exist in.
+	//       Right now we query for all series ids on a query against each shard, even if that shard may not have the
+	//       data, so cur could be nil.
+	if c.cur == nil || c.err != nil {
+		return blockPoint{}, false
+	}
+
+	for {
+		// Read the next block once the current block is exhausted.
+		if c.index >= len(c.points) {
+			var k, v []byte
+			if !c.initialized {
+				if c.descending {
+					k, v = seekBlock(c.cur, tmax)
+				} else if k, v = seekBlock(c.cur, tmin); k == nil {
+					k, v = c.cur.First()
+				}
+				c.initialized = true
+			} else if c.descending {
+				k, v = c.cur.Prev()
+			} else {
+				k, v = c.cur.Next()
+			}
+
+			// Exit if there is no more data.
+			if k == nil {
+				return blockPoint{}, false
+			}
+
+			// Decode the block.
+			points, err := unmarshalBlock(v)
+			if err != nil {
+				c.err = fmt.Errorf("series %d: %s", c.id, err)
+				return blockPoint{}, false
+			}
+			c.points, c.index = points, 0
+			continue
+		}
+
+		// Read points from the end of the block when iterating in reverse.
+		p := c.points[c.index]
+		if c.descending {
+			p = c.points[len(c.points)-1-c.index]
+		}
+		c.index++
+
+		// Skip points before the start of the time range.
+		// Exit once the end of the time range has been passed.
+		if c.descending {
+			if p.timestamp > tmax {
 				continue
+			} else if p.timestamp < tmin {
+				return blockPoint{}, false
 			}
+		} else if p.timestamp < tmin {
+			continue
+		} else if p.timestamp > tmax {
+			return blockPoint{}, false
 		}
 
-		return key, v, value
+		return p, true
 	}
 }